@@ -1,7 +1,9 @@
 package data
 
+import "context"
+
 type Inpteval interface {
-	Eval(in map[string]interface{}, serverUrl string) (map[string]string, error)
+	Eval(ctx context.Context, in map[string]interface{}, serverUrl string) (map[string]string, error)
 	BuildAggregatedContent(items []map[string]string) (map[string]string, error)
 	IsAggregationSupported() bool
 }
@@ -0,0 +1,56 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+)
+
+const propSep = "."
+
+// JoinProps returns values of input properties joined by "-". A property is a path like "vulnerability_summary.critical",
+// missing properties are skipped.
+func JoinProps(in map[string]interface{}, props []string) string {
+	values := make([]string, 0)
+	for _, prop := range props {
+		v := Prop(in, prop)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return strings.Join(values, "-")
+}
+
+// Prop returns the value of an input property given by its path, or "" if there is no such property
+func Prop(in map[string]interface{}, prop string) string {
+	return getSingleValue(in, strings.Split(prop, propSep))
+}
+
+func getSingleValue(o interface{}, parts []string) string {
+	in, ok := o.(map[string]interface{})
+
+	if !ok {
+		return ""
+	}
+
+	if len(parts) == 1 {
+		v, ok := in[parts[0]]
+		if ok {
+			return fmt.Sprintf("%v", v)
+		}
+	} else {
+		part := parts[0]
+		v, ok := in[part]
+		if ok {
+			switch x := v.(type) {
+			case map[string]interface{}:
+				return getSingleValue(x, parts[1:])
+			case []map[string]interface{}:
+				if len(x) > 0 {
+					return getSingleValue(x[0], parts[1:]) //re-iterate with first element
+				}
+			}
+		}
+
+	}
+	return ""
+}
@@ -2,22 +2,22 @@ package dbservice
 
 import (
 	"bytes"
-	"log"
 	"time"
 
+	"github.com/aquasecurity/postee/v2/logging"
 	bolt "go.etcd.io/bbolt"
 )
 
-func CheckSizeLimit() {
-	if DbSizeLimit == 0 {
+func (s *Store) CheckSizeLimit() {
+	if s.sizeLimit() == 0 {
 		return
 	}
-	mutex.Lock()
-	defer mutex.Unlock()
+	s.lock().Lock()
+	defer s.lock().Unlock()
 
-	db, err := bolt.Open(DbPath, 0666, nil)
+	db, err := bolt.Open(s.path(), 0666, nil)
 	if err != nil {
-		log.Println("CheckSizeLimit: Can't open db:", DbPath)
+		logging.Errorf("CheckSizeLimit: Can't open db: %s", s.path())
 		return
 	}
 	defer db.Close()
@@ -32,35 +32,35 @@ func CheckSizeLimit() {
 		for k, v := c.First(); k != nil; k, v = c.Next() {
 			size += len(v)
 		}
-		if size > DbSizeLimit {
+		if size > s.sizeLimit() {
 			return tx.DeleteBucket([]byte(dbBucketName))
 		}
 		return nil
 	}); err != nil {
-		log.Println("Error a check of db size:", err)
+		logging.Errorf("Error a check of db size: %v", err)
 		return
 	}
 }
 
-func CheckExpiredData() {
-	mutex.Lock()
-	defer mutex.Unlock()
+func (s *Store) CheckExpiredData() {
+	s.lock().Lock()
+	defer s.lock().Unlock()
 
-	db, err := bolt.Open(DbPath, 0666, nil)
+	db, err := bolt.Open(s.path(), 0666, nil)
 	if err != nil {
-		log.Println("CheckExpiredData: Can't open db:", DbPath)
+		logging.Errorf("CheckExpiredData: Can't open db: %s", s.path())
 		return
 	}
 	defer db.Close()
 
 	expired, err := getExpired(db)
 	if err != nil {
-		log.Println("Can't select expired data: ", err)
+		logging.Errorf("Can't select expired data: %v", err)
 		return
 	}
 
 	if err := dbDelete(db, dbBucketName, expired); err != nil {
-		log.Println("Can't remove expired data: ", err)
+		logging.Errorf("Can't remove expired data: %v", err)
 	}
 }
 
@@ -6,14 +6,14 @@ import (
 	bolt "go.etcd.io/bbolt"
 )
 
-func AggregateScans(output string,
+func (s *Store) AggregateScans(output string,
 	currentScan map[string]string,
 	scansPerTicket int,
 	ignoreTheQuantity bool) ([]map[string]string, error) {
-	mutex.Lock()
-	defer mutex.Unlock()
+	s.lock().Lock()
+	defer s.lock().Unlock()
 
-	db, err := bolt.Open(DbPath, 0666, nil)
+	db, err := bolt.Open(s.path(), 0666, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -60,3 +60,37 @@ func AggregateScans(output string,
 	}
 	return aggregatedScans, nil
 }
+
+// GetAggregationQueueDepths returns the number of messages waiting in every aggregation queue
+func (s *Store) GetAggregationQueueDepths() (map[string]int, error) {
+	s.lock().Lock()
+	defer s.lock().Unlock()
+
+	db, err := bolt.Open(s.path(), 0666, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	depths := make(map[string]int)
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(dbBucketAggregator))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var scans []map[string]string
+			if len(v) > 0 {
+				if err := json.Unmarshal(v, &scans); err != nil {
+					return err
+				}
+			}
+			depths[string(k)] = len(scans)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return depths, nil
+}
@@ -92,3 +92,33 @@ func TestAggregateScans(t *testing.T) {
 		t.Errorf("Wrong Description\nResult: %q\nWaited: %q", lastScan[0]["description"], scan4["description"])
 	}
 }
+
+func TestGetAggregationQueueDepths(t *testing.T) {
+	dbPathReal := DbPath
+	defer func() {
+		os.Remove(DbPath)
+		DbPath = dbPathReal
+	}()
+	DbPath = "test_webhooks.db"
+
+	scan := map[string]string{"title": "t1"}
+	for i := 0; i < 2; i++ {
+		if _, err := AggregateScans("route1", scan, 3, false); err != nil {
+			t.Fatalf("AggregateScans Error: %v", err)
+		}
+	}
+	if _, err := AggregateScans("route2", scan, 0, true); err != nil {
+		t.Fatalf("AggregateScans Error: %v", err)
+	}
+
+	depths, err := GetAggregationQueueDepths()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]int{"route1": 2, "route2": 1}
+	for route, depth := range expected {
+		if depths[route] != depth {
+			t.Errorf("Wrong depth of %q\nResult: %d\nWaited: %d", route, depths[route], depth)
+		}
+	}
+}
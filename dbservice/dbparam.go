@@ -1,11 +1,12 @@
 package dbservice
 
 import (
-	"log"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/aquasecurity/postee/v2/logging"
 )
 
 var (
@@ -14,13 +15,15 @@ var (
 	dbBucketExpiryDates  = "WebookExpiryDates"
 	DbBucketOutputStats  = "WebhookOutputStats"
 	DbBucketSharedConfig = "WebhookSharedConfig"
+	dbBucketOutbox       = "WebhookOutbox"
+	dbBucketDeadLetters  = "WebhookDeadLetters"
 
 	DbSizeLimit = 0
 	dueTimeBase = time.Hour * time.Duration(24)
 	DateFmt     = time.RFC3339Nano
 
 	DbPath = "/server/database/webhooks.db"
-	mutex  sync.Mutex
+	mutex  sync.Mutex //lock of the default database
 )
 
 func ChangeDbPath(newPath string) {
@@ -29,6 +32,18 @@ func ChangeDbPath(newPath string) {
 	mutex.Unlock()
 }
 
+// GetDbSize returns the size of the database file in bytes
+func (s *Store) GetDbSize() (int64, error) {
+	s.lock().Lock()
+	defer s.lock().Unlock()
+
+	info, err := os.Stat(s.path())
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
 func SetNewDbPathFromEnv() {
 	newPath := os.Getenv("PATH_TO_DB")
 	if newPath != "" {
@@ -36,11 +51,11 @@ func SetNewDbPathFromEnv() {
 			if os.IsNotExist(err) {
 				err = os.MkdirAll(filepath.Dir(newPath), os.ModePerm)
 				if err != nil {
-					log.Printf("Can't create DateBase directory: %v, the default path is used", err)
+					logging.Errorf("Can't create DateBase directory: %v, the default path is used", err)
 					return
 				}
 			} else {
-				log.Printf("Can't check DateBase directory: %v, the default path is used", err)
+				logging.Errorf("Can't check DateBase directory: %v, the default path is used", err)
 				return
 			}
 		}
@@ -2,7 +2,9 @@ package dbservice
 
 import (
 	"errors"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -210,3 +212,34 @@ func testBucketInsert(t *testing.T, testBucket string) {
 		t.Errorf("Unexpected error: expected %s, got %s \n", insertErr, err)
 	}
 }
+
+func TestStoresAreLockedApart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "postee-stores")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	busy, err := NewStore(filepath.Join(dir, "busy.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewStore(filepath.Join(dir, "other.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	busy.lock().Lock()
+	defer busy.lock().Unlock()
+	done := make(chan error, 1)
+	go func() {
+		done <- other.SaveOutboxMessage(&OutboxMessage{Output: "my-slack"})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Store waits for the lock of another store")
+	}
+}
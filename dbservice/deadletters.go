@@ -0,0 +1,156 @@
+package dbservice
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// MoveToDeadLetters removes a message which exhausted its delivery attempts from the outbox and keeps it as a dead letter
+func (s *Store) MoveToDeadLetters(msg *OutboxMessage) error {
+	s.lock().Lock()
+	defer s.lock().Unlock()
+
+	db, err := bolt.Open(s.path(), 0666, nil)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if msg.Id != "" {
+		if err := dbDelete(db, dbBucketOutbox, [][]byte{[]byte(msg.Id)}); err != nil {
+			return err
+		}
+	}
+	return saveMessage(db, dbBucketDeadLetters, msg)
+}
+
+func (s *Store) GetDeadLetters() ([]*OutboxMessage, error) {
+	s.lock().Lock()
+	defer s.lock().Unlock()
+
+	db, err := bolt.Open(s.path(), 0666, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return selectMessages(db, dbBucketDeadLetters)
+}
+
+// GetDeadLetter returns nil if there is no dead letter with such id
+func (s *Store) GetDeadLetter(id string) (*OutboxMessage, error) {
+	s.lock().Lock()
+	defer s.lock().Unlock()
+
+	db, err := bolt.Open(s.path(), 0666, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	if err = Init(db, dbBucketDeadLetters); err != nil {
+		return nil, err
+	}
+	value, err := dbSelect(db, dbBucketDeadLetters, id)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	msg := &OutboxMessage{}
+	if err := json.Unmarshal(value, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (s *Store) RemoveDeadLetter(id string) error {
+	s.lock().Lock()
+	defer s.lock().Unlock()
+
+	db, err := bolt.Open(s.path(), 0666, nil)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return dbDelete(db, dbBucketDeadLetters, [][]byte{[]byte(id)})
+}
+
+// ReplayDeadLetter moves a dead letter back to the outbox in a single transaction, so it's replayed only once.
+// Attempts of the message are cleared and it's due at once. Nil is returned if there is no dead letter with such id,
+// e.g. it's already replayed.
+func (s *Store) ReplayDeadLetter(id string) (*OutboxMessage, error) {
+	s.lock().Lock()
+	defer s.lock().Unlock()
+
+	db, err := bolt.Open(s.path(), 0666, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var msg *OutboxMessage
+	err = db.Update(func(tx *bolt.Tx) error {
+		deadLetters := tx.Bucket([]byte(dbBucketDeadLetters))
+		if deadLetters == nil {
+			return nil
+		}
+		value := deadLetters.Get([]byte(id))
+		if value == nil {
+			return nil
+		}
+		replayed := &OutboxMessage{}
+		if err := json.Unmarshal(value, replayed); err != nil {
+			return err
+		}
+		replayed.Attempts = nil
+		replayed.NextAttempt = time.Now().UTC()
+		value, err := json.Marshal(replayed)
+		if err != nil {
+			return err
+		}
+		outbox, err := tx.CreateBucketIfNotExists([]byte(dbBucketOutbox))
+		if err != nil {
+			return err
+		}
+		if err := outbox.Put([]byte(id), value); err != nil {
+			return err
+		}
+		if err := deadLetters.Delete([]byte(id)); err != nil {
+			return err
+		}
+		msg = replayed
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// PurgeDeadLetters removes all dead letters and returns their number
+func (s *Store) PurgeDeadLetters() (int, error) {
+	s.lock().Lock()
+	defer s.lock().Unlock()
+
+	db, err := bolt.Open(s.path(), 0666, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	purged := 0
+	err = db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(dbBucketDeadLetters))
+		if b == nil {
+			return nil
+		}
+		purged = b.Stats().KeyN
+		return tx.DeleteBucket([]byte(dbBucketDeadLetters))
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
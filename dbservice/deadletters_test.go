@@ -0,0 +1,133 @@
+package dbservice
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDeadLetters(t *testing.T) {
+	dbPathReal := DbPath
+	defer func() {
+		os.Remove(DbPath)
+		DbPath = dbPathReal
+	}()
+	DbPath = "test_webhooks.db"
+
+	failed := &OutboxMessage{
+		Route:    "route1",
+		Output:   "my-jira",
+		Template: "legacy-jira",
+		Content:  map[string]string{"title": "failed"},
+	}
+	if err := SaveOutboxMessage(failed); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	failed.Attempts = append(failed.Attempts, DeliveryAttempt{Error: "503 Service Unavailable"})
+	if err := MoveToDeadLetters(failed); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	pending, err := GetOutboxMessages()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("Message isn't removed from the outbox")
+	}
+
+	msg, err := GetDeadLetter(failed.Id)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if msg == nil {
+		t.Fatalf("Dead letter %s isn't found", failed.Id)
+	}
+	if msg.Output != "my-jira" || msg.Template != "legacy-jira" || msg.LastError() != "503 Service Unavailable" {
+		t.Errorf("Unexpected dead letter %#v", msg)
+	}
+
+	missing, err := GetDeadLetter("not-exist")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if missing != nil {
+		t.Errorf("No dead letter is expected, got %#v", missing)
+	}
+
+	if err := MoveToDeadLetters(&OutboxMessage{Output: "my-slack"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	all, err := GetDeadLetters()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("Wrong number of dead letters, expected 2, got %d", len(all))
+	}
+
+	if err := RemoveDeadLetter(failed.Id); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	purged, err := PurgeDeadLetters()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("Wrong number of purged dead letters, expected 1, got %d", purged)
+	}
+	all, err = GetDeadLetters()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(all) != 0 {
+		t.Errorf("Dead letters aren't purged")
+	}
+}
+
+func TestReplayDeadLetter(t *testing.T) {
+	dbPathReal := DbPath
+	defer func() {
+		os.Remove(DbPath)
+		DbPath = dbPathReal
+	}()
+	DbPath = "test_webhooks.db"
+
+	failed := &OutboxMessage{
+		Output:   "my-slack",
+		Content:  map[string]string{"title": "failed"},
+		Attempts: []DeliveryAttempt{{Error: "503 Service Unavailable"}},
+	}
+	if err := MoveToDeadLetters(failed); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	msg, err := ReplayDeadLetter(failed.Id)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if msg == nil || msg.Id != failed.Id || len(msg.Attempts) != 0 || msg.Content["title"] != "failed" {
+		t.Errorf("Unexpected replayed message %#v", msg)
+	}
+	pending, err := GetOutboxMessages()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Id != failed.Id {
+		t.Errorf("Replayed message isn't moved to the outbox: %v", pending)
+	}
+	deadLetters, err := GetDeadLetters()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(deadLetters) != 0 {
+		t.Errorf("Replayed message isn't removed from dead letters")
+	}
+
+	again, err := ReplayDeadLetter(failed.Id)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if again != nil {
+		t.Errorf("Message is expected to be replayed once, got %#v", again)
+	}
+}
@@ -0,0 +1,123 @@
+package dbservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type DeliveryAttempt struct {
+	Time  time.Time `json:"time"`
+	Error string    `json:"error,omitempty"`
+}
+
+type OutboxMessage struct {
+	Id            string            `json:"id"`
+	Route         string            `json:"route"`
+	Output        string            `json:"output"`
+	Template      string            `json:"template"`
+	Content       map[string]string `json:"content"`
+	CorrelationId string            `json:"correlation-id,omitempty"`
+	Attempts      []DeliveryAttempt `json:"attempts,omitempty"`
+	Created       time.Time         `json:"created"`
+	NextAttempt   time.Time         `json:"next-attempt"`
+}
+
+// LastError returns the error of the latest failed attempt, if any
+func (msg *OutboxMessage) LastError() string {
+	if len(msg.Attempts) == 0 {
+		return ""
+	}
+	return msg.Attempts[len(msg.Attempts)-1].Error
+}
+
+// SaveOutboxMessage stores a message which is waiting for delivery. A new id is assigned if the message has none.
+func (s *Store) SaveOutboxMessage(msg *OutboxMessage) error {
+	s.lock().Lock()
+	defer s.lock().Unlock()
+
+	db, err := bolt.Open(s.path(), 0666, nil)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return saveMessage(db, dbBucketOutbox, msg)
+}
+
+func (s *Store) RemoveOutboxMessage(id string) error {
+	s.lock().Lock()
+	defer s.lock().Unlock()
+
+	db, err := bolt.Open(s.path(), 0666, nil)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return dbDelete(db, dbBucketOutbox, [][]byte{[]byte(id)})
+}
+
+// GetOutboxMessages returns all messages waiting for delivery, the oldest first
+func (s *Store) GetOutboxMessages() ([]*OutboxMessage, error) {
+	s.lock().Lock()
+	defer s.lock().Unlock()
+
+	db, err := bolt.Open(s.path(), 0666, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return selectMessages(db, dbBucketOutbox)
+}
+
+func saveMessage(db *bolt.DB, bucket string, msg *OutboxMessage) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		if msg.Id == "" {
+			//ids are taken from the outbox sequence to stay unique when messages move between buckets
+			outbox, err := tx.CreateBucketIfNotExists([]byte(dbBucketOutbox))
+			if err != nil {
+				return err
+			}
+			seq, err := outbox.NextSequence()
+			if err != nil {
+				return err
+			}
+			msg.Id = fmt.Sprintf("%020d", seq) //zero padding keeps keys sorted by creation
+		}
+		value, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(msg.Id), value)
+	})
+}
+
+func selectMessages(db *bolt.DB, bucket string) ([]*OutboxMessage, error) {
+	messages := make([]*OutboxMessage, 0)
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			msg := &OutboxMessage{}
+			if err := json.Unmarshal(v, msg); err != nil {
+				return err
+			}
+			messages = append(messages, msg)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
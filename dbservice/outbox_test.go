@@ -0,0 +1,73 @@
+package dbservice
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestOutbox(t *testing.T) {
+	dbPathReal := DbPath
+	defer func() {
+		os.Remove(DbPath)
+		DbPath = dbPathReal
+	}()
+	DbPath = "test_webhooks.db"
+
+	first := &OutboxMessage{
+		Route:    "route1",
+		Output:   "my-slack",
+		Template: "raw",
+		Content:  map[string]string{"title": "first"},
+		Created:  time.Now().UTC(),
+	}
+	second := &OutboxMessage{
+		Route:   "route1",
+		Output:  "my-jira",
+		Content: map[string]string{"title": "second"},
+	}
+	for _, msg := range []*OutboxMessage{first, second} {
+		if err := SaveOutboxMessage(msg); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if msg.Id == "" {
+			t.Fatal("Id isn't assigned")
+		}
+	}
+	if first.Id >= second.Id {
+		t.Errorf("Ids aren't ordered: %q, %q", first.Id, second.Id)
+	}
+
+	first.Attempts = append(first.Attempts, DeliveryAttempt{Time: time.Now().UTC(), Error: "connection refused"})
+	if err := SaveOutboxMessage(first); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	messages, err := GetOutboxMessages()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("Wrong number of messages, expected 2, got %d", len(messages))
+	}
+	if messages[0].Id != first.Id || messages[0].Content["title"] != "first" {
+		t.Errorf("Unexpected first message %#v", messages[0])
+	}
+	if messages[0].LastError() != "connection refused" {
+		t.Errorf("Wrong last error: %q", messages[0].LastError())
+	}
+	if messages[1].LastError() != "" {
+		t.Errorf("Last error is expected to be empty, got %q", messages[1].LastError())
+	}
+
+	if err := RemoveOutboxMessage(first.Id); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	messages, err = GetOutboxMessages()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(messages) != 1 || messages[0].Id != second.Id {
+		t.Errorf("Message %s isn't removed", first.Id)
+	}
+}
@@ -6,11 +6,11 @@ import (
 	bolt "go.etcd.io/bbolt"
 )
 
-func RegisterPlgnInvctn(name string) error {
-	mutex.Lock()
-	defer mutex.Unlock()
+func (s *Store) RegisterPlgnInvctn(name string) error {
+	s.lock().Lock()
+	defer s.lock().Unlock()
 
-	db, err := bolt.Open(DbPath, 0666, nil)
+	db, err := bolt.Open(s.path(), 0666, nil)
 	if err != nil {
 		return err
 	}
@@ -13,33 +13,58 @@ const (
 	apiKeyName = "POSTEE_API_KEY"
 )
 
-func EnsureApiKey() error {
-	mutex.Lock()
-	defer mutex.Unlock()
+// EnsureApiKey generates the API key unless the database already has one, so the key survives restarts
+func (s *Store) EnsureApiKey() error {
+	s.lock().Lock()
+	defer s.lock().Unlock()
 
-	db, err := bolt.Open(DbPath, 0666, nil)
+	db, err := bolt.Open(s.path(), 0666, nil)
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	err = Init(db, DbBucketOutputStats)
+	err = Init(db, DbBucketSharedConfig)
 	if err != nil {
 		return err
 	}
 
-	newApiKey, err := generateApiKey(32)
-	if err != nil {
+	key, err := dbSelect(db, DbBucketSharedConfig, apiKeyName)
+	if err != nil || len(key) > 0 {
 		return err
 	}
+	_, err = saveApiKey(db)
+	return err
+}
 
-	err = dbInsert(db, DbBucketSharedConfig, []byte(apiKeyName), []byte(newApiKey))
+// RotateApiKey replaces the API key with a new one, the previous key stops working
+func (s *Store) RotateApiKey() (string, error) {
+	s.lock().Lock()
+	defer s.lock().Unlock()
 
-	return err
+	db, err := bolt.Open(s.path(), 0666, nil)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	return saveApiKey(db)
 }
-func GetApiKey() (string, error) {
+
+func saveApiKey(db *bolt.DB) (string, error) {
+	newApiKey, err := generateApiKey(32)
+	if err != nil {
+		return "", err
+	}
+	if err := dbInsert(db, DbBucketSharedConfig, []byte(apiKeyName), []byte(newApiKey)); err != nil {
+		return "", err
+	}
+	return newApiKey, nil
+}
+
+func (s *Store) GetApiKey() (string, error) {
 	var apiKey string = ""
-	db, err := bolt.Open(DbPath, 0444, nil) //should be enough
+	db, err := bolt.Open(s.path(), 0444, nil) //should be enough
 	if err != nil {
 		return "", err
 	}
@@ -39,7 +39,7 @@ func TestApiKeyWithoutInit(t *testing.T) {
 		t.Fatal("Empty key is expected")
 	}
 }
-func TestApiKeyRenewal(t *testing.T) {
+func TestApiKeyIsKept(t *testing.T) {
 	dbPathReal := DbPath
 	defer func() {
 		os.Remove(DbPath)
@@ -62,7 +62,33 @@ func TestApiKeyRenewal(t *testing.T) {
 		keys[i] = key
 
 	}
-	if keys[0] == keys[1] {
-		t.Errorf("Key is not updated. (before: %s and after update: %s)", keys[0], keys[1])
+	if keys[0] != keys[1] {
+		t.Errorf("Key is changed on restart. (before: %s and after restart: %s)", keys[0], keys[1])
+	}
+}
+func TestApiKeyRotation(t *testing.T) {
+	dbPathReal := DbPath
+	defer func() {
+		os.Remove(DbPath)
+		DbPath = dbPathReal
+	}()
+	DbPath = "test_webhooks.db"
+	if err := EnsureApiKey(); err != nil {
+		t.Fatalf("error EnsureApiKey: %s", err)
+	}
+	before, err := GetApiKey()
+	if err != nil {
+		t.Fatal("error while getting value of API key")
+	}
+	rotated, err := RotateApiKey()
+	if err != nil {
+		t.Fatalf("error RotateApiKey: %s", err)
+	}
+	after, err := GetApiKey()
+	if err != nil {
+		t.Fatal("error while getting value of API key")
+	}
+	if rotated == before || after != rotated {
+		t.Errorf("Key is not rotated. (before: %s, rotated: %s and after rotation: %s)", before, rotated, after)
 	}
 }
@@ -0,0 +1,134 @@
+package dbservice
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Store is a database of a tenant, so messages, aggregation queues, the outbox and output stats of tenants
+// are kept apart. A nil Store is the default database at DbPath, which is used by the package functions.
+type Store struct {
+	Path      string
+	SizeLimit int
+	mutex     sync.Mutex //serializes access to the database file, so stores of tenants don't wait for each other
+}
+
+// NewStore returns a store of a database file, its directory is created if it doesn't exist
+func NewStore(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+	return &Store{Path: path}, nil
+}
+
+func (s *Store) path() string {
+	if s == nil {
+		return DbPath
+	}
+	return s.Path
+}
+
+func (s *Store) lock() *sync.Mutex {
+	if s == nil {
+		return &mutex
+	}
+	return &s.mutex
+}
+
+func (s *Store) sizeLimit() int {
+	if s == nil {
+		return DbSizeLimit
+	}
+	return s.SizeLimit
+}
+
+// SetSizeLimit sets the limit which is applied by CheckSizeLimit
+func (s *Store) SetSizeLimit(limit int) {
+	s.lock().Lock()
+	defer s.lock().Unlock()
+	if s == nil {
+		DbSizeLimit = limit
+		return
+	}
+	s.SizeLimit = limit
+}
+
+var defaultStore *Store
+
+func MayBeStoreMessage(message []byte, messageKey string, expired *time.Time) (wasStored bool, err error) {
+	return defaultStore.MayBeStoreMessage(message, messageKey, expired)
+}
+
+func CheckSizeLimit() {
+	defaultStore.CheckSizeLimit()
+}
+
+func CheckExpiredData() {
+	defaultStore.CheckExpiredData()
+}
+
+func AggregateScans(output string, currentScan map[string]string, scansPerTicket int, ignoreTheQuantity bool) ([]map[string]string, error) {
+	return defaultStore.AggregateScans(output, currentScan, scansPerTicket, ignoreTheQuantity)
+}
+
+func GetAggregationQueueDepths() (map[string]int, error) {
+	return defaultStore.GetAggregationQueueDepths()
+}
+
+func GetDbSize() (int64, error) {
+	return defaultStore.GetDbSize()
+}
+
+func MoveToDeadLetters(msg *OutboxMessage) error {
+	return defaultStore.MoveToDeadLetters(msg)
+}
+
+func GetDeadLetters() ([]*OutboxMessage, error) {
+	return defaultStore.GetDeadLetters()
+}
+
+func GetDeadLetter(id string) (*OutboxMessage, error) {
+	return defaultStore.GetDeadLetter(id)
+}
+
+func RemoveDeadLetter(id string) error {
+	return defaultStore.RemoveDeadLetter(id)
+}
+
+func ReplayDeadLetter(id string) (*OutboxMessage, error) {
+	return defaultStore.ReplayDeadLetter(id)
+}
+
+func PurgeDeadLetters() (int, error) {
+	return defaultStore.PurgeDeadLetters()
+}
+
+func SaveOutboxMessage(msg *OutboxMessage) error {
+	return defaultStore.SaveOutboxMessage(msg)
+}
+
+func RemoveOutboxMessage(id string) error {
+	return defaultStore.RemoveOutboxMessage(id)
+}
+
+func GetOutboxMessages() ([]*OutboxMessage, error) {
+	return defaultStore.GetOutboxMessages()
+}
+
+func RegisterPlgnInvctn(name string) error {
+	return defaultStore.RegisterPlgnInvctn(name)
+}
+
+func EnsureApiKey() error {
+	return defaultStore.EnsureApiKey()
+}
+
+func RotateApiKey() (string, error) {
+	return defaultStore.RotateApiKey()
+}
+
+func GetApiKey() (string, error) {
+	return defaultStore.GetApiKey()
+}
@@ -0,0 +1,197 @@
+package delivery
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/aquasecurity/postee/v2/metrics"
+	"github.com/aquasecurity/postee/v2/outputs"
+)
+
+// States of a circuit breaker
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = time.Minute
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// breaker stops sends to an output after a number of consecutive failures. Once the cooldown is over
+// a single probe is sent, the circuit is closed again if it succeeds.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	state     string
+	failures  int //consecutive failures
+	lastError string
+	openedAt  time.Time
+	probing   bool
+	timer     *time.Timer
+}
+
+// OutputHealth is a state of an output as seen by the dispatcher
+type OutputHealth struct {
+	Output              string     `json:"output"`
+	Type                string     `json:"type"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive-failures"`
+	LastError           string     `json:"last-error,omitempty"`
+	OpenedAt            *time.Time `json:"opened-at,omitempty"`
+	Pending             int        `json:"pending"` //messages waiting in the outbox
+}
+
+// allow reports whether a message may be sent to an output. It lets a single probe through after the cooldown,
+// probe is true for it.
+func (d *Dispatcher) allow(output string) (allowed, probe bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	b, ok := d.breakers[output]
+	if !ok {
+		return true, false
+	}
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false, false
+		}
+		b.state = CircuitHalfOpen
+		metrics.CircuitStateChanged(d.tenant, output, b.state)
+		fallthrough
+	case CircuitHalfOpen:
+		if b.probing {
+			return false, false
+		}
+		b.probing = true
+		return true, true
+	}
+	return true, false
+}
+
+// releaseProbe lets the next message through the half-open breaker of an output when the probe is
+// interrupted before its outcome is recorded
+func (d *Dispatcher) releaseProbe(output string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if b, ok := d.breakers[output]; ok && b.state == CircuitHalfOpen {
+		b.probing = false
+	}
+}
+
+// record updates the breaker of an output with an outcome of a send. Errors which aren't retryable are
+// caused by the message rather than by the output, so they don't count towards opening the circuit.
+func (d *Dispatcher) record(output string, err error) {
+	d.mu.Lock()
+	b, ok := d.breakers[output]
+	if !ok {
+		d.mu.Unlock()
+		return
+	}
+	if err != nil && !outputs.IsRetryable(err) {
+		//a probe which failed this way tells nothing about the output, the next message is a probe again
+		b.probing = false
+		d.mu.Unlock()
+		return
+	}
+	if err == nil {
+		recovered := b.state != CircuitClosed
+		b.state, b.failures, b.lastError, b.probing = CircuitClosed, 0, "", false
+		if b.timer != nil {
+			b.timer.Stop()
+			b.timer = nil
+		}
+		d.mu.Unlock()
+		if recovered {
+			logging.Infof("Circuit of %q is closed, pending messages are resumed", output)
+			metrics.CircuitStateChanged(d.tenant, output, CircuitClosed)
+			d.resume(output)
+		}
+		return
+	}
+	defer d.mu.Unlock()
+	b.failures++
+	b.lastError = err.Error()
+	if b.state == CircuitHalfOpen || (b.state == CircuitClosed && b.failures >= b.threshold) {
+		b.state, b.openedAt, b.probing = CircuitOpen, time.Now().UTC(), false
+		metrics.CircuitStateChanged(d.tenant, output, CircuitOpen)
+		logging.Warnf("Circuit of %q is open after %d consecutive failure(s), messages are kept in the outbox for %s",
+			output, b.failures, b.cooldown)
+		if b.timer != nil {
+			b.timer.Stop()
+		}
+		b.timer = time.AfterFunc(b.cooldown, func() {
+			d.probe(output)
+		})
+	}
+}
+
+// probe sends the oldest message waiting for an output, it's let through by the half-open breaker.
+// If there are no messages the next dispatched one becomes the probe.
+func (d *Dispatcher) probe(output string) {
+	messages, err := d.store.GetOutboxMessages()
+	if err != nil {
+		logging.Errorf("Unable to load the outbox: %v", err)
+		return
+	}
+	for _, msg := range messages {
+		if msg.Output != output || d.isPending(msg.Id) {
+			continue
+		}
+		d.setPending(msg.Id, true)
+		d.push(msg, nil)
+		return
+	}
+}
+
+// resume schedules messages which were kept in the outbox while the circuit of an output was open
+func (d *Dispatcher) resume(output string) {
+	messages, err := d.store.GetOutboxMessages()
+	if err != nil {
+		logging.Errorf("Unable to load the outbox: %v", err)
+		return
+	}
+	for _, msg := range messages {
+		if msg.Output != output || d.isPending(msg.Id) {
+			continue
+		}
+		d.schedule(msg)
+	}
+}
+
+// Health returns the state of all registered outputs
+func (d *Dispatcher) Health() ([]OutputHealth, error) {
+	messages, err := d.store.GetOutboxMessages()
+	if err != nil {
+		return nil, err
+	}
+	pending := make(map[string]int)
+	for _, msg := range messages {
+		pending[msg.Output]++
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	health := make([]OutputHealth, 0, len(d.targets))
+	for name, t := range d.targets {
+		h := OutputHealth{Output: name, Type: t.opts.Type, State: CircuitClosed, Pending: pending[name]}
+		if b, ok := d.breakers[name]; ok {
+			h.State, h.ConsecutiveFailures, h.LastError = b.state, b.failures, b.lastError
+			if b.state != CircuitClosed {
+				openedAt := b.openedAt
+				h.OpenedAt = &openedAt
+			}
+		}
+		health = append(health, h)
+	}
+	sort.Slice(health, func(i, j int) bool {
+		return health[i].Output < health[j].Output
+	})
+	return health, nil
+}
@@ -0,0 +1,569 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aquasecurity/postee/v2/dbservice"
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/aquasecurity/postee/v2/metrics"
+	"github.com/aquasecurity/postee/v2/outputs"
+)
+
+var ErrNotFound = errors.New("message not found")
+
+const (
+	DefaultTimeout = 30 * time.Second
+	// DrainTimeout limits how long Terminate waits for in-flight sends
+	DrainTimeout = 10 * time.Second
+)
+
+// Status is an outcome of a delivery attempt
+type Status string
+
+const (
+	Queued    Status = "queued"
+	Delivered Status = "delivered"
+	Retrying  Status = "retrying"
+	Failed    Status = "failed"
+)
+
+// Options are delivery settings of an output
+type Options struct {
+	Type             string //output type, used to label metrics
+	Retry            RetryPolicy
+	Timeout          time.Duration //limit of a single send, no limit if it isn't positive
+	MaxConcurrency   int           //limit of concurrent sends to the output, DefaultDeliveryWorkers if it isn't positive
+	OrderingKey      string        //sends with the same key are made one at a time, see deliveryWorkers
+	RateLimiter      *outputs.RateLimiter
+	BreakerThreshold int           //consecutive failures which open the circuit, no breaker if it isn't positive
+	BreakerCooldown  time.Duration //time before a probe is sent to an output with the open circuit
+}
+
+func DefaultOptions() Options {
+	return Options{Retry: DefaultRetryPolicy(), Timeout: DefaultTimeout}
+}
+
+type target struct {
+	output     outputs.Output
+	opts       Options
+	registered bool
+}
+
+// Dispatcher delivers rendered messages to outputs. Every message is saved to the outbox and put to
+// the delivery queue of its output, so a slow output doesn't hold up messages of others. Messages stay
+// in the outbox until they're delivered, so pending deliveries survive a restart.
+//
+// Order of messages with the same ordering key is best effort: messages which are dispatched at the same
+// time may be queued in any order, and a retried message is queued after the ones dispatched meanwhile.
+type Dispatcher struct {
+	mu       sync.Mutex
+	running  bool
+	targets  map[string]*target
+	timers   map[string]*time.Timer
+	pending  map[string]bool
+	queues   map[string]*queue //delivery queues by output
+	orders   map[string]*lane  //ordering lanes by ordering key
+	breakers map[string]*breaker
+	ctx      context.Context //parent of all sends, cancelled by Terminate
+	cancel   context.CancelFunc
+	inflight *sync.WaitGroup
+	store    *dbservice.Store //nil for the default database
+	tenant   string           //labels metrics, empty for the default configuration
+}
+
+var (
+	initCtx       sync.Once
+	dispatcherCtx *Dispatcher
+)
+
+func Instance() *Dispatcher {
+	initCtx.Do(func() {
+		dispatcherCtx = New("", nil)
+	})
+	return dispatcherCtx
+}
+
+// New creates a dispatcher of a tenant whose outbox and dead letters are kept in its store
+func New(tenant string, store *dbservice.Store) *Dispatcher {
+	d := &Dispatcher{
+		targets:  make(map[string]*target),
+		timers:   make(map[string]*time.Timer),
+		pending:  make(map[string]bool),
+		queues:   make(map[string]*queue),
+		orders:   make(map[string]*lane),
+		breakers: make(map[string]*breaker),
+		inflight: &sync.WaitGroup{},
+		store:    store,
+		tenant:   tenant,
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	return d
+}
+
+// Register makes an output available for deliveries and retries
+func (d *Dispatcher) Register(output outputs.Output, opts Options) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.targets[output.GetName()] = &target{output, opts, true}
+	d.queue(output.GetName()).resize(deliveryWorkers(opts), d.run)
+	if b, ok := d.breakers[output.GetName()]; ok && b.timer != nil {
+		b.timer.Stop()
+	}
+	if opts.BreakerThreshold > 0 {
+		cooldown := opts.BreakerCooldown
+		if cooldown <= 0 {
+			cooldown = DefaultBreakerCooldown
+		}
+		d.breakers[output.GetName()] = &breaker{threshold: opts.BreakerThreshold, cooldown: cooldown, state: CircuitClosed}
+		metrics.CircuitStateChanged(d.tenant, output.GetName(), CircuitClosed)
+	} else {
+		delete(d.breakers, output.GetName())
+	}
+}
+
+// Unregister removes an output, its pending deliveries stay in the outbox until the output is registered again
+func (d *Dispatcher) Unregister(name string) {
+	d.mu.Lock()
+	delete(d.targets, name)
+	q, ok := d.queues[name]
+	delete(d.queues, name)
+	if b, ok := d.breakers[name]; ok && b.timer != nil {
+		b.timer.Stop()
+	}
+	delete(d.breakers, name)
+	d.mu.Unlock()
+	if ok {
+		d.keep(name, q.close(), fmt.Errorf("output %q isn't configured", name))
+	}
+}
+
+// Start resumes deliveries which were pending in the outbox
+func (d *Dispatcher) Start() {
+	d.mu.Lock()
+	d.running = true
+	if d.ctx.Err() != nil {
+		d.ctx, d.cancel = context.WithCancel(context.Background())
+	}
+	d.mu.Unlock()
+
+	messages, err := d.store.GetOutboxMessages()
+	if err != nil {
+		logging.Errorf("Unable to load the outbox: %v", err)
+		return
+	}
+	resumed := 0
+	for _, msg := range messages {
+		if d.isPending(msg.Id) {
+			continue
+		}
+		if d.getTarget(msg.Output) == nil {
+			msgLogger(msg).Warnf("Output %q isn't configured, message %s is kept in the outbox", msg.Output, msg.Id)
+			continue
+		}
+		d.schedule(msg)
+		resumed++
+	}
+	if resumed > 0 {
+		logging.Infof("%d pending message(s) resumed from the outbox", resumed)
+	}
+}
+
+// Terminate cancels scheduled retries and in-flight sends and waits for the sends to return.
+// Messages stay in the outbox and are resumed by the next Start, so do messages dispatched in between.
+func (d *Dispatcher) Terminate() {
+	d.mu.Lock()
+	d.running = false
+	for id, timer := range d.timers {
+		timer.Stop()
+		delete(d.pending, id)
+	}
+	d.timers = make(map[string]*time.Timer)
+	d.cancel()
+	inflight := d.inflight
+	d.inflight = &sync.WaitGroup{}
+	queues := d.queues
+	d.queues = make(map[string]*queue)
+	d.mu.Unlock()
+
+	for name, q := range queues {
+		d.keep(name, q.close(), context.Canceled)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		inflight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(DrainTimeout):
+		logging.Warnf("In-flight sends didn't finish in %s", DrainTimeout)
+	}
+
+	d.mu.Lock()
+	d.targets = make(map[string]*target)
+	d.orders = make(map[string]*lane)
+	for _, b := range d.breakers {
+		if b.timer != nil {
+			b.timer.Stop()
+		}
+	}
+	d.breakers = make(map[string]*breaker)
+	d.mu.Unlock()
+}
+
+// Replay moves a dead letter back to the outbox and puts it to the delivery queue of its output
+func (d *Dispatcher) Replay(id string) error {
+	msg, err := d.store.GetDeadLetter(id)
+	if err != nil {
+		return err
+	}
+	if msg == nil {
+		return ErrNotFound
+	}
+	if d.getTarget(msg.Output) == nil {
+		return fmt.Errorf("output %q isn't configured", msg.Output)
+	}
+	attempts := len(msg.Attempts)
+	//the dead letter is moved in a single transaction, a concurrent replay of the same message doesn't find it
+	msg, err = d.store.ReplayDeadLetter(id)
+	if err != nil {
+		return err
+	}
+	if msg == nil {
+		return ErrNotFound
+	}
+
+	msgLogger(msg).Infof("Replaying message %s to %q, %d attempt(s) were made before", msg.Id, msg.Output, attempts)
+	d.setPending(msg.Id, true)
+	d.push(msg, nil)
+	return nil
+}
+
+// ReplayAll replays all dead letters, or only the ones of a given output if its name isn't empty.
+// It returns the number of replayed messages.
+func (d *Dispatcher) ReplayAll(output string) (int, error) {
+	messages, err := d.store.GetDeadLetters()
+	if err != nil {
+		return 0, err
+	}
+	replayed := 0
+	for _, msg := range messages {
+		if output != "" && msg.Output != output {
+			continue
+		}
+		if err := d.Replay(msg.Id); err != nil {
+			msgLogger(msg).Errorf("Unable to replay message %s: %v", msg.Id, err)
+			continue
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// Dispatch saves a message to the outbox and waits for the first delivery attempt, see Enqueue.
+// It returns the outcome of the attempt along with its error.
+func (d *Dispatcher) Dispatch(ctx context.Context, route, template string, output outputs.Output, content map[string]string) (Status, error) {
+	return d.Enqueue(ctx, route, template, output, content).Wait(ctx)
+}
+
+// Enqueue saves a message to the outbox and puts it to the delivery queue of the output without waiting
+// for the delivery. Failed attempts are retried in background according to the retry policy of the output.
+// The returned receipt tells the outcome of the first attempt.
+func (d *Dispatcher) Enqueue(ctx context.Context, route, template string, output outputs.Output, content map[string]string) *Receipt {
+	d.mu.Lock()
+	//a registered output wins, so messages handled during a config reload go to its current instance
+	if t, ok := d.targets[output.GetName()]; !ok {
+		d.targets[output.GetName()] = &target{output, DefaultOptions(), false}
+	} else if !t.registered {
+		d.targets[output.GetName()] = &target{output, t.opts, false}
+	}
+	d.mu.Unlock()
+
+	now := time.Now().UTC()
+	msg := &dbservice.OutboxMessage{
+		Route:         route,
+		Output:        output.GetName(),
+		Template:      template,
+		Content:       content,
+		CorrelationId: logging.CorrelationId(ctx),
+		Created:       now,
+		NextAttempt:   now,
+	}
+	if err := d.store.SaveOutboxMessage(msg); err != nil {
+		//the message has no id, so it's sent once and isn't scheduled for retries
+		msgLogger(msg).Errorf("Unable to save a message for %q to the outbox, it won't be retried: %v", msg.Output, err)
+		msg.Id = ""
+	}
+	d.setPending(msg.Id, true)
+	receipt := newReceipt()
+	d.push(msg, receipt)
+	return receipt
+}
+
+// queue returns the delivery queue of an output, the caller has to hold the lock
+func (d *Dispatcher) queue(output string) *queue {
+	q, ok := d.queues[output]
+	if !ok {
+		q = newQueue()
+		d.queues[output] = q
+	}
+	return q
+}
+
+// push puts a message to the delivery queue of its output
+func (d *Dispatcher) push(msg *dbservice.OutboxMessage, receipt *Receipt) {
+	d.mu.Lock()
+	q, ok := d.queues[msg.Output]
+	if !ok {
+		opts := DefaultOptions()
+		if t, ok := d.targets[msg.Output]; ok {
+			opts = t.opts
+		}
+		q = d.queue(msg.Output)
+		q.resize(deliveryWorkers(opts), d.run)
+	}
+	d.mu.Unlock()
+
+	metrics.SendQueued(d.tenant, msg.Output)
+	if !q.push(&job{msg, receipt}) {
+		//the queue was closed by Terminate or Unregister in the meantime
+		d.keep(msg.Output, []*job{{msg, receipt}}, context.Canceled)
+	}
+}
+
+// run delivers a message taken from a queue by its worker
+func (d *Dispatcher) run(j *job) {
+	metrics.SendStarted(d.tenant, j.msg.Output)
+	status, err := d.deliver(j.msg)
+	metrics.SendFinished(d.tenant, j.msg.Output)
+	if j.receipt != nil {
+		j.receipt.complete(status, err)
+	}
+}
+
+// keep leaves messages of a closed queue in the outbox, they're resumed by the next Start
+func (d *Dispatcher) keep(output string, jobs []*job, err error) {
+	if len(jobs) == 0 {
+		return
+	}
+	logging.Warnf("%d queued message(s) of %q are kept in the outbox", len(jobs), output)
+	for _, j := range jobs {
+		metrics.SendStarted(d.tenant, output)
+		metrics.SendFinished(d.tenant, output)
+		if j.msg.Id == "" {
+			msgLogger(j.msg).Errorf("Message for %q isn't in the outbox, it's lost", output)
+		}
+		d.setPending(j.msg.Id, false)
+		if j.receipt != nil {
+			j.receipt.complete(Retrying, err)
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(msg *dbservice.OutboxMessage) (Status, error) {
+	logger := msgLogger(msg)
+	d.mu.Lock()
+	t := d.targets[msg.Output]
+	root, inflight := d.ctx, d.inflight
+	if t != nil {
+		inflight.Add(1)
+	}
+	d.mu.Unlock()
+	if t == nil {
+		logger.Warnf("Output %q isn't configured, message %s is kept in the outbox", msg.Output, msg.Id)
+		d.setPending(msg.Id, false)
+		return Retrying, fmt.Errorf("output %q isn't configured", msg.Output)
+	}
+	defer inflight.Done()
+
+	if root.Err() != nil {
+		return d.interrupt(msg, root.Err())
+	}
+	allowed, probe := d.allow(msg.Output)
+	if !allowed {
+		if msg.Id == "" {
+			logger.Errorf("Circuit of %q is open and the message isn't in the outbox, it's moved to dead letters", msg.Output)
+			d.moveToDeadLetters(msg)
+			return Failed, ErrCircuitOpen
+		}
+		logger.Debugf("Circuit of %q is open, message %s is kept in the outbox", msg.Output, msg.Id)
+		d.setPending(msg.Id, false)
+		return Retrying, ErrCircuitOpen
+	}
+
+	release, err := d.acquire(root, t.opts)
+	if err != nil {
+		if probe {
+			d.releaseProbe(msg.Output)
+		}
+		return d.interrupt(msg, err)
+	}
+	defer release()
+
+	ctx := outputs.WithRoute(logging.WithCorrelationId(root, msg.CorrelationId), msg.Route, msg.Template)
+	if t.opts.RateLimiter != nil {
+		ctx = outputs.WithRateLimiter(ctx, t.opts.RateLimiter)
+	}
+	if t.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.opts.Timeout)
+		defer cancel()
+	}
+
+	logger.Debugf("Sending message %s to %q", msg.Id, msg.Output)
+	started := time.Now()
+	err = t.output.Send(ctx, msg.Content)
+	elapsed := time.Since(started)
+	if err != nil && root.Err() != nil {
+		if probe {
+			d.releaseProbe(msg.Output)
+		}
+		return d.interrupt(msg, err)
+	}
+	d.record(msg.Output, err)
+	if err == nil {
+		logger.Infof("Message %s is delivered to %q", msg.Id, msg.Output)
+		metrics.DeliveryAttempted(d.tenant, msg.Output, t.opts.Type, metrics.ResultDelivered, elapsed)
+		d.complete(msg)
+		return Delivered, nil
+	}
+
+	msg.Attempts = append(msg.Attempts, dbservice.DeliveryAttempt{
+		Time:  time.Now().UTC(),
+		Error: err.Error(),
+	})
+	if !outputs.IsRetryable(err) {
+		logger.Errorf("Error while sending event to %q, the error isn't retryable: %v", msg.Output, err)
+		metrics.DeliveryAttempted(d.tenant, msg.Output, t.opts.Type, metrics.ResultFailed, elapsed)
+		d.moveToDeadLetters(msg)
+		return Failed, err
+	}
+	if msg.Id == "" {
+		logger.Errorf("Error while sending event to %q, the message isn't in the outbox and can't be retried: %v", msg.Output, err)
+		metrics.DeliveryAttempted(d.tenant, msg.Output, t.opts.Type, metrics.ResultFailed, elapsed)
+		d.moveToDeadLetters(msg)
+		return Failed, err
+	}
+	if len(msg.Attempts) >= t.opts.Retry.MaxAttempts {
+		logger.Errorf("Error while sending event to %q, giving up after %d attempt(s): %v", msg.Output, len(msg.Attempts), err)
+		metrics.DeliveryAttempted(d.tenant, msg.Output, t.opts.Type, metrics.ResultFailed, elapsed)
+		d.moveToDeadLetters(msg)
+		return Failed, err
+	}
+
+	metrics.DeliveryAttempted(d.tenant, msg.Output, t.opts.Type, metrics.ResultRetrying, elapsed)
+	delay := t.opts.Retry.Backoff(len(msg.Attempts))
+	if retryAfter := outputs.RetryAfter(err); retryAfter > delay {
+		delay = retryAfter
+	}
+	logger.Warnf("Error while sending event to %q (attempt %d of %d), retrying in %s: %v",
+		msg.Output, len(msg.Attempts), t.opts.Retry.MaxAttempts, delay, err)
+	msg.NextAttempt = time.Now().UTC().Add(delay)
+	if err := d.store.SaveOutboxMessage(msg); err != nil {
+		logger.Errorf("Unable to update message %s in the outbox: %v", msg.Id, err)
+	}
+	d.schedule(msg)
+	return Retrying, err
+}
+
+// acquire waits for the ordering lane of an output, which is shared by outputs with the same ordering key.
+// The returned func releases it.
+func (d *Dispatcher) acquire(ctx context.Context, opts Options) (func(), error) {
+	if opts.OrderingKey == "" {
+		return func() {}, nil
+	}
+	d.mu.Lock()
+	l, ok := d.orders[opts.OrderingKey]
+	if !ok {
+		l = newLane(1)
+		d.orders[opts.OrderingKey] = l
+	}
+	d.mu.Unlock()
+
+	if err := l.acquire(ctx); err != nil {
+		return nil, err
+	}
+	return l.release, nil
+}
+
+// interrupt keeps a message in the outbox when its delivery is cancelled by Terminate
+func (d *Dispatcher) interrupt(msg *dbservice.OutboxMessage, err error) (Status, error) {
+	msgLogger(msg).Warnf("Sending message %s to %q is interrupted by shutdown, it's kept in the outbox", msg.Id, msg.Output)
+	d.setPending(msg.Id, false)
+	return Retrying, err
+}
+
+func (d *Dispatcher) complete(msg *dbservice.OutboxMessage) {
+	if msg.Id != "" {
+		if err := d.store.RemoveOutboxMessage(msg.Id); err != nil {
+			msgLogger(msg).Errorf("Unable to remove message %s from the outbox: %v", msg.Id, err)
+		}
+	}
+	d.setPending(msg.Id, false)
+}
+
+func (d *Dispatcher) moveToDeadLetters(msg *dbservice.OutboxMessage) {
+	if err := d.store.MoveToDeadLetters(msg); err != nil {
+		msgLogger(msg).Errorf("Unable to move message %s to dead letters: %v", msg.Id, err)
+	}
+	d.setPending(msg.Id, false)
+}
+
+func (d *Dispatcher) schedule(msg *dbservice.OutboxMessage) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.running {
+		delete(d.pending, msg.Id)
+		return
+	}
+	d.pending[msg.Id] = true
+	d.timers[msg.Id] = time.AfterFunc(time.Until(msg.NextAttempt), func() {
+		d.mu.Lock()
+		delete(d.timers, msg.Id)
+		d.mu.Unlock()
+		d.push(msg, nil)
+	})
+}
+
+func (d *Dispatcher) getTarget(name string) *target {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.targets[name]
+}
+
+func (d *Dispatcher) isPending(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.pending[id]
+}
+
+func (d *Dispatcher) setPending(id string, pending bool) {
+	if id == "" {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if pending {
+		d.pending[id] = true
+	} else {
+		delete(d.pending, id)
+	}
+}
+
+// Tenant returns the tenant of the dispatcher, empty for the default configuration
+func (d *Dispatcher) Tenant() string {
+	return d.tenant
+}
+
+func msgLogger(msg *dbservice.OutboxMessage) *logging.Logger {
+	logger := logging.With("output", msg.Output)
+	if msg.CorrelationId != "" {
+		logger = logger.With(logging.CorrelationIdKey, msg.CorrelationId)
+	}
+	return logger
+}
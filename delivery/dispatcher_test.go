@@ -0,0 +1,721 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aquasecurity/postee/v2/dbservice"
+	"github.com/aquasecurity/postee/v2/formatting"
+	"github.com/aquasecurity/postee/v2/layout"
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/aquasecurity/postee/v2/outputs"
+	"github.com/aquasecurity/postee/v2/utils"
+)
+
+type flakyOutput struct {
+	mu       sync.Mutex
+	errs     []error
+	attempts int
+	sent     chan map[string]string
+}
+
+func (o *flakyOutput) GetName() string { return "flaky" }
+func (o *flakyOutput) Init() error     { return nil }
+func (o *flakyOutput) Send(ctx context.Context, content map[string]string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.attempts++
+	if len(o.errs) > 0 {
+		err := o.errs[0]
+		o.errs = o.errs[1:]
+		o.sent <- nil
+		return err
+	}
+	o.sent <- content
+	return nil
+}
+func (o *flakyOutput) Terminate() error { return nil }
+func (o *flakyOutput) GetLayoutProvider() layout.LayoutProvider {
+	return new(formatting.HtmlProvider)
+}
+func (o *flakyOutput) getAttempts() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.attempts
+}
+
+// blockingOutput doesn't return until the context of a send is done
+type blockingOutput struct {
+	started chan struct{}
+}
+
+func (o *blockingOutput) GetName() string { return "blocking" }
+func (o *blockingOutput) Init() error     { return nil }
+func (o *blockingOutput) Send(ctx context.Context, content map[string]string) error {
+	o.started <- struct{}{}
+	<-ctx.Done()
+	return ctx.Err()
+}
+func (o *blockingOutput) Terminate() error { return nil }
+func (o *blockingOutput) GetLayoutProvider() layout.LayoutProvider {
+	return new(formatting.HtmlProvider)
+}
+
+var testPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 10 * time.Millisecond,
+	MaxBackoff:     20 * time.Millisecond,
+}
+
+func TestDispatch(t *testing.T) {
+	tests := []struct {
+		caseDesc         string
+		errs             []error
+		expectedAttempts int
+		delivered        bool
+	}{
+		{"Delivered at once", nil, 1, true},
+		{"Delivered after network errors", []error{errors.New("connection refused"), errors.New("timeout")}, 3, true},
+		{"Delivered after 503", []error{utils.NewHttpError(503, "unavailable")}, 2, true},
+		{"Retried after 429", []error{utils.NewHttpError(429, "too many requests")}, 2, true},
+		{"Not retried after 400", []error{utils.NewHttpError(400, "bad request")}, 1, false},
+		{"Not retried after permanent error", []error{outputs.Permanent(errors.New("invalid payload"))}, 1, false},
+		{"Attempts are exhausted", []error{errors.New("1"), errors.New("2"), errors.New("3")}, 3, false},
+	}
+	for _, test := range tests {
+		runDispatchCase(t, test.caseDesc, test.errs, test.expectedAttempts, test.delivered)
+	}
+}
+
+func runDispatchCase(t *testing.T, caseDesc string, errs []error, expectedAttempts int, delivered bool) {
+	dbPathReal := dbservice.DbPath
+	defer func() {
+		os.Remove(dbservice.DbPath)
+		dbservice.DbPath = dbPathReal
+	}()
+	dbservice.DbPath = "test_webhooks.db"
+
+	output := &flakyOutput{errs: errs, sent: make(chan map[string]string, 10)}
+	d := Instance()
+	d.Register(output, Options{Type: "flaky", Retry: testPolicy})
+	d.Start()
+	defer d.Terminate()
+
+	d.Dispatch(logging.WithCorrelationId(context.Background(), "abc123"), "route1", "raw", output, map[string]string{"title": "title"})
+
+	timeout := time.After(2 * time.Second)
+	received := false
+	for signals := 0; signals < expectedAttempts && !received; signals++ {
+		select {
+		case content := <-output.sent:
+			received = content != nil
+		case <-timeout:
+			t.Fatalf("[%s] test didn't finish in time", caseDesc)
+		}
+	}
+	time.Sleep(100 * time.Millisecond) //make sure there are no more attempts
+
+	if output.getAttempts() != expectedAttempts {
+		t.Errorf("[%s] Wrong number of attempts, expected %d, got %d", caseDesc, expectedAttempts, output.getAttempts())
+	}
+	if received != delivered {
+		t.Errorf("[%s] Wrong delivery result, expected %t, got %t", caseDesc, delivered, received)
+	}
+	messages, err := dbservice.GetOutboxMessages()
+	if err != nil {
+		t.Fatalf("[%s] Unexpected error: %v", caseDesc, err)
+	}
+	if len(messages) != 0 {
+		t.Errorf("[%s] Outbox is expected to be empty, got %d message(s)", caseDesc, len(messages))
+	}
+	deadLetters, err := dbservice.GetDeadLetters()
+	if err != nil {
+		t.Fatalf("[%s] Unexpected error: %v", caseDesc, err)
+	}
+	expectedDeadLetters := 0
+	if !delivered {
+		expectedDeadLetters = 1
+	}
+	if len(deadLetters) != expectedDeadLetters {
+		t.Fatalf("[%s] Wrong number of dead letters, expected %d, got %d", caseDesc, expectedDeadLetters, len(deadLetters))
+	}
+	if !delivered && len(deadLetters[0].Attempts) != expectedAttempts {
+		t.Errorf("[%s] Attempt history isn't kept, expected %d attempt(s), got %d", caseDesc, expectedAttempts, len(deadLetters[0].Attempts))
+	}
+	if !delivered && deadLetters[0].CorrelationId != "abc123" {
+		t.Errorf("[%s] Correlation id isn't kept, got %q", caseDesc, deadLetters[0].CorrelationId)
+	}
+}
+
+func TestDispatchWithoutOutbox(t *testing.T) {
+	dbPathReal := dbservice.DbPath
+	defer func() {
+		dbservice.DbPath = dbPathReal
+	}()
+	dbservice.DbPath = "missing/dir/test_webhooks.db" //saving to the outbox fails
+
+	output := &flakyOutput{
+		errs: []error{errors.New("connection refused"), errors.New("connection refused")},
+		sent: make(chan map[string]string, 10),
+	}
+	d := Instance()
+	d.Register(output, Options{Type: "flaky", Retry: testPolicy})
+	d.Start()
+	defer d.Terminate()
+
+	for i := 0; i < 2; i++ {
+		status, err := d.Dispatch(context.Background(), "route1", "raw", output, map[string]string{"title": fmt.Sprint(i)})
+		if status != Failed || err == nil {
+			t.Errorf("A message which isn't in the outbox shouldn't be retried, got %q, %v", status, err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond) //make sure there are no retries
+	if output.getAttempts() != 2 {
+		t.Errorf("Wrong number of attempts, expected 2, got %d", output.getAttempts())
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.timers) != 0 || len(d.pending) != 0 {
+		t.Errorf("Messages without id shouldn't be scheduled, timers: %d, pending: %d", len(d.timers), len(d.pending))
+	}
+}
+
+func TestReplay(t *testing.T) {
+	dbPathReal := dbservice.DbPath
+	defer func() {
+		os.Remove(dbservice.DbPath)
+		dbservice.DbPath = dbPathReal
+	}()
+	dbservice.DbPath = "test_webhooks.db"
+
+	failed := &dbservice.OutboxMessage{
+		Route:   "route1",
+		Output:  "flaky",
+		Content: map[string]string{"title": "failed during outage"},
+		Attempts: []dbservice.DeliveryAttempt{
+			{Error: "503"},
+		},
+	}
+	if err := dbservice.MoveToDeadLetters(failed); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	output := &flakyOutput{sent: make(chan map[string]string, 10)}
+	d := Instance()
+	d.Register(output, Options{Type: "flaky", Retry: testPolicy})
+	d.Start()
+	defer d.Terminate()
+
+	if err := d.Replay("not-exist"); err != ErrNotFound {
+		t.Errorf("ErrNotFound is expected, got %v", err)
+	}
+
+	replayed, err := d.ReplayAll("another-output")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if replayed != 0 {
+		t.Errorf("Dead letters of other outputs shouldn't be replayed")
+	}
+
+	if err := d.Replay(failed.Id); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	select {
+	case content := <-output.sent:
+		if content["title"] != "failed during outage" {
+			t.Errorf("Unexpected content %v", content)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("dead letter wasn't replayed")
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	deadLetters, err := dbservice.GetDeadLetters()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(deadLetters) != 0 {
+		t.Errorf("Replayed dead letter isn't removed")
+	}
+}
+
+func TestConcurrentReplay(t *testing.T) {
+	dbPathReal := dbservice.DbPath
+	defer func() {
+		os.Remove(dbservice.DbPath)
+		dbservice.DbPath = dbPathReal
+	}()
+	dbservice.DbPath = "test_webhooks.db"
+
+	failed := &dbservice.OutboxMessage{Route: "route1", Output: "flaky", Content: map[string]string{"title": "replayed"}}
+	if err := dbservice.MoveToDeadLetters(failed); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	output := &flakyOutput{sent: make(chan map[string]string, 10)}
+	d := Instance()
+	d.Register(output, Options{Type: "flaky", Retry: testPolicy})
+	d.Start()
+	defer d.Terminate()
+
+	const replays = 5
+	errs := make(chan error, replays)
+	for i := 0; i < replays; i++ {
+		go func() {
+			errs <- d.Replay(failed.Id)
+		}()
+	}
+	replayed := 0
+	for i := 0; i < replays; i++ {
+		switch err := <-errs; err {
+		case nil:
+			replayed++
+		case ErrNotFound:
+		default:
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+	if replayed != 1 {
+		t.Errorf("Message is expected to be replayed once, got %d", replayed)
+	}
+	<-output.sent
+	time.Sleep(100 * time.Millisecond)
+	if output.getAttempts() != 1 {
+		t.Errorf("Message is expected to be sent once, got %d", output.getAttempts())
+	}
+}
+
+func TestResumeFromOutbox(t *testing.T) {
+	dbPathReal := dbservice.DbPath
+	defer func() {
+		os.Remove(dbservice.DbPath)
+		dbservice.DbPath = dbPathReal
+	}()
+	dbservice.DbPath = "test_webhooks.db"
+
+	pending := &dbservice.OutboxMessage{
+		Route:    "route1",
+		Output:   "flaky",
+		Template: "raw",
+		Content:  map[string]string{"title": "saved before restart"},
+	}
+	if err := dbservice.SaveOutboxMessage(pending); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	output := &flakyOutput{sent: make(chan map[string]string, 10)}
+	d := Instance()
+	d.Register(output, Options{Type: "flaky", Retry: testPolicy})
+	d.Start()
+	defer d.Terminate()
+
+	select {
+	case content := <-output.sent:
+		if content["title"] != "saved before restart" {
+			t.Errorf("Unexpected content %v", content)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("pending message wasn't resumed")
+	}
+	for i := 0; ; i++ {
+		messages, err := dbservice.GetOutboxMessages()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(messages) == 0 {
+			break
+		}
+		if i == 100 {
+			t.Fatal("delivered message isn't removed from the outbox")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSendTimeout(t *testing.T) {
+	dbPathReal := dbservice.DbPath
+	defer func() {
+		os.Remove(dbservice.DbPath)
+		dbservice.DbPath = dbPathReal
+	}()
+	dbservice.DbPath = "test_webhooks.db"
+
+	output := &blockingOutput{started: make(chan struct{}, 10)}
+	d := Instance()
+	d.Register(output, Options{Type: "blocking", Retry: RetryPolicy{MaxAttempts: 1}, Timeout: 50 * time.Millisecond})
+	d.Start()
+	defer d.Terminate()
+
+	status, err := d.Dispatch(context.Background(), "route1", "raw", output, map[string]string{"title": "title"})
+	if status != Failed {
+		t.Errorf("Wrong status, expected %q, got %q", Failed, status)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Deadline exceeded error is expected, got %v", err)
+	}
+}
+
+func TestTerminateCancelsSends(t *testing.T) {
+	dbPathReal := dbservice.DbPath
+	defer func() {
+		os.Remove(dbservice.DbPath)
+		dbservice.DbPath = dbPathReal
+	}()
+	dbservice.DbPath = "test_webhooks.db"
+
+	output := &blockingOutput{started: make(chan struct{}, 10)}
+	d := Instance()
+	d.Register(output, Options{Type: "blocking", Retry: testPolicy, Timeout: time.Minute})
+	d.Start()
+
+	type result struct {
+		status Status
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		status, err := d.Dispatch(context.Background(), "route1", "raw", output, map[string]string{"title": "in-flight"})
+		done <- result{status, err}
+	}()
+	select {
+	case <-output.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("send didn't start")
+	}
+
+	terminated := make(chan struct{})
+	go func() {
+		d.Terminate()
+		close(terminated)
+	}()
+	select {
+	case <-terminated:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Terminate didn't cancel the in-flight send")
+	}
+	r := <-done
+	if r.status != Retrying || !errors.Is(r.err, context.Canceled) {
+		t.Errorf("Unexpected result of the cancelled send: %q, %v", r.status, r.err)
+	}
+
+	messages, err := dbservice.GetOutboxMessages()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(messages) != 1 || len(messages[0].Attempts) != 0 {
+		t.Errorf("Cancelled message is expected to stay in the outbox without attempts, got %v", messages)
+	}
+	deadLetters, err := dbservice.GetDeadLetters()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(deadLetters) != 0 {
+		t.Errorf("Cancelled message shouldn't be moved to dead letters")
+	}
+}
+
+func TestEnqueueDoesNotWaitForSends(t *testing.T) {
+	dbPathReal := dbservice.DbPath
+	defer func() {
+		os.Remove(dbservice.DbPath)
+		dbservice.DbPath = dbPathReal
+	}()
+	dbservice.DbPath = "test_webhooks.db"
+
+	blocking := &blockingOutput{started: make(chan struct{}, 10)}
+	flaky := &flakyOutput{sent: make(chan map[string]string, 10)}
+	d := Instance()
+	d.Register(blocking, Options{Type: "blocking", Retry: testPolicy, MaxConcurrency: 1})
+	d.Register(flaky, Options{Type: "flaky", Retry: testPolicy})
+	d.Start()
+
+	first := d.Enqueue(context.Background(), "route1", "raw", blocking, map[string]string{"title": "first"})
+	select {
+	case <-blocking.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("send didn't start")
+	}
+	second := d.Enqueue(context.Background(), "route1", "raw", blocking, map[string]string{"title": "second"})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if status, err := second.Wait(ctx); status != Queued || err != context.DeadlineExceeded {
+		t.Errorf("Message is expected to wait in the queue, got %q, %v", status, err)
+	}
+	if status, err := d.Dispatch(context.Background(), "route1", "raw", flaky, map[string]string{"title": "other"}); status != Delivered {
+		t.Errorf("Other output is expected to get its message, got %q, %v", status, err)
+	}
+
+	d.Terminate()
+	if status, err := first.Wait(context.Background()); status != Retrying || !errors.Is(err, context.Canceled) {
+		t.Errorf("Unexpected result of the cancelled send: %q, %v", status, err)
+	}
+	if status, err := second.Wait(context.Background()); status != Retrying || err != context.Canceled {
+		t.Errorf("Unexpected result of the queued message: %q, %v", status, err)
+	}
+	messages, err := dbservice.GetOutboxMessages()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Errorf("Messages of the blocking output are expected to stay in the outbox, got %d", len(messages))
+	}
+}
+
+// countingOutput keeps the highest number of its concurrent sends
+type countingOutput struct {
+	name    string
+	mu      *sync.Mutex
+	current *int
+	max     *int
+}
+
+func (o *countingOutput) GetName() string { return o.name }
+func (o *countingOutput) Init() error     { return nil }
+func (o *countingOutput) Send(ctx context.Context, content map[string]string) error {
+	o.mu.Lock()
+	*o.current++
+	if *o.current > *o.max {
+		*o.max = *o.current
+	}
+	o.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	o.mu.Lock()
+	*o.current--
+	o.mu.Unlock()
+	return nil
+}
+func (o *countingOutput) Terminate() error { return nil }
+func (o *countingOutput) GetLayoutProvider() layout.LayoutProvider {
+	return new(formatting.HtmlProvider)
+}
+
+func TestConcurrencyLimits(t *testing.T) {
+	tests := []struct {
+		caseDesc    string
+		opts        []Options
+		expectedMax int
+	}{
+		{"Concurrency limit", []Options{{MaxConcurrency: 2}}, 2},
+		{"Ordering key is shared by outputs", []Options{{OrderingKey: "jira:PRJ"}, {OrderingKey: "jira:PRJ"}}, 1},
+	}
+	for _, test := range tests {
+		dbPathReal := dbservice.DbPath
+		dbservice.DbPath = "test_webhooks.db"
+
+		mu := &sync.Mutex{}
+		current, max := 0, 0
+		d := Instance()
+		targets := make([]outputs.Output, 0)
+		for i, opts := range test.opts {
+			output := &countingOutput{name: fmt.Sprintf("counting%d", i), mu: mu, current: &current, max: &max}
+			opts.Retry = testPolicy
+			d.Register(output, opts)
+			targets = append(targets, output)
+		}
+		d.Start()
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 6; i++ {
+			wg.Add(1)
+			go func(output outputs.Output) {
+				defer wg.Done()
+				if status, err := d.Dispatch(context.Background(), "route1", "raw", output, map[string]string{"title": "title"}); status != Delivered {
+					t.Errorf("[%s] Unexpected result: %q, %v", test.caseDesc, status, err)
+				}
+			}(targets[i%len(targets)])
+		}
+		wg.Wait()
+		d.Terminate()
+		os.Remove(dbservice.DbPath)
+		dbservice.DbPath = dbPathReal
+
+		if max != test.expectedMax {
+			t.Errorf("[%s] Wrong number of concurrent sends, expected %d, got %d", test.caseDesc, test.expectedMax, max)
+		}
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	dbPathReal := dbservice.DbPath
+	defer func() {
+		os.Remove(dbservice.DbPath)
+		dbservice.DbPath = dbPathReal
+	}()
+	dbservice.DbPath = "test_webhooks.db"
+
+	output := &flakyOutput{
+		errs: []error{utils.NewHttpError(503, "unavailable"), utils.NewHttpError(503, "unavailable")},
+		sent: make(chan map[string]string, 10),
+	}
+	d := Instance()
+	d.Register(output, Options{
+		Type:             "flaky",
+		Retry:            RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Minute, MaxBackoff: time.Minute},
+		BreakerThreshold: 2,
+		BreakerCooldown:  100 * time.Millisecond,
+	})
+	d.Start()
+	defer d.Terminate()
+
+	for i := 1; i <= 2; i++ {
+		d.Dispatch(context.Background(), "route1", "raw", output, map[string]string{"title": fmt.Sprint(i)})
+		<-output.sent
+	}
+	status, err := d.Dispatch(context.Background(), "route1", "raw", output, map[string]string{"title": "3"})
+	if status != Retrying || err != ErrCircuitOpen {
+		t.Errorf("Message is expected to be kept while the circuit is open, got %q, %v", status, err)
+	}
+	if output.getAttempts() != 2 {
+		t.Errorf("Output shouldn't be called while the circuit is open, got %d attempt(s)", output.getAttempts())
+	}
+	health, err := d.Health()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(health) != 1 || health[0].State != CircuitOpen || health[0].ConsecutiveFailures != 2 || health[0].Pending != 3 {
+		t.Errorf("Unexpected health: %+v", health)
+	}
+
+	//the kept message is the probe, the others wait for their retries
+	select {
+	case content := <-output.sent:
+		if content["title"] != "3" {
+			t.Errorf("Unexpected probe %v", content)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("probe wasn't sent")
+	}
+	for i := 0; ; i++ {
+		health, err = d.Health()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if health[0].State == CircuitClosed && health[0].Pending == 2 {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("Circuit isn't closed after the successful probe: %+v", health)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCircuitBreakerProbeInterrupted(t *testing.T) {
+	dbPathReal := dbservice.DbPath
+	defer func() {
+		os.Remove(dbservice.DbPath)
+		dbservice.DbPath = dbPathReal
+	}()
+	dbservice.DbPath = "test_webhooks.db"
+
+	blocking := &blockingOutput{started: make(chan struct{}, 10)}
+	flaky := &flakyOutput{sent: make(chan map[string]string, 10)}
+	d := Instance()
+	d.Register(blocking, Options{Type: "blocking", Retry: testPolicy, OrderingKey: "jira:PRJ"})
+	d.Register(flaky, Options{Type: "flaky", Retry: testPolicy, OrderingKey: "jira:PRJ", BreakerThreshold: 1, BreakerCooldown: time.Minute})
+	d.Start()
+	defer d.Terminate()
+
+	go d.Dispatch(context.Background(), "route1", "raw", blocking, map[string]string{"title": "in-flight"})
+	select {
+	case <-blocking.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("send didn't start")
+	}
+
+	d.mu.Lock()
+	d.breakers[flaky.GetName()].state = CircuitHalfOpen
+	ordering := d.orders["jira:PRJ"]
+	d.mu.Unlock()
+
+	done := make(chan Status, 1)
+	go func() {
+		status, _ := d.Dispatch(context.Background(), "route1", "raw", flaky, map[string]string{"title": "probe"})
+		done <- status
+	}()
+	for i := 0; ; i++ {
+		ordering.mu.Lock()
+		waiting := len(ordering.waiters)
+		ordering.mu.Unlock()
+		if waiting == 1 {
+			break
+		}
+		if i == 100 {
+			t.Fatal("probe doesn't wait for the ordering lane")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	//shutdown cancels the probe before it's sent
+	d.cancel()
+	select {
+	case status := <-done:
+		if status != Retrying {
+			t.Errorf("Interrupted probe is expected to be kept in the outbox, got %q", status)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("probe wasn't interrupted")
+	}
+	if flaky.getAttempts() != 0 {
+		t.Errorf("Interrupted probe shouldn't be sent")
+	}
+	if allowed, probe := d.allow(flaky.GetName()); !allowed || !probe {
+		t.Errorf("Next message is expected to be a probe after the interrupted one")
+	}
+}
+
+func TestCircuitBreakerIgnoresPermanentErrors(t *testing.T) {
+	dbPathReal := dbservice.DbPath
+	defer func() {
+		os.Remove(dbservice.DbPath)
+		dbservice.DbPath = dbPathReal
+	}()
+	dbservice.DbPath = "test_webhooks.db"
+
+	const threshold = 2
+	var errs []error
+	for i := 0; i < threshold+1; i++ {
+		errs = append(errs, outputs.Permanent(errors.New("invalid payload")))
+	}
+	output := &flakyOutput{errs: errs, sent: make(chan map[string]string, 10)}
+	d := Instance()
+	d.Register(output, Options{
+		Type:             "flaky",
+		Retry:            testPolicy,
+		BreakerThreshold: threshold,
+		BreakerCooldown:  time.Minute,
+	})
+	d.Start()
+	defer d.Terminate()
+
+	for i := 0; i < threshold+1; i++ {
+		if status, _ := d.Dispatch(context.Background(), "route1", "raw", output, map[string]string{"title": fmt.Sprint(i)}); status != Failed {
+			t.Errorf("Message with a permanent error is expected to fail, got %q", status)
+		}
+		<-output.sent
+	}
+	status, err := d.Dispatch(context.Background(), "route1", "raw", output, map[string]string{"title": "healthy"})
+	if status != Delivered || err != nil {
+		t.Errorf("Circuit shouldn't be opened by permanent errors, got %q, %v", status, err)
+	}
+	health, err := d.Health()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(health) != 1 || health[0].State != CircuitClosed || health[0].ConsecutiveFailures != 0 {
+		t.Errorf("Unexpected health: %+v", health)
+	}
+	deadLetters, err := dbservice.GetDeadLetters()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(deadLetters) != threshold+1 {
+		t.Errorf("Messages with permanent errors are expected in dead letters, got %d", len(deadLetters))
+	}
+}
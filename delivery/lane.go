@@ -0,0 +1,62 @@
+package delivery
+
+import (
+	"context"
+	"sync"
+)
+
+// lane limits the number of concurrent sends. Sends waiting for a free slot get it in arrival order,
+// so a lane of size 1 keeps messages ordered.
+type lane struct {
+	mu      sync.Mutex
+	size    int
+	busy    int
+	waiters []chan struct{}
+}
+
+func newLane(size int) *lane {
+	return &lane{size: size}
+}
+
+// acquire blocks until a slot is free or the context is done
+func (l *lane) acquire(ctx context.Context) error {
+	l.mu.Lock()
+	if l.busy < l.size && len(l.waiters) == 0 {
+		l.busy++
+		l.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	l.waiters = append(l.waiters, ready)
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		for i, w := range l.waiters {
+			if w == ready {
+				l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+				l.mu.Unlock()
+				return ctx.Err()
+			}
+		}
+		l.mu.Unlock()
+		//the slot was handed over before the context was done
+		l.release()
+		return ctx.Err()
+	}
+}
+
+// release hands the slot over to the first waiting send, if any
+func (l *lane) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.waiters) > 0 {
+		close(l.waiters[0])
+		l.waiters = l.waiters[1:]
+		return
+	}
+	l.busy--
+}
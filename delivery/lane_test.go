@@ -0,0 +1,73 @@
+package delivery
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLaneOrder(t *testing.T) {
+	l := newLane(1)
+	if err := l.acquire(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	mu := sync.Mutex{}
+	order := make([]int, 0)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := l.acquire(context.Background()); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+				return
+			}
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			l.release()
+		}(i)
+		//let the send join the queue before the next one
+		for waiting(l) != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	l.release()
+	wg.Wait()
+
+	for i, n := range order {
+		if i != n {
+			t.Fatalf("Sends aren't taken in arrival order: %v", order)
+		}
+	}
+}
+
+func TestLaneSize(t *testing.T) {
+	l := newLane(2)
+	for i := 0; i < 2; i++ {
+		if err := l.acquire(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Lane is expected to be full, got %v", err)
+	}
+	if waiting(l) != 0 {
+		t.Errorf("Cancelled send is expected to leave the queue")
+	}
+
+	l.release()
+	if err := l.acquire(context.Background()); err != nil {
+		t.Fatalf("Released slot isn't available: %v", err)
+	}
+}
+
+func waiting(l *lane) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.waiters)
+}
@@ -0,0 +1,132 @@
+package delivery
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aquasecurity/postee/v2/dbservice"
+)
+
+// DefaultDeliveryWorkers is the number of concurrent sends to an output without a concurrency limit
+const DefaultDeliveryWorkers = 10
+
+// Receipt tells the outcome of the first delivery attempt of a queued message
+type Receipt struct {
+	done   chan struct{}
+	status Status
+	err    error
+}
+
+func newReceipt() *Receipt {
+	return &Receipt{done: make(chan struct{})}
+}
+
+func (r *Receipt) complete(status Status, err error) {
+	r.status, r.err = status, err
+	close(r.done)
+}
+
+// Wait blocks until the first delivery attempt is made. If the context is done first,
+// the message stays in the queue and Wait returns Queued along with the error of the context.
+func (r *Receipt) Wait(ctx context.Context) (Status, error) {
+	select {
+	case <-r.done:
+		return r.status, r.err
+	case <-ctx.Done():
+		return Queued, ctx.Err()
+	}
+}
+
+// job is a message waiting in the delivery queue of an output
+type job struct {
+	msg     *dbservice.OutboxMessage
+	receipt *Receipt //nil if nobody waits for the outcome
+}
+
+// queue keeps messages waiting for delivery to an output, its workers take them in arrival order.
+// A queue with a single worker sends messages one at a time.
+type queue struct {
+	mu       sync.Mutex
+	ready    *sync.Cond
+	jobs     []*job
+	workers  int
+	stopping int //workers which have to stop
+	closed   bool
+}
+
+func newQueue() *queue {
+	q := &queue{}
+	q.ready = sync.NewCond(&q.mu)
+	return q
+}
+
+// push adds a job to the end of the queue. It returns false if the queue is closed.
+func (q *queue) push(j *job) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return false
+	}
+	q.jobs = append(q.jobs, j)
+	q.ready.Signal()
+	return true
+}
+
+// resize starts or stops workers which run the jobs. Busy workers stop after their current job.
+func (q *queue) resize(n int, run func(*job)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	for ; q.workers < n; q.workers++ {
+		go q.work(run)
+	}
+	if extra := q.workers - n; extra > 0 {
+		q.workers = n
+		q.stopping += extra
+		q.ready.Broadcast()
+	}
+}
+
+func (q *queue) work(run func(*job)) {
+	for {
+		q.mu.Lock()
+		for len(q.jobs) == 0 && !q.closed && q.stopping == 0 {
+			q.ready.Wait()
+		}
+		if q.closed || q.stopping > 0 {
+			if q.stopping > 0 {
+				q.stopping--
+			}
+			q.mu.Unlock()
+			return
+		}
+		j := q.jobs[0]
+		q.jobs = q.jobs[1:]
+		q.mu.Unlock()
+		run(j)
+	}
+}
+
+// close stops the workers and returns the jobs which are left in the queue
+func (q *queue) close() []*job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	jobs := q.jobs
+	q.jobs = nil
+	q.ready.Broadcast()
+	return jobs
+}
+
+// deliveryWorkers returns the number of concurrent sends to an output
+func deliveryWorkers(opts Options) int {
+	switch {
+	case opts.OrderingKey != "":
+		return 1
+	case opts.MaxConcurrency > 0:
+		return opts.MaxConcurrency
+	}
+	return DefaultDeliveryWorkers
+}
@@ -0,0 +1,43 @@
+package delivery
+
+import (
+	"math/rand"
+	"time"
+)
+
+const (
+	DefaultMaxAttempts    = 5
+	DefaultInitialBackoff = time.Second
+	DefaultMaxBackoff     = 5 * time.Minute
+)
+
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    DefaultMaxAttempts,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+	}
+}
+
+// Backoff returns a delay before the next attempt. The delay doubles after each failed attempt
+// up to MaxBackoff, a random jitter of up to a half of the delay is applied to spread retries out.
+func (policy RetryPolicy) Backoff(failedAttempts int) time.Duration {
+	delay := policy.InitialBackoff
+	for i := 1; i < failedAttempts && delay < policy.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+	half := int64(delay / 2)
+	if half <= 0 {
+		return delay
+	}
+	return time.Duration(half + rand.Int63n(half+1))
+}
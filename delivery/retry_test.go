@@ -0,0 +1,34 @@
+package delivery
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: time.Second,
+		MaxBackoff:     10 * time.Second,
+	}
+	tests := []struct {
+		failedAttempts int
+		max            time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{20, 10 * time.Second},
+	}
+	for _, test := range tests {
+		for i := 0; i < 10; i++ {
+			d := policy.Backoff(test.failedAttempts)
+			if d < test.max/2 || d > test.max {
+				t.Errorf("Backoff after %d attempt(s) is %s, expected between %s and %s",
+					test.failedAttempts, d, test.max/2, test.max)
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/aquasecurity/postee/v2/router"
+	"github.com/spf13/cobra"
+)
+
+var dryRunCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Show what would be sent for a message without sending it",
+	Long: "Route a message from a file by the alert configuration file and render it by templates of matched routes.\n" +
+		"Outputs aren't called, the result is printed per output along with the reasons of missed routes.",
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		input, _ := cmd.Flags().GetString("input")
+		route, _ := cmd.Flags().GetString("route")
+		os.Exit(dryRun(os.Stdout, cmd.Flag("cfgfile").Value.String(), input, route))
+	},
+}
+
+func init() {
+	dryRunCmd.Flags().String("cfgfile", CFG_FILE, CFG_USAGE)
+	dryRunCmd.Flags().String("input", "", "The file with a message (scan result or event) in JSON.")
+	dryRunCmd.Flags().String("route", "", "The route to evaluate, all routes are evaluated by default.")
+	dryRunCmd.MarkFlagRequired("input")
+	rootCmd.AddCommand(dryRunCmd)
+}
+
+func dryRun(w io.Writer, cfgfile, inputFile, route string) int {
+	//only problems are logged, the result is printed
+	if err := logging.Configure("", "error"); err != nil {
+		logging.Errorf("Invalid logging settings: %v", err)
+	}
+	tenant, err := router.Parsev2cfg(cfgfile)
+	if err != nil {
+		return 1
+	}
+	input, err := ioutil.ReadFile(inputFile)
+	if err != nil {
+		logging.Errorf("Failed to open file %s, %s", inputFile, err)
+		return 1
+	}
+	results, err := router.DryRun(context.Background(), tenant, input, route)
+	if err != nil {
+		logging.Errorf("Can't route %s: %v", inputFile, err)
+		return 1
+	}
+
+	for _, r := range results {
+		switch {
+		case !r.Matched:
+			fmt.Fprintf(w, "route %q: missed: %s\n", r.Route, r.Reason)
+			continue
+		case r.Reason != "":
+			fmt.Fprintf(w, "route %q: matched, but %s\n", r.Route, r.Reason)
+			continue
+		}
+		fmt.Fprintf(w, "route %q: matched, template %q\n", r.Route, r.Template)
+		if r.Note != "" {
+			fmt.Fprintf(w, "  note: %s\n", r.Note)
+		}
+		for _, o := range r.Outputs {
+			if o.Note != "" {
+				fmt.Fprintf(w, "  output %q: %s\n", o.Output, o.Note)
+				continue
+			}
+			fmt.Fprintf(w, "  output %q (%s):\n", o.Output, o.Type)
+			printContent(w, o.Content)
+		}
+	}
+	return 0
+}
+
+// printContent prints title and description first, then other fields rendered by a template
+func printContent(w io.Writer, content map[string]string) {
+	keys := make([]string, 0, len(content))
+	for key := range content {
+		if key != "title" && key != "description" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range append([]string{"title", "description"}, keys...) {
+		value, ok := content[key]
+		if !ok || value == "" {
+			continue
+		}
+		if !strings.Contains(value, "\n") {
+			fmt.Fprintf(w, "    %s: %s\n", key, value)
+			continue
+		}
+		fmt.Fprintf(w, "    %s:\n", key)
+		for _, line := range strings.Split(strings.TrimRight(value, "\n"), "\n") {
+			fmt.Fprintf(w, "      %s\n", line)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package formatting
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,7 +17,7 @@ type legacyScnEvaluator struct {
 	layoutProvider layout.LayoutProvider
 }
 
-func (legacyScnEvaluator *legacyScnEvaluator) Eval(in map[string]interface{}, serverUrl string) (map[string]string, error) {
+func (legacyScnEvaluator *legacyScnEvaluator) Eval(ctx context.Context, in map[string]interface{}, serverUrl string) (map[string]string, error) {
 	scan, err := toScanImage(in)
 	if err != nil {
 		return nil, err
@@ -1,6 +1,7 @@
 package formatting
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -44,7 +45,7 @@ func TestEval(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Unexpected error %v\n", err)
 	}
-	out, err := e.Eval(in, "")
+	out, err := e.Eval(context.Background(), in, "")
 
 	assert.NoError(t, err)
 
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const (
+	CorrelationIdKey = "correlation_id"
+	TenantKey        = "tenant"
+)
+
+type correlationIdCtxKey struct{}
+type tenantCtxKey struct{}
+
+// NewCorrelationId returns a random id to trace a message through routing and delivery
+func NewCorrelationId() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+func WithCorrelationId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIdCtxKey{}, id)
+}
+
+func CorrelationId(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(correlationIdCtxKey{}).(string)
+	return id
+}
+
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantCtxKey{}, tenant)
+}
+
+func Tenant(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	tenant, _ := ctx.Value(tenantCtxKey{}).(string)
+	return tenant
+}
+
+// FromContext returns a logger which tags lines with the tenant and the correlation id of the context, if any
+func FromContext(ctx context.Context) *Logger {
+	logger := root
+	if tenant := Tenant(ctx); tenant != "" {
+		logger = logger.With(TenantKey, tenant)
+	}
+	if id := CorrelationId(ctx); id != "" {
+		logger = logger.With(CorrelationIdKey, id)
+	}
+	return logger
+}
@@ -0,0 +1,212 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+const (
+	FormatText = "text"
+	FormatJson = "json"
+
+	EnvLevel  = "POSTEE_LOG_LEVEL"
+	EnvFormat = "POSTEE_LOG_FORMAT"
+
+	timeFormat = "2006/01/02 15:04:05"
+)
+
+var levelNames = map[Level]string{
+	DebugLevel: "debug",
+	InfoLevel:  "info",
+	WarnLevel:  "warn",
+	ErrorLevel: "error",
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+func ParseLevel(s string) (Level, error) {
+	for l, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return l, nil
+		}
+	}
+	if strings.EqualFold(s, "warning") {
+		return WarnLevel, nil
+	}
+	return InfoLevel, fmt.Errorf("unknown log level %q", s)
+}
+
+var (
+	mu     sync.Mutex
+	out    io.Writer = os.Stderr
+	level            = InfoLevel
+	format           = FormatText
+
+	root = &Logger{}
+)
+
+func init() {
+	//lines written by the standard logger are passed through, so they share the format of leveled ones
+	log.SetFlags(0)
+	log.SetOutput(stdWriter{})
+}
+
+// Configure sets the format ("text" or "json") and the minimal level of logs.
+// Empty values don't change the current settings, values set by environment variables take precedence.
+func Configure(logFormat, logLevel string) error {
+	if v := os.Getenv(EnvFormat); v != "" {
+		logFormat = v
+	}
+	if v := os.Getenv(EnvLevel); v != "" {
+		logLevel = v
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if logLevel != "" {
+		l, err := ParseLevel(logLevel)
+		if err != nil {
+			return err
+		}
+		level = l
+	}
+	switch strings.ToLower(logFormat) {
+	case "":
+	case FormatText:
+		format = FormatText
+	case FormatJson:
+		format = FormatJson
+	default:
+		return fmt.Errorf("unknown log format %q", logFormat)
+	}
+	return nil
+}
+
+func SetLevel(l Level) {
+	mu.Lock()
+	level = l
+	mu.Unlock()
+}
+
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	out = w
+	mu.Unlock()
+}
+
+func IsDebug() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return level == DebugLevel
+}
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+// Logger writes leveled lines tagged with a set of fields. Loggers are immutable, With returns a new one.
+type Logger struct {
+	fields []field
+}
+
+func With(key string, value interface{}) *Logger {
+	return root.With(key, value)
+}
+
+func (l *Logger) With(key string, value interface{}) *Logger {
+	fields := make([]field, len(l.fields), len(l.fields)+1)
+	copy(fields, l.fields)
+	return &Logger{fields: append(fields, field{key, value})}
+}
+
+func (l *Logger) Debugf(msg string, v ...interface{}) { l.write(DebugLevel, fmt.Sprintf(msg, v...)) }
+func (l *Logger) Infof(msg string, v ...interface{})  { l.write(InfoLevel, fmt.Sprintf(msg, v...)) }
+func (l *Logger) Warnf(msg string, v ...interface{})  { l.write(WarnLevel, fmt.Sprintf(msg, v...)) }
+func (l *Logger) Errorf(msg string, v ...interface{}) { l.write(ErrorLevel, fmt.Sprintf(msg, v...)) }
+
+func Debugf(msg string, v ...interface{}) { root.write(DebugLevel, fmt.Sprintf(msg, v...)) }
+func Infof(msg string, v ...interface{})  { root.write(InfoLevel, fmt.Sprintf(msg, v...)) }
+func Warnf(msg string, v ...interface{})  { root.write(WarnLevel, fmt.Sprintf(msg, v...)) }
+func Errorf(msg string, v ...interface{}) { root.write(ErrorLevel, fmt.Sprintf(msg, v...)) }
+
+func (l *Logger) write(lvl Level, msg string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if lvl < level {
+		return
+	}
+	msg = strings.TrimRight(msg, "\n")
+	now := time.Now()
+
+	var line []byte
+	if format == FormatJson {
+		entry := make(map[string]interface{}, len(l.fields)+3)
+		for _, f := range l.fields {
+			entry[f.key] = jsonValue(f.value)
+		}
+		entry["time"] = now.UTC().Format(time.RFC3339Nano)
+		entry["level"] = lvl.String()
+		entry["msg"] = msg
+		var err error
+		line, err = json.Marshal(entry)
+		if err != nil {
+			line = []byte(fmt.Sprintf(`{"level":"error","msg":"unable to encode a log entry: %v"}`, err))
+		}
+	} else {
+		b := strings.Builder{}
+		b.WriteString(now.Format(timeFormat))
+		b.WriteString(" ")
+		b.WriteString(strings.ToUpper(lvl.String()))
+		b.WriteString(" ")
+		b.WriteString(msg)
+		for _, f := range sortedFields(l.fields) {
+			fmt.Fprintf(&b, " %s=%v", f.key, f.value)
+		}
+		line = []byte(b.String())
+	}
+	out.Write(append(line, '\n')) //nolint:errcheck
+}
+
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return v
+}
+
+func sortedFields(fields []field) []field {
+	sorted := make([]field, len(fields))
+	copy(sorted, fields)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].key < sorted[j].key })
+	return sorted
+}
+
+// stdWriter passes lines of the standard logger to the root logger at info level
+type stdWriter struct{}
+
+func (w stdWriter) Write(p []byte) (int, error) {
+	root.write(InfoLevel, string(p))
+	return len(p), nil
+}
@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func setup(t *testing.T, logFormat, logLevel string) *bytes.Buffer {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	SetOutput(buf)
+	SetLevel(InfoLevel)
+	format = FormatText
+	if err := Configure(logFormat, logLevel); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() {
+		SetOutput(os.Stderr)
+		SetLevel(InfoLevel)
+		format = FormatText
+	})
+	return buf
+}
+
+func TestJsonFormat(t *testing.T) {
+	buf := setup(t, "json", "info")
+
+	ctx := WithCorrelationId(context.Background(), "abc123")
+	FromContext(ctx).With("route", "route1").Warnf("message %d", 1)
+
+	entry := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Log line isn't a valid json: %v, %q", err, buf.String())
+	}
+	expected := map[string]interface{}{
+		"level":          "warn",
+		"msg":            "message 1",
+		"route":          "route1",
+		"correlation_id": "abc123",
+	}
+	for k, v := range expected {
+		if entry[k] != v {
+			t.Errorf("Wrong %q, expected %q, got %q", k, v, entry[k])
+		}
+	}
+	if _, ok := entry["time"]; !ok {
+		t.Errorf("Time is missed")
+	}
+}
+
+func TestTextFormat(t *testing.T) {
+	buf := setup(t, "text", "debug")
+
+	With("route", "route1").With(CorrelationIdKey, "abc123").Debugf("message\n")
+	line := buf.String()
+	if !strings.HasSuffix(line, " DEBUG message correlation_id=abc123 route=route1\n") {
+		t.Errorf("Unexpected line %q", line)
+	}
+}
+
+func TestLevels(t *testing.T) {
+	buf := setup(t, "", "warn")
+
+	Debugf("debug")
+	Infof("info")
+	Warnf("warn")
+	Errorf("error")
+	log.Printf("standard")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Wrong number of lines, expected 2, got %d: %q", len(lines), lines)
+	}
+	if !strings.HasSuffix(lines[0], "WARN warn") || !strings.HasSuffix(lines[1], "ERROR error") {
+		t.Errorf("Unexpected lines %q", lines)
+	}
+}
+
+func TestStandardLogger(t *testing.T) {
+	buf := setup(t, "json", "")
+
+	log.Printf("standard %s", "line")
+	entry := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Log line isn't a valid json: %v, %q", err, buf.String())
+	}
+	if entry["msg"] != "standard line" || entry["level"] != "info" {
+		t.Errorf("Unexpected entry %v", entry)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	setup(t, "", "")
+	if err := Configure("xml", ""); err == nil {
+		t.Errorf("Error is expected for unknown format")
+	}
+	if err := Configure("", "verbose"); err == nil {
+		t.Errorf("Error is expected for unknown level")
+	}
+
+	os.Setenv(EnvLevel, "error")
+	defer os.Unsetenv(EnvLevel)
+	if err := Configure("", "debug"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if level != ErrorLevel {
+		t.Errorf("Level of the environment variable is expected, got %s", level)
+	}
+}
@@ -2,13 +2,14 @@ package main
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"os/signal"
 	"runtime"
 	"syscall"
+	"time"
 
 	"github.com/aquasecurity/postee/v2/dbservice"
+	"github.com/aquasecurity/postee/v2/logging"
 	"github.com/aquasecurity/postee/v2/router"
 	"github.com/aquasecurity/postee/v2/utils"
 	"github.com/aquasecurity/postee/v2/webserver"
@@ -23,13 +24,21 @@ const (
 	//	CFG_USAGE  = "The folder which contains alert configuration files."
 	//	CFG_FOLDER = "/config/"
 	CFG_FILE  = "/config/cfg.yaml"
-	CFG_USAGE = "The alert configuration file."
+	CFG_USAGE = "The alert configuration file, a directory or a glob of configuration files."
+
+	CFG_WATCH_USAGE = "How often the alert configuration file is checked for changes, 0 disables watching."
+	STRICT_USAGE    = "Fail on unknown options in the alert configuration file instead of ignoring them."
+	TENANTS_USAGE   = "The folder which contains alert configurations of tenants, a file or a subfolder per tenant."
 )
 
 var (
 	url     = ""
 	tls     = ""
 	cfgfile = ""
+	tenants = ""
+
+	cfgWatchInterval time.Duration
+	strictConfig     = false
 )
 
 var rootCmd = &cobra.Command{
@@ -42,11 +51,17 @@ func init() {
 	rootCmd.Flags().StringVar(&url, "url", URL, URL_USAGE)
 	rootCmd.Flags().StringVar(&tls, "tls", TLS, TLS_USAGE)
 	rootCmd.Flags().StringVar(&cfgfile, "cfgfile", CFG_FILE, CFG_USAGE)
+	rootCmd.Flags().DurationVar(&cfgWatchInterval, "cfg-watch-interval", router.WatchIntervalDefault, CFG_WATCH_USAGE)
+	rootCmd.Flags().BoolVar(&strictConfig, "strict-config", false, STRICT_USAGE)
+	rootCmd.Flags().StringVar(&tenants, "tenants-dir", "", TENANTS_USAGE)
 }
 
 func main() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
 	utils.InitDebug()
+	if err := logging.Configure("", ""); err != nil {
+		logging.Errorf("Invalid logging settings: %v", err)
+	}
 
 	rootCmd.Run = func(cmd *cobra.Command, args []string) {
 
@@ -74,26 +89,64 @@ func main() {
 			cfgfile = os.Getenv("POSTEE_CFG")
 		}
 
+		if os.Getenv("POSTEE_TENANTS_DIR") != "" {
+			tenants = os.Getenv("POSTEE_TENANTS_DIR")
+		}
+
+		if os.Getenv("POSTEE_CFG_WATCH_INTERVAL") != "" {
+			interval, err := time.ParseDuration(os.Getenv("POSTEE_CFG_WATCH_INTERVAL"))
+			if err != nil {
+				logging.Errorf("Invalid POSTEE_CFG_WATCH_INTERVAL: %v", err)
+				return
+			}
+			cfgWatchInterval = interval
+		}
+
+		if os.Getenv("POSTEE_STRICT_CONFIG") != "" {
+			strictConfig = os.Getenv("POSTEE_STRICT_CONFIG") == "true"
+		}
+		router.StrictConfig = strictConfig
+
 		if os.Getenv("PATH_TO_DB") != "" {
 			dbservice.SetNewDbPathFromEnv()
 		}
 
 		err := router.Instance().Start(cfgfile)
 		if err != nil {
-			log.Printf("Can't start alert manager %v", err)
+			logging.Errorf("Can't start alert manager %v", err)
 			return
 		}
 
 		defer router.Instance().Terminate()
 
+		if tenants != "" {
+			if err := router.StartTenants(tenants); err != nil {
+				logging.Errorf("Can't start tenants %v", err)
+				return
+			}
+			defer router.TerminateTenants()
+		}
+
 		go webserver.Instance().Start(url, tls)
 		defer webserver.Instance().Terminate()
 
+		if cfgWatchInterval > 0 {
+			watcher := router.NewConfigWatcher(cfgfile, cfgWatchInterval, router.WatchDebounceDefault, router.Instance().ReloadConfig)
+			watcher.Start()
+			defer watcher.Stop()
+
+			for _, tenant := range router.Tenants() {
+				watcher := router.NewConfigWatcher(tenant.CfgFile(), cfgWatchInterval, router.WatchDebounceDefault, tenant.ReloadConfig)
+				watcher.Start()
+				defer watcher.Stop()
+			}
+		}
+
 		Daemonize()
 	}
 	err := rootCmd.Execute()
 	if err != nil {
-		log.Printf("Can't start command %v", err)
+		logging.Errorf("Can't start command %v", err)
 		return
 	}
 }
@@ -101,12 +154,19 @@ func main() {
 func Daemonize() {
 	sigs := make(chan os.Signal, 1)
 	done := make(chan bool, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	go func() {
-		sig := <-sigs
-		log.Println(sig)
-		done <- true
+		for sig := range sigs {
+			logging.Infof("Received signal %s", sig)
+			if sig == syscall.SIGHUP {
+				router.Instance().ReloadConfig()
+				router.ReloadTenants()
+				continue
+			}
+			done <- true
+			return
+		}
 	}()
 
 	<-done
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/aquasecurity/postee/v2/dbservice"
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	dbSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "db_size_bytes"),
+		"Size of the database file.",
+		[]string{"tenant"}, nil)
+	aggregationDepthDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "aggregation_queue_depth"),
+		"Number of messages waiting in the aggregation queue of a route.",
+		[]string{"tenant", "route"}, nil)
+)
+
+var (
+	mutexStores sync.Mutex
+	stores      = map[string]*dbservice.Store{"": nil} //databases by tenant, nil is the default one
+)
+
+// RegisterStore adds the database of a tenant to the collected metrics
+func RegisterStore(tenant string, store *dbservice.Store) {
+	mutexStores.Lock()
+	defer mutexStores.Unlock()
+	stores[tenant] = store
+}
+
+func UnregisterStore(tenant string) {
+	mutexStores.Lock()
+	defer mutexStores.Unlock()
+	delete(stores, tenant)
+}
+
+// dbCollector reads values which are kept in the database at scrape time
+type dbCollector struct{}
+
+func (c *dbCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbSizeDesc
+	ch <- aggregationDepthDesc
+}
+
+func (c *dbCollector) Collect(ch chan<- prometheus.Metric) {
+	mutexStores.Lock()
+	collected := make(map[string]*dbservice.Store, len(stores))
+	for tenant, store := range stores {
+		collected[tenant] = store
+	}
+	mutexStores.Unlock()
+
+	for tenant, store := range collected {
+		size, err := store.GetDbSize()
+		if err != nil {
+			logging.Errorf("Unable to get the database size: %v", err)
+		} else {
+			ch <- prometheus.MustNewConstMetric(dbSizeDesc, prometheus.GaugeValue, float64(size), tenant)
+		}
+
+		depths, err := store.GetAggregationQueueDepths()
+		if err != nil {
+			logging.Errorf("Unable to get aggregation queues: %v", err)
+			continue
+		}
+		for route, depth := range depths {
+			ch <- prometheus.MustNewConstMetric(aggregationDepthDesc, prometheus.GaugeValue, float64(depth), tenant, route)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const namespace = "postee"
+
+// Results of delivery attempts
+const (
+	ResultDelivered = "delivered"
+	ResultRetrying  = "retrying"
+	ResultFailed    = "failed"
+)
+
+var (
+	registry = prometheus.NewRegistry()
+
+	messagesReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_received_total",
+		Help:      "Number of messages accepted for routing.",
+	}, []string{"tenant"})
+	routeMatches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "route_matches_total",
+		Help:      "Number of messages which matched a route.",
+	}, []string{"tenant", "route"})
+	routeMisses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "route_misses_total",
+		Help:      "Number of messages which didn't match a route.",
+	}, []string{"tenant", "route"})
+	regoEvaluation = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rego_evaluation_seconds",
+		Help:      "Time spent evaluating a template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"tenant", "template"})
+	deliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "deliveries_total",
+		Help:      "Number of delivery attempts by result: delivered, retrying (the attempt failed and will be retried) or failed (the message became a dead letter).",
+	}, []string{"tenant", "output", "type", "result"})
+	deliveryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "delivery_duration_seconds",
+		Help:      "Time spent sending a message to an output.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"tenant", "output", "type"})
+	dedupHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dedup_hits_total",
+		Help:      "Number of messages dropped because the same message was received before.",
+	}, []string{"tenant", "route"})
+	routingQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "routing_queue_depth",
+		Help:      "Number of messages waiting in the routing queue.",
+	}, []string{"tenant"})
+	routingWorkersBusy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "routing_workers_busy",
+		Help:      "Number of routing workers handling a message.",
+	}, []string{"tenant"})
+	outputQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "output_queue_depth",
+		Help:      "Number of sends waiting for a concurrency or ordering slot of an output.",
+	}, []string{"tenant", "output"})
+	circuitState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "output_circuit_state",
+		Help:      "State of the circuit breaker of an output: 0 - closed, 1 - half-open, 2 - open.",
+	}, []string{"tenant", "output"})
+	outputSendsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "output_sends_in_flight",
+		Help:      "Number of sends to an output in progress.",
+	}, []string{"tenant", "output"})
+)
+
+func init() {
+	registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		messagesReceived,
+		routeMatches,
+		routeMisses,
+		regoEvaluation,
+		deliveries,
+		deliveryDuration,
+		dedupHits,
+		routingQueueDepth,
+		routingWorkersBusy,
+		outputQueueDepth,
+		outputSendsInFlight,
+		circuitState,
+		&dbCollector{},
+	)
+}
+
+// Handler serves metrics of the process and of the default configuration in Prometheus format.
+// Metrics of tenants are served by TenantHandler, so their routes and outputs aren't listed here.
+func Handler() http.Handler {
+	return TenantHandler("")
+}
+
+// TenantHandler serves metrics of a tenant in Prometheus format
+func TenantHandler(tenant string) http.Handler {
+	return promhttp.HandlerFor(tenantGatherer(tenant), promhttp.HandlerOpts{})
+}
+
+// tenantGatherer selects metrics whose tenant label is the tenant, metrics without the label belong to the default configuration
+type tenantGatherer string
+
+func (tenant tenantGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := registry.Gather()
+	selected := make([]*dto.MetricFamily, 0, len(families))
+	for _, family := range families {
+		metrics := make([]*dto.Metric, 0, len(family.Metric))
+		for _, m := range family.Metric {
+			if tenantOf(m) != string(tenant) {
+				continue
+			}
+			if tenant == "" {
+				//metrics of the default configuration are served without the empty label, as before tenants
+				m.Label = withoutTenant(m.Label)
+			}
+			metrics = append(metrics, m)
+		}
+		if len(metrics) > 0 {
+			family.Metric = metrics
+			selected = append(selected, family)
+		}
+	}
+	return selected, err
+}
+
+func withoutTenant(labels []*dto.LabelPair) []*dto.LabelPair {
+	kept := make([]*dto.LabelPair, 0, len(labels))
+	for _, label := range labels {
+		if label.GetName() != "tenant" {
+			kept = append(kept, label)
+		}
+	}
+	return kept
+}
+
+func tenantOf(m *dto.Metric) string {
+	for _, label := range m.Label {
+		if label.GetName() == "tenant" {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
+
+func MessageReceived(tenant string) {
+	messagesReceived.WithLabelValues(tenant).Inc()
+}
+
+func RouteHandled(tenant, route string, matched bool) {
+	if matched {
+		routeMatches.WithLabelValues(tenant, route).Inc()
+	} else {
+		routeMisses.WithLabelValues(tenant, route).Inc()
+	}
+}
+
+func RegoEvaluated(tenant, template string, elapsed time.Duration) {
+	regoEvaluation.WithLabelValues(tenant, template).Observe(elapsed.Seconds())
+}
+
+func DeliveryAttempted(tenant, output, outputType, result string, elapsed time.Duration) {
+	deliveries.WithLabelValues(tenant, output, outputType, result).Inc()
+	deliveryDuration.WithLabelValues(tenant, output, outputType).Observe(elapsed.Seconds())
+}
+
+func DedupHit(tenant, route string) {
+	dedupHits.WithLabelValues(tenant, route).Inc()
+}
+
+func RoutingQueueDepth(tenant string, depth int) {
+	routingQueueDepth.WithLabelValues(tenant).Set(float64(depth))
+}
+
+func WorkerBusy(tenant string) {
+	routingWorkersBusy.WithLabelValues(tenant).Inc()
+}
+
+func WorkerIdle(tenant string) {
+	routingWorkersBusy.WithLabelValues(tenant).Dec()
+}
+
+// SendQueued is called when a message is put to the delivery queue of an output
+func SendQueued(tenant, output string) {
+	outputQueueDepth.WithLabelValues(tenant, output).Inc()
+}
+
+// SendStarted is called when a message leaves the delivery queue of an output
+func SendStarted(tenant, output string) {
+	outputQueueDepth.WithLabelValues(tenant, output).Dec()
+	outputSendsInFlight.WithLabelValues(tenant, output).Inc()
+}
+
+func SendFinished(tenant, output string) {
+	outputSendsInFlight.WithLabelValues(tenant, output).Dec()
+}
+
+var circuitStates = map[string]float64{
+	"closed":    0,
+	"half-open": 1,
+	"open":      2,
+}
+
+func CircuitStateChanged(tenant, output, state string) {
+	circuitState.WithLabelValues(tenant, output).Set(circuitStates[state])
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aquasecurity/postee/v2/dbservice"
+)
+
+func TestHandler(t *testing.T) {
+	dbPathReal := dbservice.DbPath
+	defer func() {
+		os.Remove(dbservice.DbPath)
+		dbservice.DbPath = dbPathReal
+	}()
+	dbservice.DbPath = "test_webhooks.db"
+
+	if _, err := dbservice.AggregateScans("route1", map[string]string{"title": "t1"}, 0, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	MessageReceived("")
+	RouteHandled("", "route1", true)
+	RouteHandled("", "route2", false)
+	RegoEvaluated("", "raw", time.Millisecond)
+	DeliveryAttempted("", "my-slack", "slack", ResultDelivered, time.Millisecond)
+	DedupHit("", "route1")
+	RoutingQueueDepth("", 3)
+	WorkerBusy("")
+	SendQueued("", "my-jira")
+	SendStarted("", "my-jira")
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(w.Body)
+
+	expected := []string{
+		`postee_messages_received_total 1`,
+		`postee_route_matches_total{route="route1"} 1`,
+		`postee_route_misses_total{route="route2"} 1`,
+		`postee_rego_evaluation_seconds_count{template="raw"} 1`,
+		`postee_deliveries_total{output="my-slack",result="delivered",type="slack"} 1`,
+		`postee_delivery_duration_seconds_count{output="my-slack",type="slack"} 1`,
+		`postee_dedup_hits_total{route="route1"} 1`,
+		`postee_aggregation_queue_depth{route="route1"} 1`,
+		`postee_routing_queue_depth 3`,
+		`postee_routing_workers_busy 1`,
+		`postee_output_queue_depth{output="my-jira"} 0`,
+		`postee_output_sends_in_flight{output="my-jira"} 1`,
+		`postee_db_size_bytes `,
+	}
+	for _, line := range expected {
+		if !strings.Contains(string(body), line) {
+			t.Errorf("Metrics don't contain %q", line)
+		}
+	}
+}
+
+func TestTenantHandler(t *testing.T) {
+	RouteHandled("bu1", "route-of-bu1", true)
+	DeliveryAttempted("bu1", "my-slack", "slack", ResultFailed, time.Millisecond)
+	CircuitStateChanged("bu1", "my-slack", "open")
+	RouteHandled("bu2", "route-of-bu2", true)
+	CircuitStateChanged("bu2", "my-slack", "closed")
+
+	scrape := func(h http.Handler) string {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+		body, _ := ioutil.ReadAll(w.Body)
+		return string(body)
+	}
+	bu1 := scrape(TenantHandler("bu1"))
+	for _, line := range []string{
+		`postee_route_matches_total{route="route-of-bu1",tenant="bu1"} 1`,
+		`postee_deliveries_total{output="my-slack",result="failed",tenant="bu1",type="slack"} 1`,
+		`postee_output_circuit_state{output="my-slack",tenant="bu1"} 2`,
+	} {
+		if !strings.Contains(bu1, line) {
+			t.Errorf("Metrics of the tenant don't contain %q", line)
+		}
+	}
+	if strings.Contains(bu1, "bu2") || strings.Contains(bu1, "go_goroutines") {
+		t.Errorf("Metrics of the tenant contain metrics of others: %s", bu1)
+	}
+	if all := scrape(Handler()); strings.Contains(all, "bu1") || strings.Contains(all, "bu2") {
+		t.Errorf("Metrics of tenants are served by the default handler: %s", all)
+	}
+}
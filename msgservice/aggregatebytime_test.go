@@ -1,6 +1,7 @@
 package msgservice
 
 import (
+	"context"
 	"log"
 	"os"
 	"testing"
@@ -52,9 +53,9 @@ func TestAggregateByTimeout(t *testing.T) {
 	srvUrl := ""
 
 	srv1 := new(MsgService)
-	srv1.MsgHandling([]byte(mockScan1), demoEmailPlg, demoRoute, demoInptEval, &srvUrl)
-	srv1.MsgHandling([]byte(mockScan2), demoEmailPlg, demoRoute, demoInptEval, &srvUrl)
-	srv1.MsgHandling([]byte(mockScan3), demoEmailPlg, demoRoute, demoInptEval, &srvUrl)
+	srv1.MsgHandling(context.Background(), []byte(mockScan1), demoEmailPlg, demoRoute, demoInptEval, &srvUrl)
+	srv1.MsgHandling(context.Background(), []byte(mockScan2), demoEmailPlg, demoRoute, demoInptEval, &srvUrl)
+	srv1.MsgHandling(context.Background(), []byte(mockScan3), demoEmailPlg, demoRoute, demoInptEval, &srvUrl)
 
 	expectedSchedulerInvctCnt := 1
 
@@ -1,6 +1,7 @@
 package msgservice
 
 import (
+	"context"
 	"os"
 	"sync"
 	"testing"
@@ -66,7 +67,7 @@ func doAggregate(t *testing.T, caseDesc string, expectedSntCnt int, expectedRend
 
 	for _, scan := range scans {
 		srv := new(MsgService)
-		srv.MsgHandling([]byte(scan), demoEmailOutput, demoRoute, demoInptEval, &srvUrl)
+		srv.MsgHandling(context.Background(), []byte(scan), demoEmailOutput, demoRoute, demoInptEval, &srvUrl).Wait(context.Background())
 	}
 
 	demoEmailOutput.wg.Wait()
@@ -1,6 +1,7 @@
 package msgservice
 
 import (
+	"context"
 	"os"
 	"strings"
 	"sync"
@@ -43,7 +44,7 @@ func TestApplicationScopeOwner(t *testing.T) {
 	demoEmailOutput.wg.Add(1)
 
 	srv := new(MsgService)
-	srv.MsgHandling([]byte(scnWithOwners), demoEmailOutput, demoRoute, demoInptEval, &srvUrl)
+	srv.MsgHandling(context.Background(), []byte(scnWithOwners), demoEmailOutput, demoRoute, demoInptEval, &srvUrl).Wait(context.Background())
 
 	demoEmailOutput.wg.Wait()
 
@@ -1,6 +1,7 @@
 package msgservice
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -112,7 +113,7 @@ func sendInputs(t *testing.T, caseDesc string, inputs []string, uniqueMessagePro
 
 	for _, inp := range inputs {
 		srv := new(MsgService)
-		srv.MsgHandling([]byte(inp), demoEmailOutput, demoRoute, demoInptEval, &srvUrl)
+		srv.MsgHandling(context.Background(), []byte(inp), demoEmailOutput, demoRoute, demoInptEval, &srvUrl).Wait(context.Background())
 	}
 
 	demoEmailOutput.wg.Wait()
@@ -1,46 +1,54 @@
 package msgservice
 
 import (
+	"context"
 	"encoding/json"
-	"log"
 	"strings"
 	"time"
 
 	"github.com/aquasecurity/postee/v2/data"
 	"github.com/aquasecurity/postee/v2/dbservice"
+	"github.com/aquasecurity/postee/v2/delivery"
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/aquasecurity/postee/v2/metrics"
 	"github.com/aquasecurity/postee/v2/outputs"
 	"github.com/aquasecurity/postee/v2/regoservice"
 	"github.com/aquasecurity/postee/v2/routes"
 )
 
+// MsgService handles messages of routes. Store and Dispatcher keep messages of a tenant apart,
+// nil ones are the default database and dispatcher.
 type MsgService struct {
+	Store      *dbservice.Store
+	Dispatcher *delivery.Dispatcher
 }
 
-func (scan *MsgService) MsgHandling(input []byte, output outputs.Output, route *routes.InputRoute, inpteval data.Inpteval, AquaServer *string) {
+func (scan *MsgService) MsgHandling(ctx context.Context, input []byte, output outputs.Output, route *routes.InputRoute, inpteval data.Inpteval, AquaServer *string) Result {
 	if output == nil {
-		return
+		return Result{}
 	}
+	logger := logging.FromContext(ctx).With("route", route.Name).With("output", output.GetName())
 
 	in := map[string]interface{}{}
 	if err := json.Unmarshal(input, &in); err != nil {
-		prnInputLogs("json.Unmarshal error for %q: %v", input, err)
-		return
+		logger.Warnf("Unable to parse the input: %v", err)
+		return newResult(StatusInvalidInput, err)
 	}
 
 	if ok, err := regoservice.DoesMatchRegoCriteria(in, route.InputFiles, route.Input); err != nil {
 		if !regoservice.IsUsedRegoFiles(route.InputFiles) {
-			prnInputLogs("Error while evaluating rego rule %s :%v for the input %s", route.Input, err, input)
+			logger.Errorf("Error while evaluating rego rule %s: %v", route.Input, err)
 		} else {
-			prnInputLogs("Error while evaluating rego rule for input files :%v for the input %s", err, input)
+			logger.Errorf("Error while evaluating rego rule for input files: %v", err)
 		}
-		return
+		return newResult(StatusRegoError, err)
 	} else if !ok {
 		if !regoservice.IsUsedRegoFiles(route.InputFiles) {
-			prnInputLogs("Input %s... doesn't match a REGO rule: %s", input, route.Input)
+			logger.Infof("Input doesn't match a REGO rule: %s", route.Input)
 		} else {
-			prnInputLogs("Input %s... doesn't match a REGO input files rule", input)
+			logger.Infof("Input doesn't match a REGO input files rule")
 		}
-		return
+		return newResult(StatusNotMatched, nil)
 	}
 
 	//TODO move logic below somewhere close to Jira output implementation
@@ -62,14 +70,15 @@ func (scan *MsgService) MsgHandling(input []byte, output outputs.Output, route *
 		msgKey := GetMessageUniqueId(in, route.Plugins.UniqueMessageProps)
 		expired := calculateExpired(route.Plugins.UniqueMessageTimeoutSeconds)
 
-		wasStored, err := dbservice.MayBeStoreMessage(input, msgKey, expired)
+		wasStored, err := scan.Store.MayBeStoreMessage(input, msgKey, expired)
 		if err != nil {
-			log.Printf("Error while storing input: %v", err)
-			return
+			logger.Errorf("Error while storing input: %v", err)
+			return newResult(StatusError, err)
 		}
 		if !wasStored {
-			log.Printf("The same message was received before: %s", msgKey)
-			return
+			logger.Infof("The same message was received before: %s", msgKey)
+			metrics.DedupHit(scan.dispatcher().Tenant(), route.Name)
+			return newResult(StatusDuplicate, nil)
 		}
 
 	}
@@ -80,10 +89,12 @@ func (scan *MsgService) MsgHandling(input []byte, output outputs.Output, route *
 
 	in["postee"] = posteeOpts
 
-	content, err := inpteval.Eval(in, *AquaServer)
+	started := time.Now()
+	content, err := inpteval.Eval(ctx, in, *AquaServer)
+	metrics.RegoEvaluated(scan.dispatcher().Tenant(), route.Template, time.Since(started))
 	if err != nil {
-		log.Printf("Error while evaluating input: %v", err)
-		return
+		logger.Errorf("Error while evaluating input: %v", err)
+		return newResult(StatusTemplateError, err)
 	}
 
 	if owners != "" {
@@ -91,45 +102,70 @@ func (scan *MsgService) MsgHandling(input []byte, output outputs.Output, route *
 	}
 
 	if route.Plugins.AggregateMessageNumber > 0 && inpteval.IsAggregationSupported() {
-		aggregated := AggregateScanAndGetQueue(route.Name, content, route.Plugins.AggregateMessageNumber, false)
+		aggregated := scan.aggregator()(route.Name, content, route.Plugins.AggregateMessageNumber, false)
 		if len(aggregated) > 0 {
 			content, err = inpteval.BuildAggregatedContent(aggregated)
 			if err != nil {
-				log.Printf("Error while building aggregated content: %v", err)
-				return
+				logger.Errorf("Error while building aggregated content: %v", err)
+				return newResult(StatusTemplateError, err)
 			}
-			send(output, content)
+			return scan.send(ctx, route, output, content)
 		}
+		return newResult(StatusAggregated, nil)
 	} else if route.Plugins.AggregateTimeoutSeconds > 0 && inpteval.IsAggregationSupported() {
-		AggregateScanAndGetQueue(route.Name, content, 0, true)
+		scan.aggregator()(route.Name, content, 0, true)
 
 		if !route.IsSchedulerRun() { //TODO route shouldn't have any associated logic
-			log.Printf("about to schedule %s\n", route.Name)
-			RunScheduler(route, send, AggregateScanAndGetQueue, inpteval, &route.Name, output)
+			logger.Infof("about to schedule %s", route.Name)
+			RunScheduler(route, scan.sender(route), scan.aggregator(), inpteval, &route.Name, output)
 		} else {
-			log.Printf("%s is already scheduled\n", route.Name)
+			logger.Debugf("%s is already scheduled", route.Name)
 		}
+		return newResult(StatusAggregated, nil)
 	} else {
-		send(output, content)
+		if outputs.ReadsInput(output) {
+			content["src"] = string(input)
+		}
+		return scan.send(ctx, route, output, content)
+	}
+}
 
+// dispatcher returns the dispatcher of the tenant, which labels metrics of the service too
+func (scan *MsgService) dispatcher() *delivery.Dispatcher {
+	if scan.Dispatcher == nil {
+		return delivery.Instance()
 	}
+	return scan.Dispatcher
 }
 
-func send(otpt outputs.Output, cnt map[string]string) {
-	go func() {
-		err := otpt.Send(cnt)
-		if err != nil {
-			log.Printf("Error while sending event: %v", err)
-		}
-	}()
+// send puts a message to the delivery queue of an output, the result waits for the delivery, see Result.Wait
+func (scan *MsgService) send(ctx context.Context, route *routes.InputRoute, otpt outputs.Output, cnt map[string]string) Result {
+	receipt := scan.dispatcher().Enqueue(ctx, route.Name, route.Template, otpt, cnt)
 
-	err := dbservice.RegisterPlgnInvctn(otpt.GetName())
+	err := scan.Store.RegisterPlgnInvctn(otpt.GetName())
 	if err != nil {
-		log.Printf("Error while building aggregated content: %v", err)
-		return
+		logging.FromContext(ctx).Errorf("Error while registering invocation of %q: %v", otpt.GetName(), err)
+	}
+	return Result{Status: StatusQueued, receipt: receipt}
+}
+
+// sender sends messages released by the aggregation scheduler, each of them gets a new correlation id
+func (scan *MsgService) sender(route *routes.InputRoute) func(otpt outputs.Output, cnt map[string]string) {
+	return func(otpt outputs.Output, cnt map[string]string) {
+		scan.send(logging.WithCorrelationId(context.Background(), logging.NewCorrelationId()), route, otpt, cnt)
 	}
+}
 
+// aggregator returns the aggregation of the default database, unless the service has its own store
+func (scan *MsgService) aggregator() func(outputName string, currentContent map[string]string, counts int, ignoreLength bool) []map[string]string {
+	if scan.Store == nil {
+		return AggregateScanAndGetQueue
+	}
+	return func(outputName string, currentContent map[string]string, counts int, ignoreLength bool) []map[string]string {
+		return aggregateScans(scan.Store, outputName, currentContent, counts, ignoreLength)
+	}
 }
+
 func calculateExpired(UniqueMessageTimeoutSeconds int) *time.Time {
 	if UniqueMessageTimeoutSeconds == 0 {
 		return nil
@@ -140,13 +176,17 @@ func calculateExpired(UniqueMessageTimeoutSeconds int) *time.Time {
 }
 
 var AggregateScanAndGetQueue = func(outputName string, currentContent map[string]string, counts int, ignoreLength bool) []map[string]string {
-	aggregatedScans, err := dbservice.AggregateScans(outputName, currentContent, counts, ignoreLength)
+	return aggregateScans(nil, outputName, currentContent, counts, ignoreLength)
+}
+
+func aggregateScans(store *dbservice.Store, outputName string, currentContent map[string]string, counts int, ignoreLength bool) []map[string]string {
+	aggregatedScans, err := store.AggregateScans(outputName, currentContent, counts, ignoreLength)
 	if err != nil {
-		log.Printf("AggregateScans Error: %v", err)
+		logging.Errorf("AggregateScans Error: %v", err)
 		return aggregatedScans
 	}
 	if len(currentContent) != 0 && len(aggregatedScans) == 0 {
-		log.Printf("New scan was added to the queue of %q without sending.", outputName)
+		logging.Infof("New scan was added to the queue of %q without sending.", outputName)
 		return nil
 	}
 	return aggregatedScans
@@ -1,6 +1,7 @@
 package msgservice
 
 import (
+	"context"
 	"log"
 	"strings"
 	"sync"
@@ -24,7 +25,7 @@ type DemoInptEval struct {
 	skipAggrSpprt bool
 }
 
-func (inptEval *DemoInptEval) Eval(in map[string]interface{}, serverUrl string) (map[string]string, error) {
+func (inptEval *DemoInptEval) Eval(ctx context.Context, in map[string]interface{}, serverUrl string) (map[string]string, error) {
 	inptEval.rndMu.Lock()
 	inptEval.renderCnt++
 	inptEval.rndMu.Unlock()
@@ -79,7 +80,7 @@ func (plg *DemoEmailOutput) getEmailsCount() int {
 }
 
 func (plg *DemoEmailOutput) Init() error { return nil }
-func (plg *DemoEmailOutput) Send(data map[string]string) error {
+func (plg *DemoEmailOutput) Send(ctx context.Context, data map[string]string) error {
 	log.Printf("Sending through demo plugin..\n")
 	log.Printf("%s\n", data["title"])
 
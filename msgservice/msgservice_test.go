@@ -1,6 +1,7 @@
 package msgservice
 
 import (
+	"context"
 	"errors"
 	"os"
 	"sync"
@@ -21,7 +22,7 @@ type FailingInptEval struct {
 	expectedAggrError error
 }
 
-func (inptEval *FailingInptEval) Eval(in map[string]interface{}, serverUrl string) (map[string]string, error) {
+func (inptEval *FailingInptEval) Eval(ctx context.Context, in map[string]interface{}, serverUrl string) (map[string]string, error) {
 	if inptEval.expectedError != nil {
 		return nil, inptEval.expectedError
 	} else {
@@ -89,7 +90,7 @@ func validateInputValue(t *testing.T, caseDesc string, input []byte, shouldPass
 	demoEmailOutput.wg.Add(expected)
 
 	srv := new(MsgService)
-	srv.MsgHandling([]byte(input), demoEmailOutput, demoRoute, demoInptEval, &srvUrl)
+	srv.MsgHandling(context.Background(), []byte(input), demoEmailOutput, demoRoute, demoInptEval, &srvUrl).Wait(context.Background())
 
 	demoEmailOutput.wg.Wait()
 
@@ -122,7 +123,7 @@ func TestEvalError(t *testing.T) {
 	}
 
 	srv := new(MsgService)
-	srv.MsgHandling([]byte(mockScan1), demoEmailOutput, demoRoute, demoInptEval, &srvUrl)
+	srv.MsgHandling(context.Background(), []byte(mockScan1), demoEmailOutput, demoRoute, demoInptEval, &srvUrl)
 
 	if demoEmailOutput.getEmailsCount() > 0 {
 		t.Errorf("Output shouldn't be called when evaluation is failed")
@@ -156,7 +157,7 @@ func TestAggrEvalError(t *testing.T) {
 
 	for i := 0; i < 2; i++ {
 		srv := new(MsgService)
-		srv.MsgHandling([]byte(mockScan1), demoEmailOutput, demoRoute, demoInptEval, &srvUrl)
+		srv.MsgHandling(context.Background(), []byte(mockScan1), demoEmailOutput, demoRoute, demoInptEval, &srvUrl)
 	}
 
 	if demoEmailOutput.getEmailsCount() > 0 {
@@ -180,9 +181,53 @@ func TestEmptyInput(t *testing.T) {
 	demoInptEval := &DemoInptEval{}
 
 	srv := new(MsgService)
-	srv.MsgHandling([]byte("{}"), nil, demoRoute, demoInptEval, &srvUrl)
+	srv.MsgHandling(context.Background(), []byte("{}"), nil, demoRoute, demoInptEval, &srvUrl)
 
 	if demoInptEval.renderCnt != 0 {
 		t.Errorf("Eval() shouldn't be called if no output is passed to ResultHandling()")
 	}
 }
+
+// inputReaderOutput is an output which reads fields of the input
+type inputReaderOutput struct {
+	*DemoEmailOutput
+}
+
+func (o *inputReaderOutput) GetName() string {
+	return "reader"
+}
+
+func (o *inputReaderOutput) ReadsInput() bool {
+	return true
+}
+
+func TestInputIsPassedToReaders(t *testing.T) {
+	dbPathReal := dbservice.DbPath
+	defer func() {
+		os.Remove(dbservice.DbPath)
+		dbservice.DbPath = dbPathReal
+	}()
+	dbservice.DbPath = "test_webhooks.db"
+
+	srvUrl := ""
+	demoRoute := &routes.InputRoute{}
+	demoRoute.Name = "demo-route"
+
+	demoEmailOutput := &DemoEmailOutput{wg: &sync.WaitGroup{}}
+	reader := &inputReaderOutput{&DemoEmailOutput{wg: &sync.WaitGroup{}}}
+	demoEmailOutput.wg.Add(1)
+	reader.wg.Add(1)
+
+	srv := new(MsgService)
+	srv.MsgHandling(context.Background(), []byte(mockScan1), demoEmailOutput, demoRoute, &DemoInptEval{}, &srvUrl).Wait(context.Background())
+	srv.MsgHandling(context.Background(), []byte(mockScan1), reader, demoRoute, &DemoInptEval{}, &srvUrl).Wait(context.Background())
+	demoEmailOutput.wg.Wait()
+	reader.wg.Wait()
+
+	if src, ok := demoEmailOutput.payloads[0]["src"]; ok {
+		t.Errorf("The input is passed to an output which doesn't read it: %q", src)
+	}
+	if src := reader.payloads[0]["src"]; src != mockScan1 {
+		t.Errorf("Wrong input, expected: %q, got: %q", mockScan1, src)
+	}
+}
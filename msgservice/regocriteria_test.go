@@ -1,6 +1,7 @@
 package msgservice
 
 import (
+	"context"
 	"os"
 	"sync"
 	"testing"
@@ -133,7 +134,7 @@ func validateRegoInput(t *testing.T, caseDesc string, input string, regoCriteria
 	demoEmailOutput.wg.Add(expected)
 
 	srv := new(MsgService)
-	srv.MsgHandling([]byte(input), demoEmailOutput, demoRoute, demoInptEval, &srvUrl)
+	srv.MsgHandling(context.Background(), []byte(input), demoEmailOutput, demoRoute, demoInptEval, &srvUrl).Wait(context.Background())
 
 	demoEmailOutput.wg.Wait()
 
@@ -0,0 +1,54 @@
+package msgservice
+
+import (
+	"context"
+
+	"github.com/aquasecurity/postee/v2/delivery"
+)
+
+const (
+	StatusInvalidInput  = "invalid-input"
+	StatusRegoError     = "rego-error"
+	StatusNotMatched    = "not-matched"
+	StatusDuplicate     = "duplicate"
+	StatusTemplateError = "template-error"
+	StatusAggregated    = "aggregated"
+	StatusError         = "error"
+	StatusQueued        = string(delivery.Queued)
+	StatusDelivered     = string(delivery.Delivered)
+	StatusRetrying      = string(delivery.Retrying)
+	StatusFailed        = string(delivery.Failed)
+)
+
+// Result describes how an input was handled by a route for a single output
+type Result struct {
+	Status  string            `json:"status"`
+	Error   string            `json:"error,omitempty"`
+	receipt *delivery.Receipt //set for messages in the delivery queue
+}
+
+func newResult(status string, err error) Result {
+	r := Result{Status: status}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// Wait waits for the first delivery attempt of a queued message, other results are returned as they are
+func (r Result) Wait(ctx context.Context) Result {
+	if r.receipt == nil {
+		return r
+	}
+	status, err := r.receipt.Wait(ctx)
+	return newResult(string(status), err)
+}
+
+// IsMatched reports whether the input passed the route's REGO criteria
+func (r Result) IsMatched() bool {
+	switch r.Status {
+	case "", StatusInvalidInput, StatusRegoError, StatusNotMatched:
+		return false
+	}
+	return true
+}
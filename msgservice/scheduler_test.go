@@ -1,6 +1,7 @@
 package msgservice
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
@@ -18,7 +19,7 @@ func TestScheduler(t *testing.T) {
 	demoRoute.Plugins.AggregateTimeoutSeconds = 3
 
 	demoSend := func(plg outputs.Output, cnt map[string]string) {
-		err := plg.Send(cnt)
+		err := plg.Send(context.Background(), cnt)
 		if err != nil {
 			t.Fatal("error Send")
 		}
@@ -0,0 +1,213 @@
+package outputs
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aquasecurity/postee/v2/data"
+)
+
+const (
+	siemVendor  = "Aqua Security"
+	siemProduct = "Postee"
+	siemVersion = "2"
+
+	siemDefaultEventId = "postee"
+)
+
+// cefSeverities maps severities of vulnerabilities to severities of CEF and LEEF events, from 0 to 10
+var cefSeverities = map[string]int{
+	"critical":   10,
+	"high":       8,
+	"medium":     5,
+	"low":        3,
+	"negligible": 1,
+}
+
+// siemField is an extension field of a CEF event or an attribute of a LEEF event
+type siemField struct {
+	key, value string
+}
+
+// eventSeverity returns the severity set by the template or the highest severity of vulnerability_summary
+func eventSeverity(content map[string]string, in map[string]interface{}) string {
+	if severity := strings.ToLower(content["severity"]); severity != "" {
+		return severity
+	}
+	return highestVulnerability(in)
+}
+
+// formatCef returns an ArcSight Common Event Format event. The route is the signature id of the event,
+// registry, image, digest and counts of vulnerabilities of a scan are mapped to labeled keys of the CEF dictionary:
+// cs, cn and, as there are only three cn keys, flexNumber.
+func formatCef(route, severity string, content map[string]string) string {
+	var b strings.Builder
+	b.WriteString("CEF:0")
+	for _, h := range []string{siemVendor, siemProduct, siemVersion, eventId(route), content["title"], strconv.Itoa(cefSeverities[severity])} {
+		b.WriteByte('|')
+		b.WriteString(escapeCefHeader(h))
+	}
+	b.WriteByte('|')
+
+	fields := []siemField{
+		{"rt", strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)},
+		{"msg", content["title"]},
+		{"request", content["url"]},
+	}
+	if scan := scanInfo(content); scan != nil {
+		fields = append(fields,
+			siemField{"cs1Label", "registry"}, siemField{"cs1", scan.Registry},
+			siemField{"cs2Label", "image"}, siemField{"cs2", scan.Image},
+			siemField{"cs3Label", "digest"}, siemField{"cs3", scan.Digest},
+			siemField{"cs4Label", "disallowed"}, siemField{"cs4", strconv.FormatBool(scan.Disallowed)},
+			siemField{"cn1Label", "critical"}, siemField{"cn1", strconv.Itoa(scan.Critical)},
+			siemField{"cn2Label", "high"}, siemField{"cn2", strconv.Itoa(scan.High)},
+			siemField{"cn3Label", "medium"}, siemField{"cn3", strconv.Itoa(scan.Medium)},
+			siemField{"flexNumber1Label", "low"}, siemField{"flexNumber1", strconv.Itoa(scan.Low)},
+			siemField{"flexNumber2Label", "negligible"}, siemField{"flexNumber2", strconv.Itoa(scan.Negligible)},
+		)
+	}
+	fields = appendTemplateFields(fields, content)
+
+	extension := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.value != "" {
+			extension = append(extension, f.key+"="+escapeCefValue(f.value))
+		}
+	}
+	b.WriteString(strings.Join(extension, " "))
+	return b.String()
+}
+
+// formatLeef returns an IBM QRadar Log Event Extended Format 1.0 event with tab separated attributes.
+// LEEF has no keys for fields of a scan, they are custom attributes named after the fields.
+func formatLeef(route, severity string, content map[string]string) string {
+	var b strings.Builder
+	b.WriteString("LEEF:1.0")
+	for _, h := range []string{siemVendor, siemProduct, siemVersion, eventId(route)} {
+		b.WriteByte('|')
+		b.WriteString(escapeLeefHeader(h))
+	}
+	b.WriteByte('|')
+
+	fields := []siemField{
+		{"devTime", time.Now().UTC().Format("Jan 02 2006 15:04:05")},
+		{"devTimeFormat", "MMM dd yyyy HH:mm:ss"},
+		{"sev", strconv.Itoa(cefSeverities[severity])},
+		{"title", content["title"]},
+		{"url", content["url"]},
+	}
+	if scan := scanInfo(content); scan != nil {
+		fields = append(fields,
+			siemField{"registry", scan.Registry},
+			siemField{"image", scan.Image},
+			siemField{"digest", scan.Digest},
+			siemField{"disallowed", strconv.FormatBool(scan.Disallowed)},
+			siemField{"critical", strconv.Itoa(scan.Critical)},
+			siemField{"high", strconv.Itoa(scan.High)},
+			siemField{"medium", strconv.Itoa(scan.Medium)},
+			siemField{"low", strconv.Itoa(scan.Low)},
+			siemField{"negligible", strconv.Itoa(scan.Negligible)},
+		)
+	}
+	fields = appendTemplateFields(fields, content)
+
+	attributes := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.value != "" {
+			attributes = append(attributes, f.key+"="+escapeLeefValue(f.value))
+		}
+	}
+	b.WriteString(strings.Join(attributes, "\t"))
+	return b.String()
+}
+
+func eventId(route string) string {
+	if route == "" {
+		return siemDefaultEventId
+	}
+	return route
+}
+
+// scanInfo returns the input of a message if it's a scan of an image
+func scanInfo(content map[string]string) *data.ScanImageInfo {
+	scan := new(data.ScanImageInfo)
+	if err := json.Unmarshal([]byte(content["src"]), scan); err != nil || scan.Image == "" {
+		return nil
+	}
+	return scan
+}
+
+// appendTemplateFields adds the fields of a template which renders its description as a JSON object.
+// Only strings, numbers and booleans are added, fields which are already set aren't overridden.
+func appendTemplateFields(fields []siemField, content map[string]string) []siemField {
+	object := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(content["description"]), &object); err != nil {
+		return fields
+	}
+	used := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		used[f.key] = true
+	}
+	keys := make([]string, 0, len(object))
+	for k := range object {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		key := siemKey(k)
+		if key == "" || used[key] {
+			continue
+		}
+		var value string
+		switch v := object[k].(type) {
+		case string:
+			value = v
+		case float64, bool:
+			value = fmt.Sprint(v)
+		default:
+			continue
+		}
+		used[key] = true
+		fields = append(fields, siemField{key, value})
+	}
+	return fields
+}
+
+// siemKey removes characters which aren't letters, digits or underscores from a key
+func siemKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return -1
+	}, key)
+}
+
+var (
+	cefHeaderEscaper  = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefValueEscaper   = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+	leefHeaderEscaper = strings.NewReplacer("|", " ", "\t", " ", "\r", " ", "\n", " ")
+	leefValueEscaper  = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+)
+
+func escapeCefHeader(value string) string {
+	return cefHeaderEscaper.Replace(value)
+}
+
+func escapeCefValue(value string) string {
+	return cefValueEscaper.Replace(value)
+}
+
+func escapeLeefHeader(value string) string {
+	return leefHeaderEscaper.Replace(value)
+}
+
+func escapeLeefValue(value string) string {
+	return leefValueEscaper.Replace(value)
+}
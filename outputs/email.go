@@ -1,6 +1,8 @@
 package outputs
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"log"
@@ -49,17 +51,18 @@ func (email *EmailOutput) GetLayoutProvider() layout.LayoutProvider {
 	return new(formatting.HtmlProvider)
 }
 
-func (email *EmailOutput) Send(content map[string]string) error {
+func (email *EmailOutput) Send(ctx context.Context, content map[string]string) error {
 	subject := content["title"]
 	body := content["description"]
-	recipients := getHandledRecipients(email.Recipients, &content, email.Name)
+	recipients := getHandledRecipients(ctx, email.Recipients, &content, email.Name)
 	if len(recipients) == 0 {
-		return errThereIsNoRecipient
+		return Permanent(errThereIsNoRecipient)
 	}
 
 	if email.UseMX {
-		sendViaMxServers(email.Sender, subject, body, recipients)
-		return nil
+		return throttled(ctx, func() error {
+			return sendViaMxServers(ctx, email.Sender, subject, body, recipients)
+		})
 	}
 
 	msg := fmt.Sprintf(
@@ -70,7 +73,9 @@ func (email *EmailOutput) Send(content map[string]string) error {
 		strings.Join(recipients, ","), email.Sender, subject, body)
 
 	auth := smtp.PlainAuth("", email.User, email.Password, email.Host)
-	err := smtp.SendMail(email.Host+":"+strconv.Itoa(email.Port), auth, email.Sender, recipients, []byte(msg))
+	err := throttled(ctx, func() error {
+		return sendMail(ctx, email.Host+":"+strconv.Itoa(email.Port), auth, email.Sender, recipients, []byte(msg))
+	})
 	if err != nil {
 		log.Println("SendMail Error:", err)
 		log.Printf("From: %q, to %v via %q", email.Sender, email.Recipients, email.Host)
@@ -80,7 +85,7 @@ func (email *EmailOutput) Send(content map[string]string) error {
 	return nil
 }
 
-func sendViaMxServers(from, subj, msg string, recipients []string) {
+func sendViaMxServers(ctx context.Context, from, subj, msg string, recipients []string) error {
 	for _, rcpt := range recipients {
 		at := strings.LastIndex(rcpt, "@")
 		if at < 0 {
@@ -88,7 +93,7 @@ func sendViaMxServers(from, subj, msg string, recipients []string) {
 			continue
 		}
 		host := rcpt[at+1:]
-		mxs, err := net.LookupMX(host)
+		mxs, err := net.DefaultResolver.LookupMX(ctx, host)
 		if err != nil {
 			log.Print(err)
 			continue
@@ -100,7 +105,7 @@ func sendViaMxServers(from, subj, msg string, recipients []string) {
 					"Subject: %s\r\n"+
 					"Content-Type: text/html; charset=UTF-8\r\n\r\n%s\r\n", rcpt, from, subj, msg)
 
-			if err := smtp.SendMail(mx.Host+":25", nil, from, []string{rcpt}, []byte(message)); err != nil {
+			if err := sendMail(ctx, mx.Host+":25", nil, from, []string{rcpt}, []byte(message)); err != nil {
 				log.Printf("SendMail error to %q via %q", rcpt, mx.Host)
 				log.Print(err)
 				continue
@@ -108,5 +113,82 @@ func sendViaMxServers(from, subj, msg string, recipients []string) {
 			log.Printf("The message to %q was sent successful via %q!", rcpt, mx.Host)
 			break
 		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// sendMail works as smtp.SendMail, but the connection is closed once the context is done
+func sendMail(ctx context.Context, addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	host, _, _ := net.SplitHostPort(addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return withContextErr(ctx, err)
+	}
+	defer c.Close()
+	if err := writeMail(c, host, auth, from, to, msg); err != nil {
+		return withContextErr(ctx, err)
+	}
+	return nil
+}
+
+func writeMail(c *smtp.Client, host string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// withContextErr reports the context error instead of the error of a connection closed because of it
+func withContextErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
 	}
+	return err
 }
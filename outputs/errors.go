@@ -0,0 +1,49 @@
+package outputs
+
+import (
+	"errors"
+	"net/textproto"
+
+	"github.com/aquasecurity/postee/v2/utils"
+)
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks an error which won't disappear if the message is sent again
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+// IsRetryable reports whether a failed Send is worth repeating.
+// Network failures, 429 and 5xx responses are retryable, 4xx responses and errors marked as Permanent are not.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+	var httpErr *utils.HttpError
+	if errors.As(err, &httpErr) {
+		return httpErr.IsTemporary()
+	}
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) {
+		return smtpErr.Code < 500
+	}
+	return true
+}
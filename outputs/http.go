@@ -0,0 +1,343 @@
+package outputs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/aquasecurity/postee/v2/formatting"
+	"github.com/aquasecurity/postee/v2/layout"
+	"github.com/aquasecurity/postee/v2/utils"
+)
+
+const (
+	httpDefaultStatus = "200-299"
+	oauthTokenMargin  = 30 * time.Second
+)
+
+// HttpOutput sends messages to any HTTP endpoint. The URL and header values are Go templates of the message,
+// e.g. "https://example.com/images/{{ .input.image | urlquery }}".
+type HttpOutput struct {
+	Name           string
+	Method         string
+	Url            string
+	Headers        map[string]string
+	User           string
+	Password       string
+	Token          string
+	TokenUrl       string
+	ClientId       string
+	ClientSecret   string
+	Scopes         []string
+	CaCert         string
+	ClientCert     string
+	ClientKey      string
+	AcceptedStatus []string
+	Body           string
+
+	url      *template.Template
+	headers  map[string]*template.Template
+	statuses []statusRange
+	client   *http.Client
+
+	tokenMutex  sync.Mutex
+	accessToken string
+	expires     time.Time
+}
+
+type statusRange struct {
+	from, to int
+}
+
+func (h *HttpOutput) GetName() string {
+	return h.Name
+}
+
+// ReadsInput makes the HTTP output get the input for templates of requests and for the "input" body
+func (h *HttpOutput) ReadsInput() bool {
+	return true
+}
+
+// Check parses the options of the output without loading certificates
+func (h *HttpOutput) Check() error {
+	if h.Method == "" {
+		h.Method = http.MethodPost
+	}
+	h.Method = strings.ToUpper(h.Method)
+	switch h.Method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return fmt.Errorf("'method'(%q) isn't one of GET, POST, PUT, PATCH or DELETE", h.Method)
+	}
+
+	var err error
+	if h.Body, err = checkBody(h.Body); err != nil {
+		return err
+	}
+	if h.url, err = parseHttpTemplate("url", h.Url); err != nil {
+		return err
+	}
+	h.headers = make(map[string]*template.Template, len(h.Headers))
+	for name, value := range h.Headers {
+		if h.headers[name], err = parseHttpTemplate("header "+name, value); err != nil {
+			return err
+		}
+	}
+
+	accepted := h.AcceptedStatus
+	if len(accepted) == 0 {
+		accepted = []string{httpDefaultStatus}
+	}
+	h.statuses = nil
+	for _, status := range accepted {
+		r, err := parseStatusRange(status)
+		if err != nil {
+			return fmt.Errorf("'accepted-status'(%q): %w", status, err)
+		}
+		h.statuses = append(h.statuses, r)
+	}
+
+	if (h.ClientId != "" || h.ClientSecret != "") && h.TokenUrl == "" {
+		return errors.New("'token-url' is required for OAuth2 client credentials")
+	}
+	return checkTlsFiles(h.ClientCert, h.ClientKey)
+}
+
+func (h *HttpOutput) Init() error {
+	if err := h.Check(); err != nil {
+		return err
+	}
+	tlsConfig, err := loadTlsConfig(h.CaCert, h.ClientCert, h.ClientKey)
+	if err != nil {
+		return err
+	}
+	h.client = &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+	}}
+	log.Printf("Starting HTTP output %q, for sending %s requests", h.Name, h.Method)
+	return nil
+}
+
+func (h *HttpOutput) Send(ctx context.Context, content map[string]string) error {
+	log.Printf("Sending a message to %q", h.Name)
+
+	in, err := parseInput(content)
+	if err != nil {
+		return Permanent(err)
+	}
+	fields := map[string]interface{}{
+		"title":       content["title"],
+		"description": content["description"],
+		"url":         content["url"],
+		"input":       in,
+	}
+	target, err := execHttpTemplate(h.url, fields)
+	if err != nil {
+		return Permanent(fmt.Errorf("url: %w", err))
+	}
+	headers := make(map[string]string, len(h.headers))
+	for name, t := range h.headers {
+		if headers[name], err = execHttpTemplate(t, fields); err != nil {
+			return Permanent(fmt.Errorf("header %s: %w", name, err))
+		}
+	}
+	body, err := messageBody(h.Body, content)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	err = throttled(ctx, func() error {
+		status, err := h.do(ctx, target, headers, body)
+		if status == http.StatusUnauthorized && h.TokenUrl != "" {
+			//the token could be revoked before it expired
+			h.dropToken()
+			_, err = h.do(ctx, target, headers, body)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Sending a message to %q was successful!", h.Name)
+	return nil
+}
+
+func (h *HttpOutput) do(ctx context.Context, target string, headers map[string]string, body []byte) (int, error) {
+	var reader io.Reader
+	if h.Method != http.MethodGet {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, h.Method, target, reader)
+	if err != nil {
+		return 0, Permanent(err)
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if err := h.authorize(ctx, req); err != nil {
+		return 0, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if !h.isAccepted(resp.StatusCode) {
+		b, _ := ioutil.ReadAll(resp.Body)
+		log.Printf("Sending %q error: failed response status %q. Body: %q", h.Name, resp.Status, string(b))
+		return resp.StatusCode, utils.NewHttpError(resp.StatusCode, "failed response status %d for %q", resp.StatusCode, h.Name).WithRetryAfter(resp.Header)
+	}
+	return resp.StatusCode, nil
+}
+
+// authorize sets credentials of a request: an OAuth2 token, a bearer token or a user and a password
+func (h *HttpOutput) authorize(ctx context.Context, req *http.Request) error {
+	switch {
+	case h.TokenUrl != "":
+		token, err := h.oauthToken(ctx)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case h.Token != "":
+		req.Header.Set("Authorization", "Bearer "+h.Token)
+	case h.User != "":
+		req.SetBasicAuth(h.User, h.Password)
+	}
+	return nil
+}
+
+// oauthToken returns a token of OAuth2 client credentials grant. The token is kept until it expires.
+func (h *HttpOutput) oauthToken(ctx context.Context) (string, error) {
+	h.tokenMutex.Lock()
+	defer h.tokenMutex.Unlock()
+	if h.accessToken != "" && (h.expires.IsZero() || time.Now().Before(h.expires)) {
+		return h.accessToken, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(h.Scopes) > 0 {
+		form.Set("scope", strings.Join(h.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.TokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(h.ClientId), url.QueryEscape(h.ClientSecret))
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(resp.Body)
+		log.Printf("%q can't get OAuth2 token: failed response status %q. Body: %q", h.Name, resp.Status, string(b))
+		return "", utils.NewHttpError(resp.StatusCode, "failed response status %d for OAuth2 token of %q", resp.StatusCode, h.Name)
+	}
+	token := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("can't parse OAuth2 token of %q: %w", h.Name, err)
+	}
+	if token.AccessToken == "" {
+		return "", Permanent(fmt.Errorf("OAuth2 token of %q is empty", h.Name))
+	}
+
+	h.accessToken = token.AccessToken
+	h.expires = time.Time{}
+	if token.ExpiresIn > 0 {
+		h.expires = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - oauthTokenMargin)
+	}
+	return h.accessToken, nil
+}
+
+func (h *HttpOutput) dropToken() {
+	h.tokenMutex.Lock()
+	defer h.tokenMutex.Unlock()
+	h.accessToken = ""
+}
+
+func (h *HttpOutput) isAccepted(status int) bool {
+	for _, r := range h.statuses {
+		if status >= r.from && status <= r.to {
+			return true
+		}
+	}
+	return false
+}
+
+// parseStatusRange reads a status ("204"), a range of statuses ("200-299") or a class of statuses ("2xx")
+func parseStatusRange(value string) (statusRange, error) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if len(value) == 3 && strings.HasSuffix(value, "xx") {
+		class, err := strconv.Atoi(value[:1])
+		if err != nil || class < 1 || class > 5 {
+			return statusRange{}, errors.New("unknown class of statuses")
+		}
+		return statusRange{class * 100, class*100 + 99}, nil
+	}
+	parts := strings.SplitN(value, "-", 2)
+	from, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return statusRange{}, errors.New("a status, a range like 200-299 or a class like 2xx is expected")
+	}
+	to := from
+	if len(parts) == 2 {
+		if to, err = strconv.Atoi(strings.TrimSpace(parts[1])); err != nil {
+			return statusRange{}, errors.New("a status, a range like 200-299 or a class like 2xx is expected")
+		}
+	}
+	if from < 100 || to > 599 || from > to {
+		return statusRange{}, errors.New("statuses should be between 100 and 599")
+	}
+	return statusRange{from, to}, nil
+}
+
+func parseHttpTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' isn't a valid template: %w", name, err)
+	}
+	return t, nil
+}
+
+func execHttpTemplate(t *template.Template, fields map[string]interface{}) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, fields); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (h *HttpOutput) Terminate() error {
+	if h.client != nil {
+		h.client.CloseIdleConnections()
+	}
+	log.Printf("HTTP output %q terminated", h.Name)
+	return nil
+}
+
+func (h *HttpOutput) GetLayoutProvider() layout.LayoutProvider {
+	return new(formatting.HtmlProvider)
+}
@@ -0,0 +1,197 @@
+package outputs
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHttpSend(t *testing.T) {
+	content := map[string]string{
+		"title":       "alpine:3.8 vulnerability scan report",
+		"description": "<p>report</p>",
+		"url":         "https://aqua.example.com/images",
+		"src":         `{"image":"alpine:3.8","registry":"Docker Hub"}`,
+	}
+	tests := []struct {
+		name       string
+		output     *HttpOutput
+		status     int
+		wantPath   string
+		wantQuery  string
+		wantAuth   string
+		wantHeader string
+		wantBody   string
+		wantErr    bool
+	}{
+		{
+			name:     "Description",
+			output:   &HttpOutput{Url: "/hook"},
+			status:   http.StatusOK,
+			wantPath: "/hook",
+			wantBody: "<p>report</p>",
+		},
+		{
+			name: "Templated URL and headers, bearer token",
+			output: &HttpOutput{
+				Method:  "put",
+				Url:     "/images/{{ .input.image }}?registry={{ .input.registry | urlquery }}",
+				Headers: map[string]string{"X-Title": "{{ .title }}"},
+				Token:   "secret-token",
+				Body:    BodyInput,
+			},
+			status:     http.StatusNoContent,
+			wantPath:   "/images/alpine:3.8",
+			wantQuery:  "registry=Docker+Hub",
+			wantAuth:   "Bearer secret-token",
+			wantHeader: content["title"],
+			wantBody:   content["src"],
+		},
+		{
+			name:     "Envelope, basic auth",
+			output:   &HttpOutput{Url: "/hook", User: "admin", Password: "pass", Body: BodyEnvelope},
+			status:   http.StatusCreated,
+			wantPath: "/hook",
+			wantAuth: "Basic YWRtaW46cGFzcw==",
+			wantBody: `{"title":"alpine:3.8 vulnerability scan report","description":"<p>report</p>","url":"https://aqua.example.com/images"}`,
+		},
+		{
+			name:     "Accepted status",
+			output:   &HttpOutput{Url: "/hook", AcceptedStatus: []string{"2xx", "409"}},
+			status:   http.StatusConflict,
+			wantPath: "/hook",
+			wantBody: "<p>report</p>",
+		},
+		{
+			name:     "Rejected status",
+			output:   &HttpOutput{Url: "/hook", AcceptedStatus: []string{"200"}},
+			status:   http.StatusAccepted,
+			wantPath: "/hook",
+			wantBody: "<p>report</p>",
+			wantErr:  true,
+		},
+		{
+			name:    "Missing field",
+			output:  &HttpOutput{Url: "/images/{{ .input.digest }}"},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var path, query, auth, header, body string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path, query = r.URL.Path, r.URL.RawQuery
+				auth, header = r.Header.Get("Authorization"), r.Header.Get("X-Title")
+				b, _ := ioutil.ReadAll(r.Body)
+				body = string(b)
+				w.WriteHeader(test.status)
+			}))
+			defer server.Close()
+
+			h := test.output
+			h.Name = "my-http"
+			h.Url = server.URL + h.Url
+			if err := h.Init(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			err := h.Send(context.Background(), content)
+			if test.wantErr != (err != nil) {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if path != test.wantPath {
+				t.Errorf("Wrong path, expected: %q, got: %q", test.wantPath, path)
+			}
+			if query != test.wantQuery {
+				t.Errorf("Wrong query, expected: %q, got: %q", test.wantQuery, query)
+			}
+			if auth != test.wantAuth {
+				t.Errorf("Wrong authorization, expected: %q, got: %q", test.wantAuth, auth)
+			}
+			if header != test.wantHeader {
+				t.Errorf("Wrong header, expected: %q, got: %q", test.wantHeader, header)
+			}
+			if body != test.wantBody {
+				t.Errorf("Wrong body, expected: %q, got: %q", test.wantBody, body)
+			}
+		})
+	}
+}
+
+func TestHttpOAuth2(t *testing.T) {
+	var issued int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "client" || secret != "secret" || r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "alerts write" {
+			http.Error(w, "invalid client", http.StatusUnauthorized)
+			return
+		}
+		n := atomic.AddInt32(&issued, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": fmt.Sprintf("token%d", n), "expires_in": 3600})
+	})
+	mux.HandleFunc("/hook", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token2" {
+			//the first token is revoked
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	caCert := filepath.Join(t.TempDir(), "ca.pem")
+	if err := ioutil.WriteFile(caCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	h := &HttpOutput{
+		Name:         "my-http",
+		Url:          server.URL + "/hook",
+		TokenUrl:     server.URL + "/token",
+		ClientId:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"alerts", "write"},
+		CaCert:       caCert,
+	}
+	if err := h.Init(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := h.Send(context.Background(), map[string]string{"description": "{}"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if issued != 2 {
+		t.Errorf("A token is expected to be issued again only after it's rejected, issued: %d", issued)
+	}
+}
+
+func TestHttpCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		output *HttpOutput
+	}{
+		{"Unknown method", &HttpOutput{Url: "http://localhost", Method: "TRACE"}},
+		{"Unknown body", &HttpOutput{Url: "http://localhost", Body: "raw"}},
+		{"Invalid template", &HttpOutput{Url: "http://localhost/{{ .input.image "}},
+		{"Invalid status", &HttpOutput{Url: "http://localhost", AcceptedStatus: []string{"299-200"}}},
+		{"OAuth2 without token url", &HttpOutput{Url: "http://localhost", ClientId: "client"}},
+		{"Client cert without key", &HttpOutput{Url: "http://localhost", ClientCert: "cert.pem"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.output.Check(); err == nil {
+				t.Error("An error is expected")
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package outputs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/aquasecurity/postee/v2/data"
+)
+
+// vulnerabilitySeverities are fields of vulnerability_summary from the most severe one
+var vulnerabilitySeverities = []string{"critical", "high", "medium", "low", "negligible"}
+
+// parseInput returns the input of a message, which is passed in "src" of the content.
+// Aggregated messages have no input.
+func parseInput(content map[string]string) (map[string]interface{}, error) {
+	in := map[string]interface{}{}
+	if src := content["src"]; src != "" {
+		if err := json.Unmarshal([]byte(src), &in); err != nil {
+			return nil, fmt.Errorf("unable to parse the input: %w", err)
+		}
+	}
+	return in, nil
+}
+
+func hasVulnerabilitySummary(in map[string]interface{}) bool {
+	_, ok := in["vulnerability_summary"].(map[string]interface{})
+	return ok
+}
+
+func highestVulnerability(in map[string]interface{}) string {
+	for _, severity := range vulnerabilitySeverities {
+		count, err := strconv.ParseFloat(data.Prop(in, "vulnerability_summary."+severity), 64)
+		if err == nil && count > 0 {
+			return severity
+		}
+	}
+	return ""
+}
+
+// isCleanScan reports whether the input is a scan without vulnerabilities
+func isCleanScan(in map[string]interface{}) bool {
+	return hasVulnerabilitySummary(in) && highestVulnerability(in) == ""
+}
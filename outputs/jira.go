@@ -1,6 +1,7 @@
 package outputs
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/aquasecurity/postee/v2/formatting"
 	"github.com/aquasecurity/postee/v2/layout"
+	"github.com/aquasecurity/postee/v2/utils"
 
 	"net/http"
 	"net/url"
@@ -78,8 +80,8 @@ func (ctx *JiraAPI) fetchBoardId(boardName string) {
 	}
 }
 
-func (ctx *JiraAPI) fetchSprintId(client jira.Client) {
-	sprints, _, err := client.Board.GetAllSprintsWithOptions(ctx.boardId, &jira.GetAllSprintsOptions{State: "active"})
+func (ctx *JiraAPI) fetchSprintId(c context.Context, client jira.Client) {
+	sprints, _, err := client.Board.GetAllSprintsWithOptionsWithContext(c, ctx.boardId, &jira.GetAllSprintsOptions{State: "active"})
 	if err != nil {
 		log.Printf("failed to get active sprint for board ID %d from Jira API. %s", ctx.boardId, err)
 		return
@@ -162,7 +164,7 @@ func (ctx *JiraAPI) createClient() (*jira.Client, error) {
 	return client, nil
 }
 
-func (ctx *JiraAPI) Send(content map[string]string) error {
+func (ctx *JiraAPI) Send(c context.Context, content map[string]string) error {
 	client, err := ctx.createClient()
 	if err != nil {
 		log.Printf("unable to create Jira client: %s", err)
@@ -170,17 +172,21 @@ func (ctx *JiraAPI) Send(content map[string]string) error {
 	}
 
 	if ctx.boardType == "scrum" {
-		ctx.fetchSprintId(*client)
+		ctx.fetchSprintId(c, *client)
 	}
 
-	metaProject, err := createMetaProject(client, ctx.ProjectKey)
+	var metaProject *jira.MetaProject
+	err = throttled(c, func() (err error) {
+		metaProject, err = createMetaProject(c, client, ctx.ProjectKey)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("Failed to create meta project: %w", err)
 	}
 
 	metaIssueType, err := createMetaIssueType(metaProject, ctx.Issuetype)
 	if err != nil {
-		return fmt.Errorf("Failed to create meta issue type: %w", err)
+		return Permanent(fmt.Errorf("Failed to create meta issue type: %w", err))
 	}
 
 	ctx.Summary = content["title"]
@@ -188,7 +194,7 @@ func (ctx *JiraAPI) Send(content map[string]string) error {
 
 	assignee := ctx.User
 	if len(ctx.Assignee) > 0 {
-		assignees := getHandledRecipients(ctx.Assignee, &content, ctx.Name)
+		assignees := getHandledRecipients(c, ctx.Assignee, &content, ctx.Name)
 		if len(assignees) > 0 {
 			assignee = assignees[0]
 		}
@@ -218,7 +224,7 @@ func (ctx *JiraAPI) Send(content map[string]string) error {
 		Name string `json:"name"`
 	}
 
-	issue, err := InitIssue(client, metaProject, metaIssueType, fieldsConfig, isServerJira(ctx.Url))
+	issue, err := InitIssue(c, client, metaProject, metaIssueType, fieldsConfig, isServerJira(ctx.Url))
 
 	if err != nil {
 		log.Printf("Failed to init issue: %s\n", err)
@@ -250,7 +256,11 @@ func (ctx *JiraAPI) Send(content map[string]string) error {
 		log.Printf("added %d affected versions into Versions field", len(ctx.AffectsVersions))
 	}
 
-	i, err := ctx.openIssue(client, issue)
+	var i *jira.Issue
+	err = throttled(c, func() (err error) {
+		i, err = ctx.openIssue(c, client, issue)
+		return err
+	})
 	if err != nil {
 		log.Printf("Failed to open jira issue, %s\n", err)
 		return err
@@ -259,19 +269,22 @@ func (ctx *JiraAPI) Send(content map[string]string) error {
 	return nil
 }
 
-func (ctx *JiraAPI) openIssue(client *jira.Client, issue *jira.Issue) (*jira.Issue, error) {
-	i, res, err := client.Issue.Create(issue)
+func (ctx *JiraAPI) openIssue(c context.Context, client *jira.Client, issue *jira.Issue) (*jira.Issue, error) {
+	i, res, err := client.Issue.CreateWithContext(c, issue)
+	if res == nil {
+		return nil, err
+	}
 
 	defer res.Body.Close()
 	resp, _ := ioutil.ReadAll(res.Body)
 	if err != nil {
-		return nil, errors.New(string(resp))
+		return nil, utils.NewHttpError(res.StatusCode, "%s", resp).WithRetryAfter(res.Header)
 	}
 	return i, nil
 }
 
-func createMetaProject(c *jira.Client, project string) (*jira.MetaProject, error) {
-	meta, _, err := c.Issue.GetCreateMeta(project)
+func createMetaProject(ctx context.Context, c *jira.Client, project string) (*jira.MetaProject, error) {
+	meta, _, err := c.Issue.GetCreateMetaWithContext(ctx, project)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get create meta : %w", err)
 	}
@@ -279,7 +292,7 @@ func createMetaProject(c *jira.Client, project string) (*jira.MetaProject, error
 	// get right project
 	metaProject := meta.GetProjectWithKey(project)
 	if metaProject == nil {
-		return nil, fmt.Errorf("could not find project with key %s", project)
+		return nil, Permanent(fmt.Errorf("could not find project with key %s", project))
 	}
 
 	return metaProject, nil
@@ -294,7 +307,7 @@ func createMetaIssueType(metaProject *jira.MetaProject, issueType string) (*jira
 	return metaIssuetype, nil
 }
 
-func InitIssue(c *jira.Client, metaProject *jira.MetaProject, metaIssuetype *jira.MetaIssueType, fieldsConfig map[string]string, useSrvApi bool) (*jira.Issue, error) {
+func InitIssue(ctx context.Context, c *jira.Client, metaProject *jira.MetaProject, metaIssuetype *jira.MetaIssueType, fieldsConfig map[string]string, useSrvApi bool) (*jira.Issue, error) {
 	issue := new(jira.Issue)
 	issueFields := new(jira.IssueFields)
 	issueFields.Unknowns = make(map[string]interface{})
@@ -384,9 +397,9 @@ func InitIssue(c *jira.Client, metaProject *jira.MetaProject, metaIssuetype *jir
 			var err error
 
 			if useSrvApi {
-				users, resp, err = findUserOnJiraServer(c, value)
+				users, resp, err = findUserOnJiraServer(ctx, c, value)
 			} else {
-				users, resp, err = c.User.Find(value)
+				users, resp, err = c.User.FindWithContext(ctx, value)
 			}
 
 			if err != nil {
@@ -418,8 +431,8 @@ func InitIssue(c *jira.Client, metaProject *jira.MetaProject, metaIssuetype *jir
 	issue.Fields = issueFields
 	return issue, nil
 }
-func findUserOnJiraServer(c *jira.Client, email string) ([]jira.User, *jira.Response, error) {
-	req, _ := c.NewRequest("GET", fmt.Sprintf("/rest/api/2/user/search?username=%s", email), nil)
+func findUserOnJiraServer(ctx context.Context, c *jira.Client, email string) ([]jira.User, *jira.Response, error) {
+	req, _ := c.NewRequestWithContext(ctx, "GET", fmt.Sprintf("/rest/api/2/user/search?username=%s", email), nil)
 
 	users := []jira.User{}
 
@@ -0,0 +1,231 @@
+package outputs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aquasecurity/postee/v2/data"
+	"github.com/aquasecurity/postee/v2/formatting"
+	"github.com/aquasecurity/postee/v2/layout"
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+const (
+	KafkaAcksAll    = "all"
+	KafkaAcksLeader = "leader"
+	KafkaAcksNone   = "none"
+
+	KafkaSaslPlain = "PLAIN"
+
+	KafkaHeaderRoute         = "postee-route"
+	KafkaHeaderTemplate      = "postee-template"
+	KafkaHeaderCorrelationId = "postee-correlation-id"
+
+	kafkaDefaultBatchSize    = 100
+	kafkaDefaultBatchTimeout = 10 * time.Millisecond
+	kafkaDialTimeout         = 10 * time.Second
+	kafkaMaxAttempts         = 3
+)
+
+var kafkaAcks = map[string]kafka.RequiredAcks{
+	KafkaAcksAll:    kafka.RequireAll,
+	KafkaAcksLeader: kafka.RequireOne,
+	KafkaAcksNone:   kafka.RequireNone,
+}
+
+// kafkaWriter is implemented by kafka.Writer, tests replace it with a stand-in of a broker
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+var newKafkaWriter = func(writer *kafka.Writer) kafkaWriter {
+	return writer
+}
+
+// KafkaOutput publishes messages to a Kafka topic. Records are keyed by KeyProps of the input, so records
+// of the same image get to the same partition. Messages which are sent at the same time are batched.
+type KafkaOutput struct {
+	Name          string
+	Brokers       []string
+	Topic         string
+	KeyProps      []string
+	Body          string
+	Acks          string
+	BatchSize     int
+	BatchTimeout  time.Duration
+	SaslMechanism string
+	User          string
+	Password      string
+	Tls           bool
+	CaCert        string
+	ClientCert    string
+	ClientKey     string
+
+	writer    kafkaWriter
+	transport *kafka.Transport
+}
+
+func (k *KafkaOutput) GetName() string {
+	return k.Name
+}
+
+// ReadsInput makes Kafka get the input for keys of records and for the "input" body
+func (k *KafkaOutput) ReadsInput() bool {
+	return true
+}
+
+// Check parses the options of the output without loading certificates, required options are checked by Init
+func (k *KafkaOutput) Check() error {
+	var err error
+	if k.Body, err = checkBody(k.Body); err != nil {
+		return err
+	}
+	if k.Acks == "" {
+		k.Acks = KafkaAcksAll
+	}
+	if _, ok := kafkaAcks[k.Acks]; !ok {
+		return fmt.Errorf("'acks'(%q) isn't one of %s, %s or %s", k.Acks, KafkaAcksAll, KafkaAcksLeader, KafkaAcksNone)
+	}
+	switch strings.ToUpper(k.SaslMechanism) {
+	case "":
+	case KafkaSaslPlain:
+		if k.User == "" {
+			return fmt.Errorf("'user' is required for SASL %s", KafkaSaslPlain)
+		}
+	default:
+		return fmt.Errorf("'sasl-mechanism'(%q) isn't supported, use %s", k.SaslMechanism, KafkaSaslPlain)
+	}
+	return checkTlsFiles(k.ClientCert, k.ClientKey)
+}
+
+func (k *KafkaOutput) Init() error {
+	if len(k.Brokers) == 0 || k.Topic == "" {
+		return errors.New("'brokers' and 'topic' are required")
+	}
+	if err := k.Check(); err != nil {
+		return err
+	}
+	transport := &kafka.Transport{
+		ClientID:    "postee",
+		DialTimeout: kafkaDialTimeout,
+	}
+	if k.Tls || k.CaCert != "" || k.ClientCert != "" {
+		tlsConfig, err := loadTlsConfig(k.CaCert, k.ClientCert, k.ClientKey)
+		if err != nil {
+			return err
+		}
+		transport.TLS = tlsConfig
+	}
+	if strings.ToUpper(k.SaslMechanism) == KafkaSaslPlain {
+		transport.SASL = plain.Mechanism{Username: k.User, Password: k.Password}
+	}
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(k.Brokers...),
+		Topic:        k.Topic,
+		Balancer:     &kafka.Hash{},
+		MaxAttempts:  kafkaMaxAttempts,
+		BatchSize:    k.BatchSize,
+		BatchTimeout: k.BatchTimeout,
+		RequiredAcks: kafkaAcks[k.Acks],
+		Transport:    transport,
+	}
+	if writer.BatchSize <= 0 {
+		writer.BatchSize = kafkaDefaultBatchSize
+	}
+	if writer.BatchTimeout <= 0 {
+		writer.BatchTimeout = kafkaDefaultBatchTimeout
+	}
+	k.transport = transport
+	k.writer = newKafkaWriter(writer)
+	log.Printf("Starting Kafka output %q, for publishing to %q", k.Name, k.Topic)
+	return nil
+}
+
+func (k *KafkaOutput) Send(ctx context.Context, content map[string]string) error {
+	log.Printf("Sending a message to %q", k.Name)
+
+	in, err := parseInput(content)
+	if err != nil {
+		return Permanent(err)
+	}
+	value, err := messageBody(k.Body, content)
+	if err != nil {
+		return Permanent(err)
+	}
+	msg := kafka.Message{
+		Value:   value,
+		Headers: kafkaHeaders(ctx),
+	}
+	if key := data.JoinProps(in, k.KeyProps); key != "" {
+		msg.Key = []byte(key)
+	}
+
+	err = k.writer.WriteMessages(ctx, msg)
+	if err != nil {
+		log.Printf("Sending to %q error: %v", k.Name, err)
+		if isRefusedRecord(err) {
+			return Permanent(err)
+		}
+		return err
+	}
+	log.Printf("Sending a message to %q was successful!", k.Name)
+	return nil
+}
+
+// isRefusedRecord reports whether a broker won't accept a record however many times it's sent,
+// e.g. the record is too large or the topic isn't authorized. Errors of records are returned as kafka.WriteErrors.
+func isRefusedRecord(err error) bool {
+	var writeErrs kafka.WriteErrors
+	if errors.As(err, &writeErrs) {
+		for _, e := range writeErrs {
+			if e != nil && !isRefusedRecord(e) {
+				return false
+			}
+		}
+		return writeErrs.Count() > 0
+	}
+	var kafkaErr kafka.Error
+	var tooLarge kafka.MessageTooLargeError
+	return errors.As(err, &tooLarge) || (errors.As(err, &kafkaErr) && !kafkaErr.Temporary())
+}
+
+// kafkaHeaders returns headers of a record with the route, the template and the correlation id of the message
+func kafkaHeaders(ctx context.Context) []kafka.Header {
+	route, template := routeFrom(ctx)
+	var headers []kafka.Header
+	for _, h := range []struct{ key, value string }{
+		{KafkaHeaderRoute, route},
+		{KafkaHeaderTemplate, template},
+		{KafkaHeaderCorrelationId, logging.CorrelationId(ctx)},
+	} {
+		if h.value != "" {
+			headers = append(headers, kafka.Header{Key: h.key, Value: []byte(h.value)})
+		}
+	}
+	return headers
+}
+
+func (k *KafkaOutput) Terminate() error {
+	if k.writer != nil {
+		if err := k.writer.Close(); err != nil {
+			return err
+		}
+	}
+	if k.transport != nil {
+		k.transport.CloseIdleConnections()
+	}
+	log.Printf("Kafka output %q terminated", k.Name)
+	return nil
+}
+
+func (k *KafkaOutput) GetLayoutProvider() layout.LayoutProvider {
+	return new(formatting.HtmlProvider)
+}
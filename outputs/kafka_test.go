@@ -0,0 +1,348 @@
+package outputs
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/apiversions"
+	"github.com/segmentio/kafka-go/protocol/metadata"
+	"github.com/segmentio/kafka-go/protocol/produce"
+)
+
+// brokerStandIn keeps records instead of publishing them
+type brokerStandIn struct {
+	writer  *kafka.Writer
+	records []kafka.Message
+	err     error
+	closed  bool
+}
+
+func (b *brokerStandIn) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if b.err != nil {
+		return b.err
+	}
+	b.records = append(b.records, msgs...)
+	return nil
+}
+
+func (b *brokerStandIn) Close() error {
+	b.closed = true
+	return nil
+}
+
+func withBrokerStandIn(t *testing.T) *brokerStandIn {
+	broker := &brokerStandIn{}
+	saved := newKafkaWriter
+	newKafkaWriter = func(writer *kafka.Writer) kafkaWriter {
+		broker.writer = writer
+		return broker
+	}
+	t.Cleanup(func() { newKafkaWriter = saved })
+	return broker
+}
+
+func TestKafkaSend(t *testing.T) {
+	broker := withBrokerStandIn(t)
+	k := &KafkaOutput{
+		Name:         "my-kafka",
+		Brokers:      []string{"localhost:9092"},
+		Topic:        "security-events",
+		KeyProps:     []string{"digest"},
+		Body:         BodyInput,
+		Acks:         KafkaAcksLeader,
+		BatchTimeout: time.Second,
+	}
+	if err := k.Init(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if broker.writer.Topic != "security-events" || broker.writer.RequiredAcks != kafka.RequireOne {
+		t.Errorf("Wrong writer: %+v", broker.writer)
+	}
+	if broker.writer.BatchSize != kafkaDefaultBatchSize || broker.writer.BatchTimeout != time.Second {
+		t.Errorf("Wrong batching, size: %d, timeout: %s", broker.writer.BatchSize, broker.writer.BatchTimeout)
+	}
+	if transport := broker.writer.Transport.(*kafka.Transport); transport.TLS != nil || transport.SASL != nil {
+		t.Error("TLS and SASL aren't expected")
+	}
+
+	src := `{"image":"alpine:3.8","digest":"sha256:45b23dee"}`
+	ctx := WithRoute(logging.WithCorrelationId(context.Background(), "0123456789abcdef"), "route1", "raw-json")
+	if err := k.Send(ctx, map[string]string{"title": "title", "description": "description", "src": src}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(broker.records) != 1 {
+		t.Fatalf("One record is expected, got %d", len(broker.records))
+	}
+	record := broker.records[0]
+	if string(record.Key) != "sha256:45b23dee" {
+		t.Errorf("Wrong key: %q", record.Key)
+	}
+	if string(record.Value) != src {
+		t.Errorf("Wrong value: %q", record.Value)
+	}
+	headers := map[string]string{}
+	for _, h := range record.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	expected := map[string]string{
+		KafkaHeaderRoute:         "route1",
+		KafkaHeaderTemplate:      "raw-json",
+		KafkaHeaderCorrelationId: "0123456789abcdef",
+	}
+	for key, value := range expected {
+		if headers[key] != value {
+			t.Errorf("Wrong header %s, expected: %q, got: %q", key, value, headers[key])
+		}
+	}
+
+	if err := k.Terminate(); err != nil || !broker.closed {
+		t.Errorf("The writer isn't closed: %v", err)
+	}
+}
+
+func TestKafkaErrors(t *testing.T) {
+	broker := withBrokerStandIn(t)
+	k := &KafkaOutput{
+		Name:          "my-kafka",
+		Brokers:       []string{"localhost:9093"},
+		Topic:         "security-events",
+		SaslMechanism: "plain",
+		User:          "postee",
+		Password:      "secret",
+		Tls:           true,
+	}
+	if err := k.Init(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if transport := broker.writer.Transport.(*kafka.Transport); transport.TLS == nil || transport.SASL == nil {
+		t.Error("TLS and SASL are expected")
+	}
+	if broker.writer.RequiredAcks != kafka.RequireAll {
+		t.Errorf("All replicas are expected to ack by default, got %d", broker.writer.RequiredAcks)
+	}
+
+	tests := []struct {
+		err       error
+		retryable bool
+	}{
+		{kafka.LeaderNotAvailable, true},
+		{errors.New("dial tcp: connection refused"), true},
+		{kafka.TopicAuthorizationFailed, false},
+		{kafka.MessageTooLargeError{}, false},
+		{kafka.WriteErrors{kafka.NotLeaderForPartition}, true},
+		{kafka.WriteErrors{kafka.TopicAuthorizationFailed}, false},
+	}
+	for _, test := range tests {
+		broker.err = test.err
+		err := k.Send(context.Background(), map[string]string{"description": "description"})
+		if err == nil {
+			t.Fatalf("An error is expected for %v", test.err)
+		}
+		if IsRetryable(err) != test.retryable {
+			t.Errorf("Error %v is expected to be retryable: %t", test.err, test.retryable)
+		}
+	}
+}
+
+func TestKafkaCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		output *KafkaOutput
+	}{
+		{"Unknown acks", &KafkaOutput{Brokers: []string{"localhost:9092"}, Topic: "events", Acks: "2"}},
+		{"Unknown SASL", &KafkaOutput{Brokers: []string{"localhost:9092"}, Topic: "events", SaslMechanism: "GSSAPI"}},
+		{"SASL without user", &KafkaOutput{Brokers: []string{"localhost:9092"}, Topic: "events", SaslMechanism: "PLAIN"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.output.Check(); err == nil {
+				t.Error("An error is expected")
+			}
+		})
+	}
+
+	if err := (&KafkaOutput{Topic: "events"}).Init(); err == nil {
+		t.Error("An error is expected for an output without brokers")
+	}
+}
+
+// localBroker is a single Kafka broker which speaks the wire protocol on a local listener.
+// Its topics have one partition, records which are produced to them are kept.
+type localBroker struct {
+	listener net.Listener
+	port     int32
+	topics   []string
+
+	mutex   sync.Mutex
+	records []kafka.Message
+	conns   []net.Conn
+	wg      sync.WaitGroup
+}
+
+func startLocalBroker(t *testing.T, topics ...string) *localBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &localBroker{listener: listener, port: int32(listener.Addr().(*net.TCPAddr).Port), topics: topics}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			b.mutex.Lock()
+			b.conns = append(b.conns, conn)
+			b.wg.Add(1)
+			b.mutex.Unlock()
+			go b.serve(t, conn)
+		}
+	}()
+	t.Cleanup(func() {
+		listener.Close()
+		b.mutex.Lock()
+		for _, conn := range b.conns {
+			conn.Close()
+		}
+		b.mutex.Unlock()
+		b.wg.Wait()
+	})
+	return b
+}
+
+func (b *localBroker) serve(t *testing.T, conn net.Conn) {
+	defer b.wg.Done()
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		version, correlationId, _, req, err := protocol.ReadRequest(r)
+		if err != nil {
+			return //the client or the test closes the connection
+		}
+		var resp protocol.Message
+		switch req := req.(type) {
+		case *apiversions.Request:
+			resp = &apiversions.Response{ApiKeys: []apiversions.ApiKeyResponse{
+				{ApiKey: int16(protocol.ApiVersions), MinVersion: 0, MaxVersion: 2},
+				{ApiKey: int16(protocol.Metadata), MinVersion: 1, MaxVersion: 8},
+				{ApiKey: int16(protocol.Produce), MinVersion: 3, MaxVersion: 8},
+			}}
+		case *metadata.Request:
+			resp = b.metadata(req)
+		case *produce.Request:
+			resp = b.produce(t, req)
+		default:
+			t.Errorf("Unexpected request: %T", req)
+			return
+		}
+		if err := protocol.WriteResponse(conn, version, correlationId, resp); err != nil {
+			t.Errorf("Unable to write a response: %v", err)
+			return
+		}
+	}
+}
+
+func (b *localBroker) metadata(req *metadata.Request) *metadata.Response {
+	resp := &metadata.Response{
+		Brokers:      []metadata.ResponseBroker{{NodeID: 1, Host: "127.0.0.1", Port: b.port}},
+		ControllerID: 1,
+	}
+	topics := req.TopicNames
+	if topics == nil {
+		topics = b.topics
+	}
+	for _, topic := range topics {
+		resp.Topics = append(resp.Topics, metadata.ResponseTopic{
+			Name: topic,
+			Partitions: []metadata.ResponsePartition{
+				{PartitionIndex: 0, LeaderID: 1, ReplicaNodes: []int32{1}, IsrNodes: []int32{1}},
+			},
+		})
+	}
+	return resp
+}
+
+func (b *localBroker) produce(t *testing.T, req *produce.Request) *produce.Response {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	resp := new(produce.Response)
+	for _, topic := range req.Topics {
+		respTopic := produce.ResponseTopic{Topic: topic.Topic}
+		for _, partition := range topic.Partitions {
+			offset := int64(len(b.records))
+			for {
+				record, err := partition.RecordSet.Records.ReadRecord()
+				if err != nil {
+					if !errors.Is(err, io.EOF) {
+						t.Errorf("Unable to read a record: %v", err)
+					}
+					break
+				}
+				msg := kafka.Message{Topic: topic.Topic}
+				msg.Key, _ = protocol.ReadAll(record.Key)
+				msg.Value, _ = protocol.ReadAll(record.Value)
+				for _, h := range record.Headers {
+					msg.Headers = append(msg.Headers, kafka.Header{Key: h.Key, Value: h.Value})
+				}
+				b.records = append(b.records, msg)
+			}
+			respTopic.Partitions = append(respTopic.Partitions, produce.ResponsePartition{
+				Partition:  partition.Partition,
+				BaseOffset: offset,
+			})
+		}
+		resp.Topics = append(resp.Topics, respTopic)
+	}
+	return resp
+}
+
+func TestKafkaSendToBroker(t *testing.T) {
+	broker := startLocalBroker(t, "security-events")
+	k := &KafkaOutput{
+		Name:     "my-kafka",
+		Brokers:  []string{broker.listener.Addr().String()},
+		Topic:    "security-events",
+		KeyProps: []string{"image"},
+		Body:     BodyEnvelope,
+	}
+	if err := k.Init(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer k.Terminate()
+
+	ctx, cancel := context.WithTimeout(WithRoute(context.Background(), "route1", "raw-json"), 10*time.Second)
+	defer cancel()
+	err := k.Send(ctx, map[string]string{"title": "title", "description": "description", "src": `{"image":"alpine:3.8"}`})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	if len(broker.records) != 1 {
+		t.Fatalf("One record is expected, got %d", len(broker.records))
+	}
+	record := broker.records[0]
+	if record.Topic != "security-events" {
+		t.Errorf("Wrong topic: %q", record.Topic)
+	}
+	if string(record.Key) != "alpine:3.8" {
+		t.Errorf("Wrong key: %q", record.Key)
+	}
+	if string(record.Value) != `{"title":"title","description":"description"}` {
+		t.Errorf("Wrong value: %q", record.Value)
+	}
+	if len(record.Headers) != 2 || record.Headers[0].Key != KafkaHeaderRoute || string(record.Headers[0].Value) != "route1" {
+		t.Errorf("Wrong headers: %v", record.Headers)
+	}
+}
@@ -2,11 +2,28 @@ package outputs
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/aquasecurity/postee/v2/layout"
 )
 
+// Bodies of HTTP requests and Kafka records
+const (
+	BodyDescription = "description"
+	BodyInput       = "input"
+	BodyEnvelope    = "envelope"
+)
+
+type messageEnvelope struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Url         string `json:"url,omitempty"`
+}
+
 func buildShortMessage(server, urls string, provider layout.LayoutProvider) string {
 	var builder bytes.Buffer
 	builder.WriteString(provider.P("This message is too long to display here. Please visit the link to read the content."))
@@ -16,3 +33,44 @@ func buildShortMessage(server, urls string, provider layout.LayoutProvider) stri
 	}
 	return builder.String()
 }
+
+// truncate cuts a text which is longer than a limit of a target system, the limit is a number of characters
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	return string([]rune(s)[:limit-3]) + "..."
+}
+
+// checkBody returns a kind of body, the rendered description is sent by default
+func checkBody(body string) (string, error) {
+	switch body {
+	case "":
+		return BodyDescription, nil
+	case BodyDescription, BodyInput, BodyEnvelope:
+		return body, nil
+	}
+	return "", fmt.Errorf("'body'(%q) isn't one of %s, %s or %s", body, BodyDescription, BodyInput, BodyEnvelope)
+}
+
+// messageBody returns the rendered description, the input or a JSON envelope with the title, the description and the url
+func messageBody(body string, content map[string]string) ([]byte, error) {
+	switch body {
+	case BodyInput:
+		if content["src"] == "" {
+			return nil, errors.New("the input isn't available, e.g. for aggregated messages")
+		}
+		return []byte(content["src"]), nil
+	case BodyEnvelope:
+		var b bytes.Buffer
+		encoder := json.NewEncoder(&b)
+		encoder.SetEscapeHTML(false) //descriptions are often HTML
+		err := encoder.Encode(&messageEnvelope{
+			Title:       content["title"],
+			Description: content["description"],
+			Url:         content["url"],
+		})
+		return bytes.TrimSuffix(b.Bytes(), []byte("\n")), err
+	}
+	return []byte(content["description"]), nil
+}
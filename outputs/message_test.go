@@ -0,0 +1,30 @@
+package outputs
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s        string
+		limit    int
+		expected string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"longer than the limit", 10, "longer ..."},
+		{"образ alpine:3.8", 10, "образ a..."},
+		{"ありがとうございます", 10, "ありがとうございます"},
+		{"ありがとうございます!", 10, "ありがとうござ..."},
+	}
+	for _, test := range tests {
+		got := truncate(test.s, test.limit)
+		if got != test.expected {
+			t.Errorf("truncate(%q, %d): expected %q, got %q", test.s, test.limit, test.expected, got)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("truncate(%q, %d) isn't valid UTF-8: %q", test.s, test.limit, got)
+		}
+	}
+}
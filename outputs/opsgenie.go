@@ -0,0 +1,245 @@
+package outputs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aquasecurity/postee/v2/data"
+	"github.com/aquasecurity/postee/v2/formatting"
+	"github.com/aquasecurity/postee/v2/layout"
+	"github.com/aquasecurity/postee/v2/utils"
+)
+
+const (
+	OpsgenieApiUrl          = "https://api.opsgenie.com"
+	OpsgenieDefaultPriority = "P3"
+
+	opsgenieMessageLimit     = 130
+	opsgenieAliasLimit       = 512
+	opsgenieDescriptionLimit = 15000
+
+	opsgenieTeam = "team:"
+	opsgenieUser = "user:"
+)
+
+// opsgeniePriorities maps severities of vulnerabilities to priorities of Opsgenie alerts
+var opsgeniePriorities = map[string]string{
+	"critical":   "P1",
+	"high":       "P2",
+	"medium":     "P3",
+	"low":        "P4",
+	"negligible": "P5",
+}
+
+// IsOpsgeniePriority reports whether a value is a priority of Opsgenie alerts
+func IsOpsgeniePriority(priority string) bool {
+	switch priority {
+	case "P1", "P2", "P3", "P4", "P5":
+		return true
+	}
+	return false
+}
+
+// OpsgenieOutput creates Opsgenie alerts. Alerts with the same alias are deduplicated by Opsgenie,
+// the alias is built from DedupKeyProps of the input.
+type OpsgenieOutput struct {
+	Name          string
+	Url           string
+	ApiKey        string
+	Priority      string
+	PriorityMap   map[string]string
+	Tags          []string
+	Responders    []string
+	DedupKeyProps []string
+	AutoClose     bool
+}
+
+type opsgenieAlert struct {
+	Message     string              `json:"message"`
+	Alias       string              `json:"alias,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Responders  []opsgenieResponder `json:"responders,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Details     map[string]string   `json:"details,omitempty"`
+	Entity      string              `json:"entity,omitempty"`
+	Source      string              `json:"source"`
+	Priority    string              `json:"priority"`
+}
+
+type opsgenieResponder struct {
+	Type     string `json:"type"`
+	Name     string `json:"name,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+type opsgenieAction struct {
+	Source string `json:"source"`
+	Note   string `json:"note,omitempty"`
+}
+
+func (opsgenie *OpsgenieOutput) GetName() string {
+	return opsgenie.Name
+}
+
+// ReadsInput makes Opsgenie get the input, aliases and priorities of alerts are read from it
+func (opsgenie *OpsgenieOutput) ReadsInput() bool {
+	return true
+}
+
+func (opsgenie *OpsgenieOutput) Init() error {
+	if opsgenie.Url == "" {
+		opsgenie.Url = OpsgenieApiUrl
+	}
+	opsgenie.Url = strings.TrimSuffix(opsgenie.Url, "/")
+	if opsgenie.Priority == "" {
+		opsgenie.Priority = OpsgenieDefaultPriority
+	}
+	log.Printf("Starting Opsgenie output %q, for sending to %q", opsgenie.Name, opsgenie.Url)
+	return nil
+}
+
+func (opsgenie *OpsgenieOutput) Send(ctx context.Context, content map[string]string) error {
+	log.Printf("Sending a message to %q", opsgenie.Name)
+
+	in, err := parseInput(content)
+	if err != nil {
+		log.Printf("Sending to %q error: %v", opsgenie.Name, err)
+		return Permanent(err)
+	}
+	alias := truncate(data.JoinProps(in, opsgenie.DedupKeyProps), opsgenieAliasLimit)
+
+	endpoint := opsgenie.Url + "/v2/alerts"
+	var body interface{}
+	switch action := opsgenie.action(content, in); action {
+	case "close", "acknowledge":
+		if alias == "" {
+			return Permanent(fmt.Errorf("%q of an alert requires an alias, the input has none of %v", action, opsgenie.DedupKeyProps))
+		}
+		endpoint += "/" + url.PathEscape(alias) + "/" + action + "?identifierType=alias"
+		body = &opsgenieAction{Source: "Postee", Note: content["title"]}
+	default:
+		body = opsgenie.buildAlert(ctx, content, in, alias)
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return Permanent(err)
+	}
+	err = throttled(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "GenieKey "+opsgenie.ApiKey)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+			b, _ := ioutil.ReadAll(resp.Body)
+			log.Printf("Opsgenie sending error: failed response status %q. Body: %q", resp.Status, string(b))
+			return utils.NewHttpError(resp.StatusCode, "failed response status %d for Opsgenie sending", resp.StatusCode).WithRetryAfter(resp.Header)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Sending a message to %q was successful!", opsgenie.Name)
+	return nil
+}
+
+// action returns "close" or "acknowledge" when the template sets event_action, or "close" for a scan
+// without vulnerabilities if AutoClose is set. Other messages create alerts.
+func (opsgenie *OpsgenieOutput) action(content map[string]string, in map[string]interface{}) string {
+	switch action := strings.ToLower(content["event_action"]); action {
+	case "resolve", "close":
+		return "close"
+	case "acknowledge":
+		return action
+	case "", "trigger", "create":
+	default:
+		log.Printf("%q: unknown event action %q, an alert is created", opsgenie.Name, content["event_action"])
+	}
+	if opsgenie.AutoClose && isCleanScan(in) {
+		return "close"
+	}
+	return "create"
+}
+
+func (opsgenie *OpsgenieOutput) buildAlert(ctx context.Context, content map[string]string, in map[string]interface{}, alias string) *opsgenieAlert {
+	alert := &opsgenieAlert{
+		Message:     truncate(content["title"], opsgenieMessageLimit),
+		Alias:       alias,
+		Description: truncate(content["description"], opsgenieDescriptionLimit),
+		Tags:        opsgenie.Tags,
+		Entity:      data.Prop(in, "image"),
+		Source:      "Postee",
+		Priority:    opsgenie.priority(content, in),
+	}
+	if alert.Message == "" {
+		alert.Message = "Postee message"
+	}
+	if content["url"] != "" {
+		alert.Details = map[string]string{"url": content["url"]}
+	}
+	for _, responder := range getHandledRecipients(ctx, opsgenie.Responders, &content, opsgenie.Name) {
+		alert.Responders = append(alert.Responders, parseResponder(responder))
+	}
+	return alert
+}
+
+// priority returns the priority set by the template, or derived from the severity set by the template
+// or from the highest severity of vulnerability_summary. PriorityMap overrides the default mapping of severities.
+func (opsgenie *OpsgenieOutput) priority(content map[string]string, in map[string]interface{}) string {
+	if p := strings.ToUpper(content["priority"]); IsOpsgeniePriority(p) {
+		return p
+	}
+	severity := strings.ToLower(content["severity"])
+	if severity == "" {
+		severity = highestVulnerability(in)
+	}
+	if severity == "" {
+		return opsgenie.Priority
+	}
+	if p, ok := opsgenie.PriorityMap[severity]; ok {
+		return p
+	}
+	if p, ok := opsgeniePriorities[severity]; ok {
+		return p
+	}
+	log.Printf("%q: severity %q isn't mapped, %q is used", opsgenie.Name, severity, opsgenie.Priority)
+	return opsgenie.Priority
+}
+
+// parseResponder reads "team:<name>" or "user:<username>". Responders without a type are users if they are emails,
+// e.g. application scope owners, and teams otherwise.
+func parseResponder(responder string) opsgenieResponder {
+	switch {
+	case strings.HasPrefix(responder, opsgenieTeam):
+		return opsgenieResponder{Type: "team", Name: strings.TrimPrefix(responder, opsgenieTeam)}
+	case strings.HasPrefix(responder, opsgenieUser):
+		return opsgenieResponder{Type: "user", Username: strings.TrimPrefix(responder, opsgenieUser)}
+	case strings.Contains(responder, "@"):
+		return opsgenieResponder{Type: "user", Username: responder}
+	}
+	return opsgenieResponder{Type: "team", Name: responder}
+}
+
+func (opsgenie *OpsgenieOutput) Terminate() error {
+	log.Printf("Opsgenie output %q terminated", opsgenie.Name)
+	return nil
+}
+
+func (opsgenie *OpsgenieOutput) GetLayoutProvider() layout.LayoutProvider {
+	return new(formatting.HtmlProvider)
+}
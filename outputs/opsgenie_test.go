@@ -0,0 +1,128 @@
+package outputs
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestOpsgenieSend(t *testing.T) {
+	tests := []struct {
+		name         string
+		output       OpsgenieOutput
+		content      map[string]string
+		wantPath     string
+		wantPriority string
+		wantAlias    string
+		wantRespond  []opsgenieResponder
+	}{
+		{
+			name: "Alert with responders",
+			output: OpsgenieOutput{
+				DedupKeyProps: []string{"registry", "image"},
+				Tags:          []string{"aqua"},
+				Responders:    []string{"team:ops", "user:jdoe@example.com", ApplicationScopeOwner},
+			},
+			content: map[string]string{
+				"title":  "alpine:3.8 vulnerability scan report",
+				"owners": "owner1@example.com;sec",
+				"src":    `{"image":"alpine:3.8","registry":"Docker Hub","vulnerability_summary":{"critical":1,"high":2}}`,
+			},
+			wantPath:     "/v2/alerts",
+			wantPriority: "P1",
+			wantAlias:    "Docker Hub-alpine:3.8",
+			wantRespond: []opsgenieResponder{
+				{Type: "team", Name: "ops"},
+				{Type: "user", Username: "jdoe@example.com"},
+				{Type: "user", Username: "owner1@example.com"},
+				{Type: "team", Name: "sec"},
+			},
+		},
+		{
+			name:   "Priority map",
+			output: OpsgenieOutput{PriorityMap: map[string]string{"medium": "P2"}},
+			content: map[string]string{
+				"title": "alpine:3.8 vulnerability scan report",
+				"src":   `{"image":"alpine:3.8","vulnerability_summary":{"medium":3}}`,
+			},
+			wantPath:     "/v2/alerts",
+			wantPriority: "P2",
+		},
+		{
+			name:         "Priority of the template",
+			output:       OpsgenieOutput{Priority: "P5"},
+			content:      map[string]string{"title": "runtime event", "priority": "p2"},
+			wantPath:     "/v2/alerts",
+			wantPriority: "P2",
+		},
+		{
+			name:         "Default priority",
+			output:       OpsgenieOutput{Priority: "P4"},
+			content:      map[string]string{"title": "runtime event"},
+			wantPath:     "/v2/alerts",
+			wantPriority: "P4",
+		},
+		{
+			name:   "Clean scan closes the alert",
+			output: OpsgenieOutput{DedupKeyProps: []string{"image"}, AutoClose: true},
+			content: map[string]string{
+				"title": "alpine:3.8 vulnerability scan report",
+				"src":   `{"image":"alpine:3.8","vulnerability_summary":{"critical":0}}`,
+			},
+			wantPath: "/v2/alerts/alpine:3.8/close",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var path, auth string
+			var received []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				auth = r.Header.Get("Authorization")
+				received, _ = ioutil.ReadAll(r.Body)
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer server.Close()
+
+			opsgenie := test.output
+			opsgenie.Name = "my-opsgenie"
+			opsgenie.Url = server.URL
+			opsgenie.ApiKey = "api-key"
+			if err := opsgenie.Init(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err := opsgenie.Send(context.Background(), test.content); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if auth != "GenieKey api-key" {
+				t.Errorf("Wrong authorization: %q", auth)
+			}
+			if path != test.wantPath {
+				t.Fatalf("Wrong path, expected: %q, got: %q", test.wantPath, path)
+			}
+			if path != "/v2/alerts" {
+				return
+			}
+			alert := new(opsgenieAlert)
+			if err := json.Unmarshal(received, alert); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if alert.Message != test.content["title"] {
+				t.Errorf("Wrong message: %q", alert.Message)
+			}
+			if alert.Priority != test.wantPriority {
+				t.Errorf("Wrong priority, expected: %q, got: %q", test.wantPriority, alert.Priority)
+			}
+			if alert.Alias != test.wantAlias {
+				t.Errorf("Wrong alias, expected: %q, got: %q", test.wantAlias, alert.Alias)
+			}
+			if !reflect.DeepEqual(alert.Responders, test.wantRespond) {
+				t.Errorf("Wrong responders, expected: %v, got: %v", test.wantRespond, alert.Responders)
+			}
+		})
+	}
+}
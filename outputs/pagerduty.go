@@ -0,0 +1,240 @@
+package outputs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/aquasecurity/postee/v2/data"
+	"github.com/aquasecurity/postee/v2/formatting"
+	"github.com/aquasecurity/postee/v2/layout"
+	"github.com/aquasecurity/postee/v2/utils"
+)
+
+const (
+	PagerDutyEventsUrl = "https://events.pagerduty.com/v2/enqueue"
+
+	pagerDutyTrigger     = "trigger"
+	pagerDutyAcknowledge = "acknowledge"
+	pagerDutyResolve     = "resolve"
+
+	pagerDutyDefaultSeverity = "warning"
+	pagerDutySummaryLimit    = 1024
+)
+
+// pagerDutySeverities maps severities of vulnerabilities to severities of PagerDuty events
+var pagerDutySeverities = map[string]string{
+	"critical":   "critical",
+	"high":       "error",
+	"error":      "error",
+	"medium":     "warning",
+	"warning":    "warning",
+	"low":        "info",
+	"negligible": "info",
+	"info":       "info",
+}
+
+// IsPagerDutySeverity reports whether a value is a severity of PagerDuty Events API v2
+func IsPagerDutySeverity(severity string) bool {
+	switch severity {
+	case "critical", "error", "warning", "info":
+		return true
+	}
+	return false
+}
+
+// PagerDutyOutput sends messages to PagerDuty Events API v2. Messages with the same dedup key update one incident,
+// so a detection which is received again doesn't page again. The dedup key is built from DedupKeyProps of the input.
+type PagerDutyOutput struct {
+	Name          string
+	Url           string
+	RoutingKey    string
+	DedupKeyProps []string
+	SeverityMap   map[string]string
+	AutoResolve   bool
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key,omitempty"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	Links       []pagerDutyLink   `json:"links,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text,omitempty"`
+}
+
+func (pd *PagerDutyOutput) GetName() string {
+	return pd.Name
+}
+
+// ReadsInput makes PagerDuty get the input, dedup keys, severities and details of events are read from it
+func (pd *PagerDutyOutput) ReadsInput() bool {
+	return true
+}
+
+func (pd *PagerDutyOutput) Init() error {
+	if pd.Url == "" {
+		pd.Url = PagerDutyEventsUrl
+	}
+	log.Printf("Starting PagerDuty output %q, for sending to %q", pd.Name, pd.Url)
+	return nil
+}
+
+func (pd *PagerDutyOutput) Send(ctx context.Context, content map[string]string) error {
+	log.Printf("Sending a message to %q", pd.Name)
+
+	event, err := pd.buildEvent(content)
+	if err != nil {
+		log.Printf("Sending to %q error: %v", pd.Name, err)
+		return Permanent(err)
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	err = throttled(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, "POST", pd.Url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+			b, _ := ioutil.ReadAll(resp.Body)
+			log.Printf("PagerDuty sending error: failed response status %q. Body: %q", resp.Status, string(b))
+			return utils.NewHttpError(resp.StatusCode, "failed response status %d for PagerDuty sending", resp.StatusCode).WithRetryAfter(resp.Header)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Sending %s event %q to %q was successful!", event.EventAction, event.DedupKey, pd.Name)
+	return nil
+}
+
+// buildEvent maps a rendered message to an event. Fields of the input are read from "src" of the content,
+// the template can set "severity" and "event_action" of the event.
+func (pd *PagerDutyOutput) buildEvent(content map[string]string) (*pagerDutyEvent, error) {
+	in, err := parseInput(content)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &pagerDutyEvent{
+		RoutingKey:  pd.RoutingKey,
+		EventAction: pd.eventAction(content, in),
+		DedupKey:    data.JoinProps(in, pd.DedupKeyProps),
+	}
+	if event.EventAction != pagerDutyTrigger {
+		if event.DedupKey == "" {
+			return nil, fmt.Errorf("%q event requires a dedup key, the input has none of %v", event.EventAction, pd.DedupKeyProps)
+		}
+		return event, nil
+	}
+
+	event.Payload = &pagerDutyPayload{
+		Summary:       truncate(content["title"], pagerDutySummaryLimit),
+		Source:        pagerDutySource(in),
+		Severity:      pd.severity(content, in),
+		CustomDetails: pagerDutyDetails(content["description"], in),
+	}
+	if event.Payload.Summary == "" {
+		event.Payload.Summary = "Postee message"
+	}
+	if content["url"] != "" {
+		event.Links = []pagerDutyLink{{Href: content["url"], Text: "Aqua"}}
+	}
+	return event, nil
+}
+
+// eventAction returns the action set by the template. Otherwise, an input without vulnerabilities resolves the incident
+// if AutoResolve is set, and any other input triggers it.
+func (pd *PagerDutyOutput) eventAction(content map[string]string, in map[string]interface{}) string {
+	switch action := strings.ToLower(content["event_action"]); action {
+	case pagerDutyTrigger, pagerDutyAcknowledge, pagerDutyResolve:
+		return action
+	case "":
+	default:
+		log.Printf("%q: unknown event action %q, the event is triggered", pd.Name, content["event_action"])
+	}
+	if pd.AutoResolve && isCleanScan(in) {
+		return pagerDutyResolve
+	}
+	return pagerDutyTrigger
+}
+
+// severity returns the severity set by the template or the highest severity of vulnerability_summary,
+// mapped by SeverityMap to a PagerDuty severity
+func (pd *PagerDutyOutput) severity(content map[string]string, in map[string]interface{}) string {
+	severity := strings.ToLower(content["severity"])
+	if severity == "" {
+		severity = highestVulnerability(in)
+	}
+	if severity == "" {
+		return pagerDutyDefaultSeverity
+	}
+	if mapped, ok := pd.SeverityMap[severity]; ok {
+		return mapped
+	}
+	if mapped, ok := pagerDutySeverities[severity]; ok {
+		return mapped
+	}
+	log.Printf("%q: severity %q isn't mapped, %q is used", pd.Name, severity, pagerDutyDefaultSeverity)
+	return pagerDutyDefaultSeverity
+}
+
+func pagerDutySource(in map[string]interface{}) string {
+	for _, prop := range []string{"image", "host", "container"} {
+		if v := data.Prop(in, prop); v != "" {
+			return v
+		}
+	}
+	return "postee"
+}
+
+// pagerDutyDetails keeps the rendered description, a JSON description is passed as is
+func pagerDutyDetails(description string, in map[string]interface{}) map[string]interface{} {
+	details := map[string]interface{}{}
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(description), &parsed); err == nil {
+		details["description"] = parsed
+	} else if description != "" {
+		details["description"] = description
+	}
+	if summary, ok := in["vulnerability_summary"]; ok {
+		details["vulnerability_summary"] = summary
+	}
+	return details
+}
+
+func (pd *PagerDutyOutput) Terminate() error {
+	log.Printf("PagerDuty output %q terminated", pd.Name)
+	return nil
+}
+
+func (pd *PagerDutyOutput) GetLayoutProvider() layout.LayoutProvider {
+	// Formatting isn't needed, the message is sent as JSON
+	return new(formatting.HtmlProvider)
+}
@@ -0,0 +1,156 @@
+package outputs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPagerDutySend(t *testing.T) {
+	tests := []struct {
+		name         string
+		output       PagerDutyOutput
+		content      map[string]string
+		wantAction   string
+		wantDedupKey string
+		wantSeverity string
+		wantErr      bool
+	}{
+		{
+			name:   "Severity of vulnerability summary",
+			output: PagerDutyOutput{DedupKeyProps: []string{"registry", "image"}},
+			content: map[string]string{
+				"title":       "alpine:3.8 vulnerability scan report",
+				"description": `{"critical":0}`,
+				"src":         `{"image":"alpine:3.8","registry":"Docker Hub","vulnerability_summary":{"critical":0,"high":2,"medium":5}}`,
+			},
+			wantAction:   "trigger",
+			wantDedupKey: "Docker Hub-alpine:3.8",
+			wantSeverity: "error",
+		},
+		{
+			name:   "Custom severity map",
+			output: PagerDutyOutput{SeverityMap: map[string]string{"high": "critical"}},
+			content: map[string]string{
+				"title": "alpine:3.8 vulnerability scan report",
+				"src":   `{"image":"alpine:3.8","vulnerability_summary":{"high":2}}`,
+			},
+			wantAction:   "trigger",
+			wantSeverity: "critical",
+		},
+		{
+			name:   "Severity and action of the template",
+			output: PagerDutyOutput{DedupKeyProps: []string{"image"}},
+			content: map[string]string{
+				"title":        "alpine:3.8 is fixed",
+				"severity":     "low",
+				"event_action": "acknowledge",
+				"src":          `{"image":"alpine:3.8"}`,
+			},
+			wantAction:   "acknowledge",
+			wantDedupKey: "alpine:3.8",
+		},
+		{
+			name:   "Clean scan resolves the incident",
+			output: PagerDutyOutput{DedupKeyProps: []string{"image"}, AutoResolve: true},
+			content: map[string]string{
+				"title": "alpine:3.8 vulnerability scan report",
+				"src":   `{"image":"alpine:3.8","vulnerability_summary":{"critical":0,"high":0}}`,
+			},
+			wantAction:   "resolve",
+			wantDedupKey: "alpine:3.8",
+		},
+		{
+			name:   "Resolve without dedup key",
+			output: PagerDutyOutput{DedupKeyProps: []string{"digest"}},
+			content: map[string]string{
+				"event_action": "resolve",
+				"src":          `{"image":"alpine:3.8"}`,
+			},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var received *pagerDutyEvent
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = new(pagerDutyEvent)
+				if err := json.NewDecoder(r.Body).Decode(received); err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer server.Close()
+
+			pd := test.output
+			pd.Name = "my-pagerduty"
+			pd.Url = server.URL
+			pd.RoutingKey = "routing-key"
+			if err := pd.Init(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			err := pd.Send(context.Background(), test.content)
+			if test.wantErr {
+				if err == nil || IsRetryable(err) {
+					t.Fatalf("A permanent error is expected, got %v", err)
+				}
+				if received != nil {
+					t.Errorf("No event is expected to be sent")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if received == nil {
+				t.Fatal("No event is received")
+			}
+			if received.RoutingKey != "routing-key" {
+				t.Errorf("Wrong routing key: %q", received.RoutingKey)
+			}
+			if received.EventAction != test.wantAction {
+				t.Errorf("Wrong event action, expected: %q, got: %q", test.wantAction, received.EventAction)
+			}
+			if received.DedupKey != test.wantDedupKey {
+				t.Errorf("Wrong dedup key, expected: %q, got: %q", test.wantDedupKey, received.DedupKey)
+			}
+			if test.wantAction != "trigger" {
+				if received.Payload != nil {
+					t.Errorf("Payload isn't expected for %q event", received.EventAction)
+				}
+				return
+			}
+			if received.Payload == nil {
+				t.Fatal("Payload is expected")
+			}
+			if received.Payload.Severity != test.wantSeverity {
+				t.Errorf("Wrong severity, expected: %q, got: %q", test.wantSeverity, received.Payload.Severity)
+			}
+			if received.Payload.Summary != test.content["title"] {
+				t.Errorf("Wrong summary: %q", received.Payload.Summary)
+			}
+			if received.Payload.Source != "alpine:3.8" {
+				t.Errorf("Wrong source: %q", received.Payload.Source)
+			}
+		})
+	}
+}
+
+func TestPagerDutyRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"status":"invalid event"}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	pd := &PagerDutyOutput{Name: "my-pagerduty", Url: server.URL, RoutingKey: "routing-key"}
+	err := pd.Send(context.Background(), map[string]string{"title": "title"})
+	if err == nil {
+		t.Fatal("An error is expected")
+	}
+	if IsRetryable(err) {
+		t.Errorf("400 response isn't expected to be retried: %v", err)
+	}
+}
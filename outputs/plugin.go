@@ -1,11 +1,12 @@
 package outputs
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"strings"
 
 	"github.com/aquasecurity/postee/v2/layout"
+	"github.com/aquasecurity/postee/v2/logging"
 )
 
 const (
@@ -15,18 +16,31 @@ const (
 type Output interface {
 	GetName() string
 	Init() error
-	Send(map[string]string) error
+	// Send delivers a message. It must return once the context is done.
+	Send(context.Context, map[string]string) error
 	Terminate() error
 	GetLayoutProvider() layout.LayoutProvider
 }
 
-func getHandledRecipients(recipients []string, content *map[string]string, outputName string) []string {
+// InputReader is implemented by outputs which read fields of the input. Only they get the input of a message
+// in "src" of the content, so outbox and dead letters of other outputs don't keep a copy of every scan.
+type InputReader interface {
+	ReadsInput() bool
+}
+
+// ReadsInput reports whether an output reads the input of messages
+func ReadsInput(o Output) bool {
+	r, ok := o.(InputReader)
+	return ok && r.ReadsInput()
+}
+
+func getHandledRecipients(ctx context.Context, recipients []string, content *map[string]string, outputName string) []string {
 	var result []string
 	for _, r := range recipients {
 		if r == ApplicationScopeOwner {
 			owners, err := getAppScopeOwners(content)
 			if err != nil {
-				log.Printf("get application scope owners error for %q: %v", outputName, err)
+				logging.FromContext(ctx).Warnf("get application scope owners error for %q: %v", outputName, err)
 				continue
 			}
 			result = append(result, owners...)
@@ -45,3 +59,19 @@ func getAppScopeOwners(content *map[string]string) ([]string, error) {
 	}
 	return strings.Split(ownersIn, ";"), nil
 }
+
+type routeCtxKey struct{}
+
+type routeInfo struct {
+	route, template string
+}
+
+// WithRoute attaches the route and the template of a message to the context of a send
+func WithRoute(ctx context.Context, route, template string) context.Context {
+	return context.WithValue(ctx, routeCtxKey{}, routeInfo{route, template})
+}
+
+func routeFrom(ctx context.Context) (route, template string) {
+	info, _ := ctx.Value(routeCtxKey{}).(routeInfo)
+	return info.route, info.template
+}
@@ -0,0 +1,155 @@
+package outputs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/aquasecurity/postee/v2/utils"
+)
+
+// maxThrottledRetries limits how many times a request rejected with Retry-After is repeated within a single send
+const maxThrottledRetries = 3
+
+// RateLimiter is a token bucket shared by all sends to an output. It also keeps the output
+// quiet for a delay requested by Retry-After header.
+type RateLimiter struct {
+	mu          sync.Mutex
+	interval    time.Duration //time to get a token, zero for no limit
+	burst       int
+	tat         time.Time //theoretical arrival time of the next request
+	pausedUntil time.Time
+}
+
+// NewRateLimiter allows up to events requests per a period, burst of them may be made at once.
+// If events isn't positive the rate isn't limited, but Retry-After is still honoured.
+func NewRateLimiter(events int, per time.Duration, burst int) *RateLimiter {
+	l := &RateLimiter{burst: burst}
+	if events > 0 && per > 0 {
+		l.interval = per / time.Duration(events)
+	}
+	if l.burst < 1 {
+		l.burst = 1
+	}
+	return l
+}
+
+// rateLimitError is returned when a request can't be made before the deadline of a send
+type rateLimitError struct {
+	delay time.Duration
+}
+
+func (e *rateLimitError) Error() string {
+	return fmt.Sprintf("rate limit is exceeded, the next request is possible in %s", e.delay)
+}
+
+// Wait blocks until a request is allowed. If the context deadline comes earlier it fails at once.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	l.mu.Lock()
+	now := time.Now()
+	allowed := now
+	if l.interval > 0 {
+		if earliest := l.tat.Add(-time.Duration(l.burst-1) * l.interval); earliest.After(allowed) {
+			allowed = earliest
+		}
+	}
+	if l.pausedUntil.After(allowed) {
+		allowed = l.pausedUntil
+	}
+	delay := allowed.Sub(now)
+	if deadline, ok := ctx.Deadline(); ok && allowed.After(deadline) {
+		l.mu.Unlock()
+		return &rateLimitError{delay}
+	}
+	if l.interval > 0 {
+		if l.tat.Before(allowed) {
+			l.tat = allowed
+		}
+		l.tat = l.tat.Add(l.interval)
+	}
+	l.mu.Unlock()
+	return sleep(ctx, delay)
+}
+
+// Pause holds all requests for a delay
+func (l *RateLimiter) Pause(delay time.Duration) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(delay); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+type rateLimiterCtxKey struct{}
+
+// WithRateLimiter attaches the rate limiter of an output to the context of a send
+func WithRateLimiter(ctx context.Context, l *RateLimiter) context.Context {
+	return context.WithValue(ctx, rateLimiterCtxKey{}, l)
+}
+
+func rateLimiterFrom(ctx context.Context) *RateLimiter {
+	l, _ := ctx.Value(rateLimiterCtxKey{}).(*RateLimiter)
+	return l
+}
+
+// RetryAfter returns a delay after which a failed send should be repeated, zero if it isn't known
+func RetryAfter(err error) time.Duration {
+	var limitErr *rateLimitError
+	if errors.As(err, &limitErr) {
+		return limitErr.delay
+	}
+	var httpErr *utils.HttpError
+	if errors.As(err, &httpErr) {
+		return httpErr.RetryAfter
+	}
+	return 0
+}
+
+// throttled makes a request within the rate limit of an output. A request which is rejected
+// with Retry-After is repeated after the delay if the send has enough time for that.
+func throttled(ctx context.Context, request func() error) error {
+	limiter := rateLimiterFrom(ctx)
+	for i := 0; ; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		err := request()
+		delay := RetryAfter(err)
+		if delay <= 0 {
+			return err
+		}
+		limiter.Pause(delay)
+		if deadline, ok := ctx.Deadline(); i == maxThrottledRetries || (ok && time.Now().Add(delay).After(deadline)) {
+			return err
+		}
+		logging.FromContext(ctx).Warnf("Request is throttled, repeating it in %s", delay)
+		if limiter == nil {
+			if err := sleep(ctx, delay); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,96 @@
+package outputs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aquasecurity/postee/v2/utils"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := NewRateLimiter(10, time.Second, 2)
+	started := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(started); elapsed < 90*time.Millisecond {
+		t.Errorf("The third request is expected to wait for a token, it waited %s", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	l.Pause(time.Minute)
+	err := l.Wait(ctx)
+	if delay := RetryAfter(err); delay < 59*time.Second {
+		t.Errorf("Request is expected to fail at once with a delay of the pause, got %v", err)
+	}
+	if !IsRetryable(err) {
+		t.Errorf("Rate limit error is expected to be retryable")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"-1", 0},
+		{"soon", 0},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0},
+	}
+	for _, test := range tests {
+		if delay := utils.ParseRetryAfter(test.value); delay != test.expected {
+			t.Errorf("Wrong delay for %q, expected %s, got %s", test.value, test.expected, delay)
+		}
+	}
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if delay := utils.ParseRetryAfter(future); delay < 59*time.Minute || delay > time.Hour {
+		t.Errorf("Wrong delay for a date, got %s", delay)
+	}
+}
+
+func TestSendHonoursRetryAfter(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := &WebhookOutput{Name: "my-webhook", Url: server.URL}
+	ctx := WithRateLimiter(context.Background(), NewRateLimiter(0, 0, 0))
+
+	started := time.Now()
+	if err := webhook.Send(ctx, map[string]string{"description": "{}"}); err != nil {
+		t.Fatalf("Throttled request is expected to be repeated, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < time.Second {
+		t.Errorf("Request is repeated before Retry-After, in %s", elapsed)
+	}
+	if atomic.LoadInt32(&requests) != 2 {
+		t.Errorf("Wrong number of requests, expected 2, got %d", requests)
+	}
+
+	//the delay doesn't fit in the deadline of the send, so it's left to the retry policy
+	atomic.StoreInt32(&requests, 0)
+	ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	err := webhook.Send(ctx, map[string]string{"description": "{}"})
+	var httpErr *utils.HttpError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusTooManyRequests || RetryAfter(err) != time.Second {
+		t.Errorf("429 error with Retry-After is expected, got %v", err)
+	}
+}
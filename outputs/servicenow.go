@@ -1,6 +1,7 @@
 package outputs
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 
@@ -29,7 +30,7 @@ func (sn *ServiceNowOutput) Init() error {
 	return nil
 }
 
-func (sn *ServiceNowOutput) Send(content map[string]string) error {
+func (sn *ServiceNowOutput) Send(ctx context.Context, content map[string]string) error {
 	log.Printf("Sending via ServiceNow %q", sn.Name)
 	d := &servicenow.ServiceNowData{
 		ShortDescription: content["title"],
@@ -40,7 +41,9 @@ func (sn *ServiceNowOutput) Send(content map[string]string) error {
 		log.Println("ServiceNow Error:", err)
 		return err
 	}
-	err = servicenow.InsertRecordToTable(sn.User, sn.Password, sn.Instance, sn.Table, body)
+	err = throttled(ctx, func() error {
+		return servicenow.InsertRecordToTable(ctx, sn.User, sn.Password, sn.Instance, sn.Table, body)
+	})
 	if err != nil {
 		log.Println("ServiceNow Error:", err)
 		return err
@@ -2,6 +2,7 @@ package outputs
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"log"
 	"strings"
@@ -53,7 +54,7 @@ func buildSlackBlock(title string, data []byte) []byte {
 	return content.Bytes()
 }
 
-func (slack *SlackOutput) Send(input map[string]string) error {
+func (slack *SlackOutput) Send(ctx context.Context, input map[string]string) error {
 	log.Printf("Sending via Slack %q", slack.Name)
 	title := clearSlackText(slack.slackLayout.TitleH2(input["title"]))
 	var body string
@@ -70,14 +71,17 @@ func (slack *SlackOutput) Send(input map[string]string) error {
 	err := json.Unmarshal([]byte(body), &rawBlock)
 	if err != nil {
 		log.Printf("Unmarshal slack sending error: %v", err)
-		return err
+		return Permanent(err)
 	}
 
 	length := len(rawBlock)
 
 	if length >= slackBlockLimit {
 		message := buildShortMessage(slack.AquaServer, input["url"], slack.slackLayout)
-		if err := slackAPI.SendToUrl(slack.Url, buildSlackBlock(title, []byte(message))); err != nil {
+		err := throttled(ctx, func() error {
+			return slackAPI.SendToUrl(ctx, slack.Url, buildSlackBlock(title, []byte(message)))
+		})
+		if err != nil {
 			return err
 		}
 		log.Printf("Sending via Slack %q was successful!", slack.Name)
@@ -89,7 +93,11 @@ func (slack *SlackOutput) Send(input map[string]string) error {
 			}
 			cutData, _ := json.Marshal(rawBlock[n : n+d])
 			cutData = cutData[1 : len(cutData)-1]
-			if err := slackAPI.SendToUrl(slack.Url, buildSlackBlock(title, cutData)); err != nil {
+			//every part waits for the rate limit, a throttled part is repeated alone so the sent ones aren't duplicated
+			err := throttled(ctx, func() error {
+				return slackAPI.SendToUrl(ctx, slack.Url, buildSlackBlock(title, cutData))
+			})
+			if err != nil {
 				log.Printf("Sending to %q was finished with error: %v", slack.Name, err)
 				return err
 			} else {
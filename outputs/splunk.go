@@ -2,6 +2,7 @@ package outputs
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 	"github.com/aquasecurity/postee/v2/data"
 	"github.com/aquasecurity/postee/v2/formatting"
 	"github.com/aquasecurity/postee/v2/layout"
+	"github.com/aquasecurity/postee/v2/utils"
 )
 
 const defaultSizeLimit = 10000
@@ -29,13 +31,18 @@ func (splunk *SplunkOutput) GetName() string {
 	return splunk.Name
 }
 
+// ReadsInput makes Splunk get the scan, which is sent as the event
+func (splunk *SplunkOutput) ReadsInput() bool {
+	return true
+}
+
 func (splunk *SplunkOutput) Init() error {
 	splunk.splunkLayout = new(formatting.HtmlProvider)
 	log.Printf("Starting Splunk output %q....", splunk.Name)
 	return nil
 }
 
-func (splunk *SplunkOutput) Send(d map[string]string) error {
+func (splunk *SplunkOutput) Send(ctx context.Context, d map[string]string) error {
 	log.Printf("Sending a message to %q", splunk.Name)
 
 	if splunk.EventLimit == 0 {
@@ -54,7 +61,7 @@ func (splunk *SplunkOutput) Send(d map[string]string) error {
 	err := json.Unmarshal([]byte(d["src"]), scanInfo)
 	if err != nil {
 		log.Printf("sending to %q error: %v", splunk.Name, err)
-		return err
+		return Permanent(err)
 	}
 
 	eventFormat := "{\"sourcetype\": \"_json\", \"event\": "
@@ -85,7 +92,7 @@ func (splunk *SplunkOutput) Send(d map[string]string) error {
 			msg := fmt.Sprintf("Scan result for %q is large for %q , its size if %d (limit %d)",
 				scanInfo.Image, splunk.Name, len(fields), splunk.EventLimit)
 			log.Print(msg)
-			return errors.New(msg)
+			return Permanent(errors.New(msg))
 		}
 	}
 
@@ -94,23 +101,29 @@ func (splunk *SplunkOutput) Send(d map[string]string) error {
 	buff.Write(fields)
 	buff.WriteByte('}')
 
-	req, err := http.NewRequest("POST", splunk.Url+"services/collector", &buff)
-	if err != nil {
-		return err
-	}
+	err = throttled(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, "POST", splunk.Url+"services/collector", bytes.NewReader(buff.Bytes()))
+		if err != nil {
+			return err
+		}
 
-	req.Header.Add("Authorization", "Splunk "+splunk.Token)
+		req.Header.Add("Authorization", "Splunk "+splunk.Token)
 
-	resp, err := http.DefaultClient.Do(req)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			b, _ := ioutil.ReadAll(resp.Body)
+			log.Printf("Splunk sending error: failed response status %q. Body: %q", resp.Status, string(b))
+			return utils.NewHttpError(resp.StatusCode, "failed response status for Splunk sending").WithRetryAfter(resp.Header)
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		b, _ := ioutil.ReadAll(resp.Body)
-		log.Printf("Splunk sending error: failed response status %q. Body: %q", resp.Status, string(b))
-		return errors.New("failed response status for Splunk sending")
-	}
 	log.Printf("Sending a message to %q was successful!", splunk.Name)
 	return nil
 }
@@ -0,0 +1,55 @@
+package outputs
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aquasecurity/postee/v2/data"
+)
+
+func TestSplunkSend(t *testing.T) {
+	var received struct {
+		SourceType string             `json:"sourcetype"`
+		Event      data.ScanImageInfo `json:"event"`
+	}
+	var auth, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, path = r.Header.Get("Authorization"), r.URL.Path
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &received); err != nil {
+			t.Errorf("Unexpected event %q: %v", body, err)
+		}
+	}))
+	defer server.Close()
+
+	splunk := &SplunkOutput{Name: "my-splunk", Url: server.URL, Token: "token"}
+	if err := splunk.Init(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	err := splunk.Send(context.Background(), map[string]string{
+		"title": "alpine:3.8 vulnerability scan report",
+		"src":   `{"image":"alpine:3.8","registry":"Docker Hub","vulnerability_summary":{"critical":1,"high":2}}`,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if path != "/services/collector" || auth != "Splunk token" {
+		t.Errorf("Wrong request: path %q, authorization %q", path, auth)
+	}
+	if received.SourceType != "_json" || received.Event.Image != "alpine:3.8" || received.Event.Registry != "Docker Hub" ||
+		received.Event.Critical != 1 || received.Event.High != 2 {
+		t.Errorf("Wrong event: %+v", received)
+	}
+}
+
+func TestSplunkSendWithoutInput(t *testing.T) {
+	splunk := &SplunkOutput{Name: "my-splunk", Url: "http://localhost:1"}
+	err := splunk.Send(context.Background(), map[string]string{"title": "aggregated"})
+	if err == nil || IsRetryable(err) {
+		t.Errorf("A permanent error is expected, got: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package outputs
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -16,7 +17,7 @@ func (stdout StdoutOutput) GetName() string { return stdout.Name }
 func (stdout StdoutOutput) Init() error {
 	return nil
 }
-func (stdout StdoutOutput) Send(data map[string]string) error {
+func (stdout StdoutOutput) Send(ctx context.Context, data map[string]string) error {
 	_, err := fmt.Fprintf(os.Stdout, "%s", data["description"])
 	return err
 }
@@ -0,0 +1,282 @@
+package outputs
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aquasecurity/postee/v2/formatting"
+	"github.com/aquasecurity/postee/v2/layout"
+)
+
+const (
+	SyslogUdp = "udp"
+	SyslogTcp = "tcp"
+	SyslogTls = "tls"
+
+	SyslogFormatRfc5424 = "rfc5424"
+	SyslogFormatCef     = "cef"
+	SyslogFormatLeef    = "leef"
+
+	syslogDefaultFacility = "local0"
+	syslogDefaultAppName  = "postee"
+	syslogDialTimeout     = 10 * time.Second
+	syslogHostnameLimit   = 255
+	syslogAppNameLimit    = 48
+	syslogMsgIdLimit      = 32
+)
+
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSeverities maps severities of vulnerabilities to severities of syslog messages
+var syslogSeverities = map[string]int{
+	"critical":   2,
+	"high":       3,
+	"medium":     4,
+	"low":        5,
+	"negligible": 6,
+}
+
+const syslogInfo = 6
+
+// SyslogOutput sends RFC 5424 messages to a syslog server or a SIEM over UDP, TCP or TLS.
+// The message is the rendered template, or a CEF or LEEF event with fields of the scan and of the template.
+type SyslogOutput struct {
+	Name       string
+	Host       string
+	Port       int
+	Protocol   string
+	Format     string
+	Facility   string
+	AppName    string
+	Body       string
+	CaCert     string
+	ClientCert string
+	ClientKey  string
+
+	facility  int
+	hostname  string
+	tlsConfig *tls.Config
+
+	mutex sync.Mutex
+	conn  net.Conn
+}
+
+func (s *SyslogOutput) GetName() string {
+	return s.Name
+}
+
+// ReadsInput makes Syslog get the input for severities and scan fields of CEF and LEEF events
+func (s *SyslogOutput) ReadsInput() bool {
+	return true
+}
+
+// Check parses the options of the output without loading certificates
+func (s *SyslogOutput) Check() error {
+	s.Protocol = strings.ToLower(s.Protocol)
+	switch s.Protocol {
+	case "":
+		s.Protocol = SyslogUdp
+	case SyslogUdp, SyslogTcp, SyslogTls:
+	default:
+		return fmt.Errorf("'protocol'(%q) isn't one of %s, %s or %s", s.Protocol, SyslogUdp, SyslogTcp, SyslogTls)
+	}
+
+	s.Format = strings.ToLower(s.Format)
+	switch s.Format {
+	case "":
+		s.Format = SyslogFormatRfc5424
+	case SyslogFormatRfc5424, SyslogFormatCef, SyslogFormatLeef:
+	default:
+		return fmt.Errorf("'format'(%q) isn't one of %s, %s or %s", s.Format, SyslogFormatRfc5424, SyslogFormatCef, SyslogFormatLeef)
+	}
+
+	if s.Facility == "" {
+		s.Facility = syslogDefaultFacility
+	}
+	facility, ok := syslogFacilities[strings.ToLower(s.Facility)]
+	if !ok {
+		return fmt.Errorf("'facility'(%q) isn't a syslog facility, e.g. user or local0", s.Facility)
+	}
+	s.facility = facility
+
+	if s.AppName == "" {
+		s.AppName = syslogDefaultAppName
+	}
+	if !isPrintUsAscii(s.AppName) || len(s.AppName) > syslogAppNameLimit {
+		return fmt.Errorf("'app-name'(%q) should be up to %d printable ASCII characters without spaces", s.AppName, syslogAppNameLimit)
+	}
+	var err error
+	if s.Body, err = checkBody(s.Body); err != nil {
+		return err
+	}
+	return checkTlsFiles(s.ClientCert, s.ClientKey)
+}
+
+func (s *SyslogOutput) Init() error {
+	if s.Host == "" {
+		return errors.New("'host' is required")
+	}
+	if err := s.Check(); err != nil {
+		return err
+	}
+	if s.Port == 0 {
+		s.Port = 514
+		if s.Protocol == SyslogTls {
+			s.Port = 6514
+		}
+	}
+	if s.Protocol == SyslogTls {
+		config, err := loadTlsConfig(s.CaCert, s.ClientCert, s.ClientKey)
+		if err != nil {
+			return err
+		}
+		config.ServerName = s.Host
+		s.tlsConfig = config
+	}
+	hostname, _ := os.Hostname()
+	s.hostname = syslogHeaderField(hostname, syslogHostnameLimit)
+	log.Printf("Starting Syslog output %q, for sending %s messages to %s://%s", s.Name, s.Format, s.Protocol, s.address())
+	return nil
+}
+
+func (s *SyslogOutput) address() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+func (s *SyslogOutput) Send(ctx context.Context, content map[string]string) error {
+	log.Printf("Sending a message to %q", s.Name)
+
+	in, err := parseInput(content)
+	if err != nil {
+		return Permanent(err)
+	}
+	route, _ := routeFrom(ctx)
+	severity := eventSeverity(content, in)
+
+	var msg []byte
+	switch s.Format {
+	case SyslogFormatCef:
+		msg = []byte(formatCef(route, severity, content))
+	case SyslogFormatLeef:
+		msg = []byte(formatLeef(route, severity, content))
+	default:
+		if msg, err = messageBody(s.Body, content); err != nil {
+			return Permanent(err)
+		}
+	}
+
+	if err := s.write(ctx, s.header(route, severity, time.Now()), msg); err != nil {
+		log.Printf("Sending to %q error: %v", s.Name, err)
+		return err
+	}
+	log.Printf("Sending a message to %q was successful!", s.Name)
+	return nil
+}
+
+// header returns the RFC 5424 header of a message, the route is its MSGID
+func (s *SyslogOutput) header(route, severity string, now time.Time) string {
+	level, ok := syslogSeverities[severity]
+	if !ok {
+		level = syslogInfo
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s - %s - ",
+		s.facility*8+level, now.UTC().Format("2006-01-02T15:04:05.000Z07:00"), s.hostname, s.AppName,
+		syslogHeaderField(route, syslogMsgIdLimit))
+}
+
+// syslogHeaderField returns a field of the header which RFC 5424 limits to printable US-ASCII characters.
+// Other characters, e.g. spaces of route names, are replaced by underscores, an empty field is NILVALUE.
+func syslogHeaderField(value string, limit int) string {
+	if value == "" {
+		return "-"
+	}
+	return truncate(strings.Map(func(r rune) rune {
+		if r < '!' || r > '~' {
+			return '_'
+		}
+		return r
+	}, value), limit)
+}
+
+func isPrintUsAscii(value string) bool {
+	for _, r := range value {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// write sends a message over a connection which is kept between messages. Messages over TCP and TLS
+// are framed by octet counting (RFC 6587), a connection which fails is opened again by the next message.
+func (s *SyslogOutput) write(ctx context.Context, header string, msg []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.conn == nil {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetWriteDeadline(deadline)
+	} else {
+		s.conn.SetWriteDeadline(time.Time{})
+	}
+
+	frame := make([]byte, 0, len(header)+len(msg)+8)
+	if s.Protocol != SyslogUdp {
+		frame = strconv.AppendInt(frame, int64(len(header)+len(msg)), 10)
+		frame = append(frame, ' ')
+	}
+	frame = append(frame, header...)
+	frame = append(frame, msg...)
+	if _, err := s.conn.Write(frame); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (s *SyslogOutput) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: syslogDialTimeout}
+	switch s.Protocol {
+	case SyslogTls:
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: s.tlsConfig}
+		return tlsDialer.DialContext(ctx, "tcp", s.address())
+	case SyslogTcp:
+		return dialer.DialContext(ctx, "tcp", s.address())
+	}
+	return dialer.DialContext(ctx, "udp", s.address())
+}
+
+func (s *SyslogOutput) Terminate() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	log.Printf("Syslog output %q terminated", s.Name)
+	return nil
+}
+
+func (s *SyslogOutput) GetLayoutProvider() layout.LayoutProvider {
+	return new(formatting.HtmlProvider)
+}
@@ -0,0 +1,210 @@
+package outputs
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var syslogContent = map[string]string{
+	"title":       "alpine:3.8 vulnerability scan report",
+	"description": `{"policy":"no-critical","blocked":true,"details":{"count":2}}`,
+	"url":         "https://aqua.example.com/images",
+	"src":         `{"image":"alpine:3.8","registry":"Docker Hub","digest":"sha256:1a2b","image_assurance_results":{"disallowed":true},"vulnerability_summary":{"critical":1,"high":2}}`,
+}
+
+func TestSyslogSend(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   *SyslogOutput
+		wantMsg  string
+		wantPart []string
+	}{
+		{
+			name:    "RFC 5424",
+			output:  &SyslogOutput{Facility: "user"},
+			wantMsg: `^<10>1 \S+ \S+ postee - my-route - \{"policy"`,
+		},
+		{
+			name:    "CEF",
+			output:  &SyslogOutput{Format: "cef", AppName: "aqua"},
+			wantMsg: `^<130>1 \S+ \S+ aqua - my-route - CEF:0\|Aqua Security\|Postee\|2\|my-route\|alpine:3.8 vulnerability scan report\|10\|rt=\d+ `,
+			wantPart: []string{
+				" cs1Label=registry cs1=Docker Hub ",
+				" cs2Label=image cs2=alpine:3.8 ",
+				" cs4Label=disallowed cs4=true ",
+				" cn1Label=critical cn1=1 cn2Label=high cn2=2 ",
+				" flexNumber1Label=low flexNumber1=0 flexNumber2Label=negligible flexNumber2=0 ",
+				" request=https://aqua.example.com/images ",
+				" blocked=true policy=no-critical",
+			},
+		},
+		{
+			name:    "LEEF",
+			output:  &SyslogOutput{Format: "leef"},
+			wantMsg: `^<130>1 \S+ \S+ postee - my-route - LEEF:1.0\|Aqua Security\|Postee\|2\|my-route\|devTime=`,
+			wantPart: []string{
+				"\tsev=10\t",
+				"\timage=alpine:3.8\t",
+				"\tdisallowed=true\t",
+				"\thigh=2\t",
+				"\tblocked=true\tpolicy=no-critical",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer listener.Close()
+
+			s := test.output
+			s.Name = "my-syslog"
+			s.Host = "127.0.0.1"
+			s.Port = listener.LocalAddr().(*net.UDPAddr).Port
+			if err := s.Init(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer s.Terminate()
+			if err := s.Send(WithRoute(context.Background(), "my-route", "my-template"), syslogContent); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			buf := make([]byte, 64*1024)
+			listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+			n, _, err := listener.ReadFrom(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg := string(buf[:n])
+			if !regexp.MustCompile(test.wantMsg).MatchString(msg) {
+				t.Errorf("Wrong message, expected: %q, got: %q", test.wantMsg, msg)
+			}
+			for _, part := range test.wantPart {
+				if !strings.Contains(msg, part) {
+					t.Errorf("Message %q doesn't contain %q", msg, part)
+				}
+			}
+		})
+	}
+}
+
+func TestSyslogTcpFraming(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for i := 0; i < 2; i++ {
+			size, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(size))
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				return
+			}
+			received <- string(msg)
+		}
+	}()
+
+	s := &SyslogOutput{
+		Name:     "my-syslog",
+		Host:     "127.0.0.1",
+		Port:     listener.Addr().(*net.TCPAddr).Port,
+		Protocol: "tcp",
+		Body:     BodyEnvelope,
+	}
+	if err := s.Init(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer s.Terminate()
+	for i := 0; i < 2; i++ {
+		if err := s.Send(context.Background(), map[string]string{"title": "event", "description": "multi\nline"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case msg := <-received:
+			if !strings.HasSuffix(msg, ` - - {"title":"event","description":"multi\nline"}`) {
+				t.Errorf("Wrong message: %q", msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("A message isn't received")
+		}
+	}
+}
+
+func TestCefEscaping(t *testing.T) {
+	got := formatCef("", "", map[string]string{"title": `a|b\c`, "url": "http://host/?a=b\nc"})
+	if !strings.HasPrefix(got, `CEF:0|Aqua Security|Postee|2|postee|a\|b\\c|0|rt=`) {
+		t.Errorf("Wrong header: %q", got)
+	}
+	if !strings.HasSuffix(got, ` msg=a|b\\c request=http://host/?a\=b\nc`) {
+		t.Errorf("Wrong extension: %q", got)
+	}
+}
+
+func TestSyslogHeader(t *testing.T) {
+	s := &SyslogOutput{Facility: "user"}
+	if err := s.Check(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.hostname = "host"
+	now := time.Date(2021, 11, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		route    string
+		expected string
+	}{
+		{"", "<14>1 2021-11-02T10:00:00.000Z host postee - - - "},
+		{"critical images", "<14>1 2021-11-02T10:00:00.000Z host postee - critical_images - "},
+		{"образы\talpine", "<14>1 2021-11-02T10:00:00.000Z host postee - _______alpine - "},
+		{strings.Repeat("r", 40), "<14>1 2021-11-02T10:00:00.000Z host postee - " + strings.Repeat("r", 29) + "... - "},
+	}
+	for _, test := range tests {
+		if got := s.header(test.route, "", now); got != test.expected {
+			t.Errorf("Wrong header of route %q, expected: %q, got: %q", test.route, test.expected, got)
+		}
+	}
+}
+
+func TestSyslogCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		output *SyslogOutput
+	}{
+		{"Unknown protocol", &SyslogOutput{Protocol: "http"}},
+		{"Unknown format", &SyslogOutput{Format: "gelf"}},
+		{"Unknown facility", &SyslogOutput{Facility: "local8"}},
+		{"Client cert without key", &SyslogOutput{Protocol: "tls", ClientCert: "cert.pem"}},
+		{"App name with a space", &SyslogOutput{AppName: "aqua postee"}},
+		{"Non-ASCII app name", &SyslogOutput{AppName: "postée"}},
+		{"Long app name", &SyslogOutput{AppName: strings.Repeat("a", 49)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.output.Check(); err == nil {
+				t.Error("An error is expected")
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package outputs
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 
@@ -32,7 +33,7 @@ func (teams *TeamsOutput) Init() error {
 	return nil
 }
 
-func (teams *TeamsOutput) Send(input map[string]string) error {
+func (teams *TeamsOutput) Send(ctx context.Context, input map[string]string) error {
 	log.Printf("Sending to MS Teams via %q...", teams.Name)
 	utils.Debug("Title for %q: %q\n", teams.Name, input["title"])
 	utils.Debug("Url(s) for %q: %q\n", teams.Name, input["url"])
@@ -56,7 +57,9 @@ func (teams *TeamsOutput) Send(input map[string]string) error {
 		return err
 	}
 
-	err = msteams.CreateMessageByWebhook(teams.Webhook, teams.teamsLayout.TitleH2(input["title"])+escaped)
+	err = throttled(ctx, func() error {
+		return msteams.CreateMessageByWebhook(ctx, teams.Webhook, teams.teamsLayout.TitleH2(input["title"])+escaped)
+	})
 
 	if err != nil {
 		log.Printf("TeamsOutput Send Error: %v", err)
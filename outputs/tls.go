@@ -0,0 +1,39 @@
+package outputs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+func checkTlsFiles(clientCert, clientKey string) error {
+	if (clientCert == "") != (clientKey == "") {
+		return errors.New("'client-cert' and 'client-key' should be set together")
+	}
+	return nil
+}
+
+// loadTlsConfig returns TLS settings with CA certificates of servers, system ones by default, and a client certificate
+func loadTlsConfig(caCert, clientCert, clientKey string) (*tls.Config, error) {
+	config := &tls.Config{}
+	if caCert != "" {
+		pem, err := ioutil.ReadFile(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("can't read 'ca-cert': %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("'ca-cert'(%q) has no PEM certificates", caCert)
+		}
+	}
+	if clientCert != "" {
+		cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("can't load 'client-cert': %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
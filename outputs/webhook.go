@@ -1,7 +1,7 @@
 package outputs
 
 import (
-	"fmt"
+	"context"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -9,6 +9,7 @@ import (
 
 	"github.com/aquasecurity/postee/v2/formatting"
 	"github.com/aquasecurity/postee/v2/layout"
+	"github.com/aquasecurity/postee/v2/utils"
 )
 
 type WebhookOutput struct {
@@ -26,26 +27,37 @@ func (webhook *WebhookOutput) Init() error {
 	return nil
 }
 
-func (webhook *WebhookOutput) Send(content map[string]string) error {
+func (webhook *WebhookOutput) Send(ctx context.Context, content map[string]string) error {
 	log.Printf("Sending webhook to %q", webhook.Url)
 	data := content["description"] //it's not supposed to work with legacy renderer
-	resp, err := http.Post(webhook.Url, "application/json", strings.NewReader(data))
-	if err != nil {
-		log.Printf("Sending webhook Error: %v", err)
-		return err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	err := throttled(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, "POST", webhook.Url, strings.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Printf("Sending webhook Error: %v", err)
+			return err
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			log.Printf("Sending %q Error: %v", webhook.Name, err)
+			return err
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			msg := "Sending webhook wrong status: %q. Body: %s"
+			log.Printf(msg, resp.StatusCode, body)
+			return utils.NewHttpError(resp.StatusCode, msg, resp.StatusCode, body).WithRetryAfter(resp.Header)
+		}
+		return nil
+	})
 	if err != nil {
-		log.Printf("Sending %q Error: %v", webhook.Name, err)
 		return err
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		msg := "Sending webhook wrong status: %q. Body: %s"
-		log.Printf(msg, resp.StatusCode, body)
-		return fmt.Errorf(msg, resp.StatusCode, body)
-	}
 	log.Printf("Sending Webhook to %q was successful!", webhook.Name)
 	return nil
 }
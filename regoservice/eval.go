@@ -5,9 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 
 	"github.com/aquasecurity/postee/v2/data"
+	"github.com/aquasecurity/postee/v2/logging"
 	"github.com/open-policy-agent/opa/rego"
 )
 
@@ -17,6 +17,9 @@ const (
 	aggregation_pkg_prop = "aggregation_pkg"
 )
 
+// optionalProps are passed to outputs when a template defines them, e.g. the severity of a PagerDuty incident
+var optionalProps = []string{"severity", "priority", "event_action"}
+
 var (
 	buildinRegoTemplates = []string{"./rego-templates"}
 	commonRegoTemplates  = []string{"./rego-templates/common"}
@@ -32,8 +35,7 @@ func (regoEvaluator *regoEvaluator) IsAggregationSupported() bool {
 	return regoEvaluator.aggrQuery != nil
 }
 
-func (regoEvaluator *regoEvaluator) Eval(in map[string]interface{}, serverUrl string) (map[string]string, error) {
-	ctx := context.Background()
+func (regoEvaluator *regoEvaluator) Eval(ctx context.Context, in map[string]interface{}, serverUrl string) (map[string]string, error) {
 	rs, err := regoEvaluator.prepQuery.Eval(ctx, rego.EvalInput(in))
 
 	if err != nil {
@@ -67,17 +69,22 @@ func (regoEvaluator *regoEvaluator) Eval(in map[string]interface{}, serverUrl st
 		return nil, err
 	}
 
-	return map[string]string{
+	content := map[string]string{
 		"title":       title,
 		"description": description,
 		"url":         serverUrl,
-	}, nil
-
+	}
+	for _, prop := range optionalProps {
+		if v, ok := data[prop].(string); ok && v != "" {
+			content[prop] = v
+		}
+	}
+	return content, nil
 }
 
 func getFirstElement(context map[string]interface{}, key string) interface{} {
 	for _, v := range context {
-		log.Printf("checking: %s ...\n", key)
+		logging.Debugf("checking: %s ...", key)
 		childCtx, ok := v.(map[string]interface{})
 		if !ok {
 			return nil
@@ -222,7 +229,7 @@ func buildAggregatedRego(query *rego.PreparedEvalQuery) (*rego.PreparedEvalQuery
 		}
 	} else {
 		//it's ok skip aggregation package - no aggregation features will be available
-		log.Printf("No aggregation package configured!!!")
+		logging.Infof("No aggregation package configured!!!")
 	}
 	return aggrQuery, nil
 }
@@ -1,6 +1,7 @@
 package regoservice
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"log"
@@ -161,7 +162,7 @@ func evaluateBuildinRego(t *testing.T, caseDesc string, regoRule *string, input
 	if demo.IsAggregationSupported() {
 		t.Errorf("[%s] rule shouldn't support aggregation", caseDesc)
 	}
-	r, err := demo.Eval(parseJson(input), "")
+	r, err := demo.Eval(context.Background(), parseJson(input), "")
 	if err != nil && !shouldEvalFail {
 		t.Errorf("[%s] unexpected error received while evaluating query: %v\n", caseDesc, err)
 	}
@@ -207,7 +208,7 @@ func evaluateExternalRego(t *testing.T, caseDesc string, regoRule *string, input
 	if demo.IsAggregationSupported() {
 		t.Errorf("[%s] rule shouldn't support aggregation", caseDesc)
 	}
-	r, err := demo.Eval(parseJson(input), "")
+	r, err := demo.Eval(context.Background(), parseJson(input), "")
 	if err != nil && !shouldEvalFail {
 		t.Errorf("[%s] unexpected error received while evaluating query: %v\n", caseDesc, err)
 	}
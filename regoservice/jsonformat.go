@@ -2,8 +2,8 @@ package regoservice
 
 import (
 	"encoding/json"
-	"log"
 
+	"github.com/aquasecurity/postee/v2/logging"
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/rego"
 	"github.com/open-policy-agent/opa/types"
@@ -21,13 +21,13 @@ func jsonFmtFunc() func(r *rego.Rego) {
 			err := ast.As(a.Value, &obj)
 			if err != nil {
 				//Rego doesn't show errors
-				log.Printf("Can't convert OPA object: %v\n", err)
+				logging.Errorf("Can't convert OPA object: %v", err)
 				return nil, err
 			}
 			b, err := json.MarshalIndent(obj, "", " ")
 			if err != nil {
 				//Rego doesn't show errors
-				log.Printf("Error while json format: %v\n", err)
+				logging.Errorf("Error while json format: %v", err)
 				return nil, err
 			}
 			return ast.StringTerm(string(b)), nil
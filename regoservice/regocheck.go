@@ -53,6 +53,24 @@ func buildRegoLoader(files []string, rule string) func(r *rego.Rego) {
 func IsUsedRegoFiles(files []string) bool {
 	return len(files) != 0 && files[0] != ""
 }
+
+func prepareRegoCriteria(ctx context.Context, files []string, rule string) (rego.PreparedEvalQuery, error) {
+	r := rego.New(
+		rego.Query("x = data.postee.allow"),
+		buildRegoLoader(files, rule),
+	)
+	return r.PrepareForEval(ctx)
+}
+
+// CompileRegoCriteria checks that criteria of a route can be evaluated
+func CompileRegoCriteria(files []string, rule string) error {
+	if !IsUsedRegoFiles(files) && rule == "" {
+		return nil
+	}
+	_, err := prepareRegoCriteria(context.Background(), files, rule)
+	return err
+}
+
 func DoesMatchRegoCriteria(input interface{}, files []string, rule string) (bool, error) {
 	if !IsUsedRegoFiles(files) && rule == "" {
 		return true, nil
@@ -60,12 +78,7 @@ func DoesMatchRegoCriteria(input interface{}, files []string, rule string) (bool
 
 	ctx := context.Background()
 
-	r := rego.New(
-		rego.Query("x = data.postee.allow"),
-		buildRegoLoader(files, rule),
-	)
-
-	query, err := r.PrepareForEval(ctx)
+	query, err := prepareRegoCriteria(ctx, files, rule)
 	if err != nil {
 		return false, err
 	}
@@ -1,6 +1,16 @@
 package router
 
-import "testing"
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aquasecurity/postee/v2/secrets"
+)
 
 func TestAnonymizeSettings(t *testing.T) {
 	tests := []struct {
@@ -53,3 +63,82 @@ func TestAnonymizeSettings(t *testing.T) {
 		}
 	}
 }
+
+func TestAnonymizeHeaders(t *testing.T) {
+	original := &OutputSettings{
+		Headers: map[string]string{"Authorization": "Basic YWRtaW46c2VjcmV0", "X-Api-Key": "key"},
+	}
+	anonymized := anonymizeSettings(original)
+	for name, value := range anonymized.Headers {
+		if value != AnonymizeReplacement {
+			t.Errorf("Header %q isn't hidden: %q", name, value)
+		}
+	}
+	if len(anonymized.Headers) != 2 {
+		t.Errorf("Wrong number of headers: %v", anonymized.Headers)
+	}
+	if original.Headers["X-Api-Key"] != "key" {
+		t.Errorf("Original headers are changed: %v", original.Headers)
+	}
+}
+
+func TestAnonymizeResolvedSecrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "postee-secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "jira.yaml"), []byte("token: jira-s3cr3t\nfield: field-s3cr3t\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "password"), []byte("file-s3cr3t\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	secrets.Register("local", &secrets.FileProvider{Root: dir})
+	defer secrets.Register("local", &secrets.FileProvider{})
+	os.Setenv("POSTEE_TEST_LABEL", "label-public")
+	defer os.Unsetenv("POSTEE_TEST_LABEL")
+	defer secrets.Track("test.yaml", nil)
+
+	load := func(settings OutputSettings) *OutputSettings {
+		tenant := &TenantSettings{Outputs: []OutputSettings{settings}}
+		resolver := secrets.NewResolver(context.Background())
+		if errs := interpolate(tenant, resolver); len(errs) > 0 {
+			t.Fatalf("Unexpected errors: %v", errs)
+		}
+		resolver.Apply("test.yaml")
+		return &tenant.Outputs[0]
+	}
+	settings := load(OutputSettings{
+		Name:       "my-jira",
+		Type:       "jira",
+		Enable:     true,
+		Token:      "local://jira.yaml#token",
+		Password:   "file:" + filepath.Join(dir, "password"),
+		ProjectKey: "PRJ",
+		Labels:     []string{"public", "${POSTEE_TEST_LABEL}"},
+		Unknowns:   map[string]string{"customfield_1": "local://jira.yaml#field"},
+	})
+	if settings.Token != "jira-s3cr3t" || settings.Unknowns["customfield_1"] != "field-s3cr3t" || settings.Password != "file-s3cr3t" {
+		t.Fatalf("Secrets aren't resolved: %#v", settings)
+	}
+
+	logged := fmt.Sprintf("%#v", anonymizeSettings(settings))
+	for _, secret := range []string{"jira-s3cr3t", "field-s3cr3t", "file-s3cr3t"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("Resolved secret %q is in anonymized settings: %s", secret, logged)
+		}
+	}
+	if !strings.Contains(logged, "PRJ") || !strings.Contains(logged, "public") || !strings.Contains(logged, "label-public") {
+		t.Errorf("Options without secrets should be kept: %s", logged)
+	}
+	if settings.Labels[1] != "label-public" || settings.Unknowns["customfield_1"] != "field-s3cr3t" {
+		t.Errorf("Original settings are changed by anonymization: %#v", settings)
+	}
+
+	//the next load doesn't use the secret, so it isn't hidden anymore
+	settings = load(OutputSettings{Name: "my-jira", Type: "jira", Enable: true, Labels: []string{"jira-s3cr3t"}})
+	if logged := fmt.Sprintf("%#v", anonymizeSettings(settings)); !strings.Contains(logged, "jira-s3cr3t") {
+		t.Errorf("Secret of the previous load is hidden: %s", logged)
+	}
+}
@@ -1,14 +1,27 @@
 package router
 
-import "reflect"
+import (
+	"reflect"
 
+	"github.com/aquasecurity/postee/v2/secrets"
+)
+
+// anonymizeSettings returns a copy of settings for logs. Credentials and URLs are hidden,
+// as well as any option which contains a value resolved from an environment variable, a file or a secret provider.
 func anonymizeSettings(settings *OutputSettings) *OutputSettings {
 	fieldsToAnonymize := [...]string{
 		"User",
 		"Password",
+		"Token",
+		"RoutingKey",
+		"ClientSecret",
 		"Url",
 		"InstanceName",
 	}
+	//values of maps such as HTTP headers are often credentials, e.g. Authorization or X-Api-Key
+	mapsToAnonymize := map[string]bool{
+		"Headers": true,
+	}
 	copyToAnonymize := *settings
 
 	for _, key := range fieldsToAnonymize {
@@ -22,5 +35,42 @@ func anonymizeSettings(settings *OutputSettings) *OutputSettings {
 		}
 	}
 
+	v := reflect.ValueOf(&copyToAnonymize).Elem()
+	for _, f := range optionFields(v.Type()) {
+		field := v.FieldByName(f.name)
+		switch f.typ.Kind() {
+		case reflect.String:
+			if secrets.Contains(field.String()) {
+				field.SetString(AnonymizeReplacement)
+			}
+		case reflect.Slice:
+			if f.typ.Elem().Kind() != reflect.String || field.IsNil() {
+				continue
+			}
+			//the copy shares lists with the original settings
+			hidden := reflect.MakeSlice(f.typ, field.Len(), field.Len())
+			for i := 0; i < field.Len(); i++ {
+				hidden.Index(i).Set(field.Index(i))
+				if secrets.Contains(field.Index(i).String()) {
+					hidden.Index(i).SetString(AnonymizeReplacement)
+				}
+			}
+			field.Set(hidden)
+		case reflect.Map:
+			if f.typ.Elem().Kind() != reflect.String || field.IsNil() {
+				continue
+			}
+			hidden := reflect.MakeMapWithSize(f.typ, field.Len())
+			for _, key := range field.MapKeys() {
+				value := field.MapIndex(key)
+				if mapsToAnonymize[f.name] || secrets.Contains(value.String()) {
+					value = reflect.ValueOf(AnonymizeReplacement).Convert(f.typ.Elem())
+				}
+				hidden.SetMapIndex(key, value)
+			}
+			field.Set(hidden)
+		}
+	}
+
 	return &copyToAnonymize
 }
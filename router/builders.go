@@ -1,11 +1,110 @@
 package router
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/aquasecurity/postee/v2/delivery"
+	"github.com/aquasecurity/postee/v2/logging"
 	"github.com/aquasecurity/postee/v2/outputs"
 )
 
+func buildRetryPolicy(sourceSettings *OutputSettings) delivery.RetryPolicy {
+	policy := delivery.DefaultRetryPolicy()
+	if sourceSettings.RetryAttempts > 0 {
+		policy.MaxAttempts = sourceSettings.RetryAttempts
+	}
+	if backoff := parseDuration(sourceSettings.Name, "retry-backoff", sourceSettings.RetryBackoff); backoff > 0 {
+		policy.InitialBackoff = backoff
+	}
+	if maxBackoff := parseDuration(sourceSettings.Name, "retry-max-backoff", sourceSettings.RetryMaxBackoff); maxBackoff > 0 {
+		policy.MaxBackoff = maxBackoff
+	}
+	if policy.MaxBackoff < policy.InitialBackoff {
+		policy.MaxBackoff = policy.InitialBackoff
+	}
+	return policy
+}
+
+func buildTimeout(sourceSettings *OutputSettings) time.Duration {
+	if timeout := parseDuration(sourceSettings.Name, "timeout", sourceSettings.Timeout); timeout > 0 {
+		return timeout
+	}
+	return delivery.DefaultTimeout
+}
+
+// buildBreakerThreshold returns the number of consecutive failures which open the circuit of an output.
+// A negative value turns the breaker off.
+func buildBreakerThreshold(sourceSettings *OutputSettings) int {
+	switch {
+	case sourceSettings.BreakerFailures < 0:
+		return 0
+	case sourceSettings.BreakerFailures == 0:
+		return delivery.DefaultBreakerThreshold
+	}
+	return sourceSettings.BreakerFailures
+}
+
+// buildOrderingKey returns a key of sends which have to be made one at a time.
+// Jira issues are always created in order within a project. Order is best effort, see delivery.Dispatcher.
+func buildOrderingKey(sourceSettings *OutputSettings) string {
+	switch {
+	case sourceSettings.Type == "jira":
+		return fmt.Sprintf("jira:%s#%s", sourceSettings.Url, sourceSettings.ProjectKey)
+	case sourceSettings.Ordered:
+		return "output:" + sourceSettings.Name
+	}
+	return ""
+}
+
+// buildRateLimiter parses rate-limit option, which is a number of requests per a period, e.g. "1/s" or "100/5m".
+// Outputs without the option get a limiter without a rate, which only honours Retry-After.
+func buildRateLimiter(sourceSettings *OutputSettings) *outputs.RateLimiter {
+	if sourceSettings.RateLimit == "" {
+		return outputs.NewRateLimiter(0, 0, 0)
+	}
+	events, per, err := parseRate(sourceSettings.RateLimit)
+	if err != nil {
+		logging.Warnf("%q settings: Can't convert 'rate-limit'(%q), the rate isn't limited: %v", sourceSettings.Name, sourceSettings.RateLimit, err)
+		return outputs.NewRateLimiter(0, 0, 0)
+	}
+	return outputs.NewRateLimiter(events, per, sourceSettings.RateBurst)
+}
+
+func parseRate(value string) (int, time.Duration, error) {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("'<requests>/<period>' is expected")
+	}
+	events, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || events <= 0 {
+		return 0, 0, fmt.Errorf("number of requests should be a positive integer")
+	}
+	period := strings.TrimSpace(parts[1])
+	if period != "" && (period[0] < '0' || period[0] > '9') {
+		period = "1" + period //"s" means "1s"
+	}
+	per, err := time.ParseDuration(period)
+	if err != nil || per <= 0 {
+		return 0, 0, fmt.Errorf("period should be a positive duration")
+	}
+	return events, per, nil
+}
+
+func parseDuration(outputName, option, value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		logging.Warnf("%q settings: Can't convert '%s'(%q) to duration, the default value is used.", outputName, option, value)
+		return 0
+	}
+	return d
+}
+
 func buildStdoutOutput(sourceSettings *OutputSettings) *outputs.StdoutOutput {
 	return &outputs.StdoutOutput{Name: sourceSettings.Name}
 }
@@ -26,6 +125,88 @@ func buildWebhookOutput(sourceSettings *OutputSettings) *outputs.WebhookOutput {
 	}
 }
 
+func buildPagerDutyOutput(sourceSettings *OutputSettings) *outputs.PagerDutyOutput {
+	return &outputs.PagerDutyOutput{
+		Name:          sourceSettings.Name,
+		Url:           sourceSettings.Url,
+		RoutingKey:    sourceSettings.RoutingKey,
+		DedupKeyProps: sourceSettings.DedupKeyProps,
+		SeverityMap:   sourceSettings.SeverityMap,
+		AutoResolve:   sourceSettings.AutoResolve,
+	}
+}
+
+func buildOpsgenieOutput(sourceSettings *OutputSettings) *outputs.OpsgenieOutput {
+	return &outputs.OpsgenieOutput{
+		Name:          sourceSettings.Name,
+		Url:           sourceSettings.Url,
+		ApiKey:        sourceSettings.Token,
+		Priority:      sourceSettings.Priority,
+		PriorityMap:   sourceSettings.SeverityMap,
+		Tags:          sourceSettings.Tags,
+		Responders:    sourceSettings.Responders,
+		DedupKeyProps: sourceSettings.DedupKeyProps,
+		AutoClose:     sourceSettings.AutoResolve,
+	}
+}
+
+func buildHttpOutput(sourceSettings *OutputSettings) *outputs.HttpOutput {
+	return &outputs.HttpOutput{
+		Name:           sourceSettings.Name,
+		Method:         sourceSettings.Method,
+		Url:            sourceSettings.Url,
+		Headers:        sourceSettings.Headers,
+		User:           sourceSettings.User,
+		Password:       sourceSettings.Password,
+		Token:          sourceSettings.Token,
+		TokenUrl:       sourceSettings.TokenUrl,
+		ClientId:       sourceSettings.ClientId,
+		ClientSecret:   sourceSettings.ClientSecret,
+		Scopes:         sourceSettings.Scopes,
+		CaCert:         sourceSettings.CaCert,
+		ClientCert:     sourceSettings.ClientCert,
+		ClientKey:      sourceSettings.ClientKey,
+		AcceptedStatus: sourceSettings.AcceptedStatus,
+		Body:           sourceSettings.Body,
+	}
+}
+
+func buildKafkaOutput(sourceSettings *OutputSettings) *outputs.KafkaOutput {
+	return &outputs.KafkaOutput{
+		Name:          sourceSettings.Name,
+		Brokers:       sourceSettings.Brokers,
+		Topic:         sourceSettings.Topic,
+		KeyProps:      sourceSettings.KeyProps,
+		Body:          sourceSettings.Body,
+		Acks:          sourceSettings.Acks,
+		BatchSize:     sourceSettings.BatchSize,
+		BatchTimeout:  parseDuration(sourceSettings.Name, "batch-timeout", sourceSettings.BatchTimeout),
+		SaslMechanism: sourceSettings.SaslMechanism,
+		User:          sourceSettings.User,
+		Password:      sourceSettings.Password,
+		Tls:           sourceSettings.Tls,
+		CaCert:        sourceSettings.CaCert,
+		ClientCert:    sourceSettings.ClientCert,
+		ClientKey:     sourceSettings.ClientKey,
+	}
+}
+
+func buildSyslogOutput(sourceSettings *OutputSettings) *outputs.SyslogOutput {
+	return &outputs.SyslogOutput{
+		Name:       sourceSettings.Name,
+		Host:       sourceSettings.Host,
+		Port:       sourceSettings.Port,
+		Protocol:   sourceSettings.Protocol,
+		Format:     sourceSettings.Format,
+		Facility:   sourceSettings.Facility,
+		AppName:    sourceSettings.AppName,
+		Body:       sourceSettings.Body,
+		CaCert:     sourceSettings.CaCert,
+		ClientCert: sourceSettings.ClientCert,
+		ClientKey:  sourceSettings.ClientKey,
+	}
+}
+
 func buildTeamsOutput(sourceSettings *OutputSettings, aquaServer string) *outputs.TeamsOutput {
 	return &outputs.TeamsOutput{
 		Name:       sourceSettings.Name,
@@ -0,0 +1,31 @@
+package router
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		value          string
+		expectedEvents int
+		expectedPer    time.Duration
+		expectedError  bool
+	}{
+		{"1/s", 1, time.Second, false},
+		{"20/m", 20, time.Minute, false},
+		{"100 / 5m", 100, 5 * time.Minute, false},
+		{"10", 0, 0, true},
+		{"0/s", 0, 0, true},
+		{"1/day", 0, 0, true},
+	}
+	for _, test := range tests {
+		events, per, err := parseRate(test.value)
+		if (err != nil) != test.expectedError {
+			t.Errorf("[%s] Unexpected error: %v", test.value, err)
+		}
+		if events != test.expectedEvents || per != test.expectedPer {
+			t.Errorf("[%s] Wrong rate, expected %d/%s, got %d/%s", test.value, test.expectedEvents, test.expectedPer, events, per)
+		}
+	}
+}
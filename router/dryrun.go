@@ -0,0 +1,137 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aquasecurity/postee/v2/data"
+	"github.com/aquasecurity/postee/v2/regoservice"
+	"github.com/aquasecurity/postee/v2/routes"
+)
+
+// DryRunOutput is a message which would be sent to an output
+type DryRunOutput struct {
+	Output  string            `json:"output"`
+	Type    string            `json:"type"`
+	Note    string            `json:"note,omitempty"` //why the output wouldn't get the message
+	Content map[string]string `json:"content,omitempty"`
+}
+
+// DryRunResult shows how a route would handle a message
+type DryRunResult struct {
+	Route    string         `json:"route"`
+	Matched  bool           `json:"matched"`
+	Reason   string         `json:"reason,omitempty"` //why the route missed the message or couldn't render it
+	Template string         `json:"template,omitempty"`
+	Note     string         `json:"note,omitempty"`
+	Outputs  []DryRunOutput `json:"outputs,omitempty"`
+}
+
+// DryRun routes a message by a configuration and renders it by templates of matched routes.
+// Outputs aren't built or called, and the database isn't used, so duplicates aren't detected and messages aren't aggregated.
+// All routes are evaluated if route name is empty. Secret providers aren't called, as outputs aren't built.
+func DryRun(c context.Context, tenant *TenantSettings, input []byte, route string) ([]DryRunResult, error) {
+	if errs := InterpolateLocal(tenant); len(errs) > 0 {
+		return nil, joinErrors(errs)
+	}
+	in := map[string]interface{}{}
+	if err := json.Unmarshal(input, &in); err != nil {
+		return nil, fmt.Errorf("unable to parse the input: %w", err)
+	}
+
+	var selected []*routes.InputRoute
+	for i := range tenant.InputRoutes {
+		if route == "" || tenant.InputRoutes[i].Name == route {
+			selected = append(selected, routes.ConfigureTimeouts(&tenant.InputRoutes[i]))
+		}
+	}
+	if len(selected) == 0 {
+		return nil, ErrUnknownRoute
+	}
+
+	outputSettings := make(map[string]*OutputSettings)
+	for i := range tenant.Outputs {
+		outputSettings[tenant.Outputs[i].Name] = &tenant.Outputs[i]
+	}
+	templates := make(map[string]*Template)
+	for i := range tenant.Templates {
+		templates[tenant.Templates[i].Name] = &tenant.Templates[i]
+	}
+	aquaServer := buildAquaServerUrl(tenant.AquaServer)
+	in["postee"] = map[string]string{"AquaServer": aquaServer}
+
+	evaluators := make(map[string]data.Inpteval)
+	results := make([]DryRunResult, 0, len(selected))
+	for _, r := range selected {
+		results = append(results, DryRunResult{Route: r.Name, Template: r.Template})
+		res := &results[len(results)-1]
+
+		ok, err := regoservice.DoesMatchRegoCriteria(in, r.InputFiles, r.Input)
+		if err != nil {
+			res.Reason = fmt.Sprintf("error while evaluating rego rule: %v", err)
+			continue
+		}
+		if !ok {
+			if regoservice.IsUsedRegoFiles(r.InputFiles) {
+				res.Reason = fmt.Sprintf("input doesn't match rego rules of input files %v", r.InputFiles)
+			} else {
+				res.Reason = fmt.Sprintf("input doesn't match rego rule: %s", r.Input)
+			}
+			continue
+		}
+		res.Matched = true
+
+		inpteval, ok := evaluators[r.Template]
+		if !ok {
+			t, defined := templates[r.Template]
+			if !defined {
+				res.Reason = fmt.Sprintf("template %q isn't defined", r.Template)
+				continue
+			}
+			inpteval, err = buildTemplate(t)
+			if err != nil {
+				res.Reason = fmt.Sprintf("template %q can't be built: %v", r.Template, err)
+				continue
+			}
+			if inpteval == nil {
+				res.Reason = fmt.Sprintf("template %q has no 'body', 'rego-package', 'legacy-scan-renderer' or 'url'", r.Template)
+				continue
+			}
+			evaluators[r.Template] = inpteval
+		}
+		content, err := inpteval.Eval(c, in, aquaServer)
+		if err != nil {
+			res.Reason = fmt.Sprintf("error while evaluating template %q: %v", r.Template, err)
+			continue
+		}
+
+		switch {
+		case !inpteval.IsAggregationSupported():
+		case r.Plugins.AggregateMessageNumber > 0:
+			res.Note = fmt.Sprintf("messages would be aggregated by %d", r.Plugins.AggregateMessageNumber)
+		case r.Plugins.AggregateTimeoutSeconds > 0:
+			res.Note = fmt.Sprintf("messages would be aggregated for %ds", r.Plugins.AggregateTimeoutSeconds)
+		}
+		if len(r.Outputs) == 0 {
+			res.Reason = "route has no outputs"
+			continue
+		}
+		for _, name := range r.Outputs {
+			o := DryRunOutput{Output: name}
+			settings, defined := outputSettings[name]
+			switch {
+			case !defined:
+				o.Note = "output isn't defined"
+			case !settings.Enable:
+				o.Type = settings.Type
+				o.Note = "output isn't enabled"
+			default:
+				o.Type = settings.Type
+				o.Content = content
+			}
+			res.Outputs = append(res.Outputs, o)
+		}
+	}
+	return results, nil
+}
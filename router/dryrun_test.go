@@ -0,0 +1,65 @@
+package router
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aquasecurity/postee/v2/routes"
+)
+
+func TestDryRun(t *testing.T) {
+	tenant := &TenantSettings{
+		InputRoutes: []routes.InputRoute{
+			{Name: "alpine", Input: `contains(input.image, "alpine")`, Outputs: []string{"my-slack", "my-jira", "my-teams"}, Template: "legacy"},
+			{Name: "ubuntu", Input: `contains(input.image, "ubuntu")`, Outputs: []string{"my-slack"}, Template: "legacy"},
+			{Name: "broken-template", Outputs: []string{"my-slack"}, Template: "raw"},
+		},
+		Templates: []Template{{Name: "legacy", LegacyScanRenderer: "slack"}},
+		Outputs: []OutputSettings{
+			{Name: "my-slack", Type: "slack", Enable: true, Password: "vault://unreachable/postee#password"},
+			{Name: "my-jira", Type: "jira"},
+		},
+	}
+	input := []byte(`{"image":"alpine:3.12","registry":"Docker Hub","vulnerability_summary":{"critical":3}}`)
+
+	results, err := DryRun(context.Background(), tenant, input, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Unexpected number of results. Expected 3, got %d", len(results))
+	}
+
+	alpine := results[0]
+	if !alpine.Matched || alpine.Reason != "" || len(alpine.Outputs) != 3 {
+		t.Fatalf("Route alpine should match and render the message: %+v", alpine)
+	}
+	if !strings.Contains(alpine.Outputs[0].Content["title"], "alpine:3.12") {
+		t.Errorf("Unexpected title for my-slack: %q", alpine.Outputs[0].Content["title"])
+	}
+	if alpine.Outputs[1].Note != "output isn't enabled" || alpine.Outputs[1].Content != nil {
+		t.Errorf("Disabled output my-jira shouldn't get the message: %+v", alpine.Outputs[1])
+	}
+	if alpine.Outputs[2].Note != "output isn't defined" {
+		t.Errorf("Undefined output my-teams shouldn't get the message: %+v", alpine.Outputs[2])
+	}
+
+	if ubuntu := results[1]; ubuntu.Matched || !strings.Contains(ubuntu.Reason, `contains(input.image, "ubuntu")`) {
+		t.Errorf("Route ubuntu should miss the message with the rule as a reason: %+v", ubuntu)
+	}
+	if broken := results[2]; !broken.Matched || broken.Reason != `template "raw" isn't defined` {
+		t.Errorf("Route broken-template should report the undefined template: %+v", broken)
+	}
+
+	results, err = DryRun(context.Background(), tenant, input, "ubuntu")
+	if err != nil || len(results) != 1 || results[0].Route != "ubuntu" {
+		t.Errorf("Only route ubuntu should be evaluated: %+v, %v", results, err)
+	}
+	if _, err := DryRun(context.Background(), tenant, input, "debian"); err != ErrUnknownRoute {
+		t.Errorf("Unexpected error for unknown route: %v", err)
+	}
+	if _, err := DryRun(context.Background(), tenant, []byte("not a json"), ""); err == nil {
+		t.Error("Error is expected for invalid input")
+	}
+}
@@ -0,0 +1,130 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	CredentialHmac       = "hmac"
+	CredentialBearer     = "bearer"
+	CredentialClientCert = "client-cert"
+
+	SignatureHeaderDefault = "X-Postee-Signature"
+	TimestampHeader        = "X-Postee-Timestamp"
+	SignatureAlgorithm     = "sha256"
+	SignatureTolerance     = 5 * time.Minute
+)
+
+// signatureAlgorithms are hash functions which can sign messages
+var signatureAlgorithms = map[string]bool{"sha1": true, "sha256": true, "sha512": true}
+
+// IngestCredential authenticates senders of messages. If a configuration has credentials, messages
+// without any of them are rejected. A credential may trigger only its routes, or all routes if it has none.
+type IngestCredential struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Secret    string   `json:"secret,omitempty"`
+	Header    string   `json:"header,omitempty"`
+	Algorithm string   `json:"algorithm,omitempty"`
+	Tolerance string   `json:"tolerance,omitempty"`
+	Token     string   `json:"token,omitempty"`
+	Subject   string   `json:"subject,omitempty"`
+	Routes    []string `json:"routes,omitempty"`
+}
+
+// SignatureHeader returns the header with HMAC signature of a message
+func (c *IngestCredential) SignatureHeader() string {
+	if c.Header == "" {
+		return SignatureHeaderDefault
+	}
+	return c.Header
+}
+
+// SignatureAlgorithm returns the hash function of HMAC signature
+func (c *IngestCredential) SignatureAlgorithm() string {
+	if c.Algorithm == "" {
+		return SignatureAlgorithm
+	}
+	return c.Algorithm
+}
+
+// SignatureTolerance returns how old a signed message may be, signatures of older messages are rejected as replays
+func (c *IngestCredential) SignatureTolerance() time.Duration {
+	if tolerance, err := time.ParseDuration(c.Tolerance); err == nil && tolerance > 0 {
+		return tolerance
+	}
+	return SignatureTolerance
+}
+
+// Allows reports whether the credential may trigger a route
+func (c *IngestCredential) Allows(route string) bool {
+	if len(c.Routes) == 0 {
+		return true
+	}
+	for _, r := range c.Routes {
+		if r == route {
+			return true
+		}
+	}
+	return false
+}
+
+// IngestCredentials returns the credentials of the running configuration, none if ingest isn't authenticated
+func (ctx *Router) IngestCredentials() []IngestCredential {
+	ctx.mutexScan.Lock()
+	defer ctx.mutexScan.Unlock()
+	return ctx.ingestAuth
+}
+
+func checkCredential(c *IngestCredential, definedRoutes map[string]bool) []error {
+	errs := make([]error, 0)
+	switch c.Type {
+	case CredentialHmac:
+		if c.Secret == "" {
+			errs = append(errs, fmt.Errorf("'secret' is required"))
+		}
+		if !signatureAlgorithms[c.SignatureAlgorithm()] {
+			errs = append(errs, fmt.Errorf("unknown algorithm %q, it should be sha1, sha256 or sha512", c.Algorithm))
+		}
+		if c.Tolerance != "" {
+			if tolerance, err := time.ParseDuration(c.Tolerance); err != nil || tolerance <= 0 {
+				errs = append(errs, fmt.Errorf("'tolerance'(%q) should be a positive duration, e.g. 5m", c.Tolerance))
+			}
+		}
+	case CredentialBearer:
+		if c.Token == "" {
+			errs = append(errs, fmt.Errorf("'token' is required"))
+		}
+	case CredentialClientCert:
+	default:
+		errs = append(errs, fmt.Errorf("type %q is undefined or empty, it should be hmac, bearer or client-cert", c.Type))
+	}
+	for _, route := range c.Routes {
+		if !definedRoutes[route] {
+			errs = append(errs, fmt.Errorf("route %q isn't defined", route))
+		}
+	}
+	return errs
+}
+
+type allowedRoutesCtxKey struct{}
+
+// WithAllowedRoutes limits routes which handle messages sent with the context
+func WithAllowedRoutes(c context.Context, routes []string) context.Context {
+	allowed := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		allowed[r] = true
+	}
+	return context.WithValue(c, allowedRoutesCtxKey{}, allowed)
+}
+
+// isAllowedRoute reports whether a route may handle messages sent with the context
+func isAllowedRoute(c context.Context, route string) bool {
+	if c == nil {
+		return true
+	}
+	allowed, ok := c.Value(allowedRoutesCtxKey{}).(map[string]bool)
+	return !ok || allowed[route]
+}
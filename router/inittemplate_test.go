@@ -105,8 +105,7 @@ func TestInitTemplate(t *testing.T) {
 
 }
 func doInitTemplate(t *testing.T, caseDesc string, template *Template, expectedCls string, shouldReturnError bool) {
-	demoCtx := Instance()
-	err := demoCtx.initTemplate(template)
+	initialized, err := buildTemplate(template)
 	if err != nil && !shouldReturnError {
 		t.Fatalf("[%s] Unexpected error: %v", caseDesc, err)
 	}
@@ -117,8 +116,7 @@ func doInitTemplate(t *testing.T, caseDesc string, template *Template, expectedC
 		return
 	}
 
-	initialized, ok := demoCtx.templates[template.Name]
-	if !ok {
+	if initialized == nil {
 		t.Fatalf("[%s] template %s is not initialized", caseDesc, template.Name)
 	}
 	actualCls := fmt.Sprintf("%T", initialized)
@@ -27,4 +27,43 @@ type OutputSettings struct {
 	UseMX           bool              `json:"use-mx,omitempty"`
 	InstanceName    string            `json:"instance,omitempty"`
 	SizeLimit       int               `json:"size-limit,omitempty"`
+	RetryAttempts   int               `json:"retry-max-attempts,omitempty"`
+	RetryBackoff    string            `json:"retry-backoff,omitempty"`
+	RetryMaxBackoff string            `json:"retry-max-backoff,omitempty"`
+	Timeout         string            `json:"timeout,omitempty"`
+	MaxConcurrency  int               `json:"max-concurrency,omitempty"`
+	Ordered         bool              `json:"ordered,omitempty"`
+	RateLimit       string            `json:"rate-limit,omitempty"`
+	RateBurst       int               `json:"rate-burst,omitempty"`
+	BreakerFailures int               `json:"circuit-breaker-failures,omitempty"`
+	BreakerCooldown string            `json:"circuit-breaker-cooldown,omitempty"`
+	RoutingKey      string            `json:"routing-key,omitempty"`
+	DedupKeyProps   []string          `json:"dedup-key-props,omitempty"`
+	SeverityMap     map[string]string `json:"severity-map,omitempty"`
+	AutoResolve     bool              `json:"auto-resolve,omitempty"`
+	Tags            []string          `json:"tags,omitempty"`
+	Responders      []string          `json:"responders,omitempty"`
+	Method          string            `json:"method,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	TokenUrl        string            `json:"token-url,omitempty"`
+	ClientId        string            `json:"client-id,omitempty"`
+	ClientSecret    string            `json:"client-secret,omitempty"`
+	Scopes          []string          `json:"scopes,omitempty"`
+	CaCert          string            `json:"ca-cert,omitempty"`
+	ClientCert      string            `json:"client-cert,omitempty"`
+	ClientKey       string            `json:"client-key,omitempty"`
+	AcceptedStatus  []string          `json:"accepted-status,omitempty"`
+	Body            string            `json:"body,omitempty"`
+	Brokers         []string          `json:"brokers,omitempty"`
+	Topic           string            `json:"topic,omitempty"`
+	KeyProps        []string          `json:"key-props,omitempty"`
+	Acks            string            `json:"acks,omitempty"`
+	BatchSize       int               `json:"batch-size,omitempty"`
+	BatchTimeout    string            `json:"batch-timeout,omitempty"`
+	SaslMechanism   string            `json:"sasl-mechanism,omitempty"`
+	Tls             bool              `json:"tls,omitempty"`
+	Protocol        string            `json:"protocol,omitempty"`
+	Format          string            `json:"format,omitempty"`
+	Facility        string            `json:"facility,omitempty"`
+	AppName         string            `json:"app-name,omitempty"`
 }
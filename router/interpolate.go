@@ -0,0 +1,90 @@
+package router
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aquasecurity/postee/v2/secrets"
+	"github.com/aquasecurity/postee/v2/utils"
+)
+
+// InterpolateLocal resolves only environment variables and files referenced by settings, references to secret
+// providers are kept as is. It's used to check a configuration where the providers may be unreachable.
+func InterpolateLocal(tenant *TenantSettings) []error {
+	return interpolate(tenant, nil)
+}
+
+// interpolate resolves environment variables, files and secrets referenced by general settings, enabled outputs,
+// templates and ingest credentials,
+// see utils.Interpolate and secrets.Resolver. Routes are kept as is, their Rego rules aren't settings.
+// References to secret providers aren't resolved if the resolver is nil.
+func interpolate(tenant *TenantSettings, resolver *secrets.Resolver) []error {
+	errs := interpolateFields(reflect.ValueOf(tenant).Elem(), resolver)
+	for i := range tenant.Outputs {
+		if !tenant.Outputs[i].Enable {
+			//disabled outputs aren't built, e.g. examples may refer to files which don't exist
+			continue
+		}
+		for _, err := range interpolateFields(reflect.ValueOf(&tenant.Outputs[i]).Elem(), resolver) {
+			errs = append(errs, fmt.Errorf("output %q: %w", tenant.Outputs[i].Name, err))
+		}
+	}
+	for i := range tenant.Templates {
+		for _, err := range interpolateFields(reflect.ValueOf(&tenant.Templates[i]).Elem(), resolver) {
+			errs = append(errs, fmt.Errorf("template %q: %w", tenant.Templates[i].Name, err))
+		}
+	}
+	for i := range tenant.IngestAuth {
+		for _, err := range interpolateFields(reflect.ValueOf(&tenant.IngestAuth[i]).Elem(), resolver) {
+			errs = append(errs, fmt.Errorf("credential %q: %w", tenant.IngestAuth[i].Name, err))
+		}
+	}
+	return errs
+}
+
+// interpolateFields resolves string options of a settings struct, including lists and maps of strings.
+// Values read from secret providers and files are tracked by the resolver, so anonymizeSettings hides them
+// once the configuration is loaded. Environment variables aren't secrets, e.g. they may set a port.
+func interpolateFields(v reflect.Value, resolver *secrets.Resolver) []error {
+	errs := make([]error, 0)
+	resolve := func(option string, s reflect.Value) {
+		resolved, err := utils.Interpolate(s.String())
+		if err == nil && resolver != nil {
+			resolved, _, err = resolver.Resolve(resolved)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("'%s': %w", option, err))
+			return
+		}
+		if resolver != nil && strings.HasPrefix(s.String(), utils.FilePrefix) {
+			resolver.Track(resolved)
+		}
+		s.SetString(resolved)
+	}
+	for _, f := range optionFields(v.Type()) {
+		field := v.FieldByName(f.name)
+		switch f.typ.Kind() {
+		case reflect.String:
+			resolve(f.key, field)
+		case reflect.Slice:
+			if f.typ.Elem().Kind() != reflect.String {
+				continue
+			}
+			for i := 0; i < field.Len(); i++ {
+				resolve(fmt.Sprintf("%s[%d]", f.key, i), field.Index(i))
+			}
+		case reflect.Map:
+			if f.typ.Elem().Kind() != reflect.String {
+				continue
+			}
+			for _, key := range field.MapKeys() {
+				elem := reflect.New(f.typ.Elem()).Elem()
+				elem.Set(field.MapIndex(key))
+				resolve(fmt.Sprintf("%s.%s", f.key, key), elem)
+				field.SetMapIndex(key, elem)
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,125 @@
+package router
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aquasecurity/postee/v2/routes"
+	"github.com/aquasecurity/postee/v2/secrets"
+)
+
+func TestInterpolate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "postee-secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "token"), []byte("file-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("POSTEE_TEST_HOOK", "T000/B000/XXX")
+	os.Setenv("POSTEE_TEST_USER", "admin")
+	os.Setenv("POSTEE_TEST_DIR", dir)
+	defer func() {
+		os.Unsetenv("POSTEE_TEST_HOOK")
+		os.Unsetenv("POSTEE_TEST_USER")
+		os.Unsetenv("POSTEE_TEST_DIR")
+	}()
+
+	tenant := &TenantSettings{
+		AquaServer: "https://${POSTEE_TEST_AQUA_HOST:-aqua.example.com}",
+		Outputs: []OutputSettings{{
+			Name:     "my-slack",
+			Type:     "slack",
+			Enable:   true,
+			Url:      "https://hooks.slack.com/services/${POSTEE_TEST_HOOK}",
+			User:     "$POSTEE_TEST_USER",
+			Password: "$ecret!",
+			Token:    "file:${POSTEE_TEST_DIR}/token",
+			Labels:   []string{"${POSTEE_TEST_USER}", "$${literal}"},
+			Unknowns: map[string]string{"customfield_1": "${POSTEE_TEST_UNSET}"},
+		}},
+		Templates:   []Template{{Name: "raw", Url: "https://${POSTEE_TEST_AQUA_HOST:-templates.example.com}/raw.rego"}},
+		InputRoutes: []routes.InputRoute{{Name: "route1", Input: "${POSTEE_TEST_USER}"}},
+	}
+	if errs := interpolate(tenant, secrets.NewResolver(context.Background())); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	expected := OutputSettings{
+		Name:     "my-slack",
+		Type:     "slack",
+		Enable:   true,
+		Url:      "https://hooks.slack.com/services/T000/B000/XXX",
+		User:     "admin",
+		Password: "$ecret!",
+		Token:    "file-token",
+		Labels:   []string{"admin", "${literal}"},
+		Unknowns: map[string]string{"customfield_1": ""},
+	}
+	if !reflect.DeepEqual(tenant.Outputs[0], expected) {
+		t.Errorf("Unexpected output settings.\nExpected: %#v\nResult: %#v", expected, tenant.Outputs[0])
+	}
+	if tenant.AquaServer != "https://aqua.example.com" {
+		t.Errorf("Unexpected aqua-server: %q", tenant.AquaServer)
+	}
+	if tenant.Templates[0].Url != "https://templates.example.com/raw.rego" {
+		t.Errorf("Unexpected template url: %q", tenant.Templates[0].Url)
+	}
+	if tenant.InputRoutes[0].Input != "${POSTEE_TEST_USER}" {
+		t.Errorf("Route rules shouldn't be interpolated: %q", tenant.InputRoutes[0].Input)
+	}
+
+	tenant = &TenantSettings{
+		Outputs: []OutputSettings{{
+			Name:   "my-jira",
+			Enable: true,
+			Url:    "https://${POSTEE_TEST_HOST",
+			Token:  "file:" + filepath.Join(dir, "missing"),
+		}},
+	}
+	tenant.Outputs = append(tenant.Outputs, OutputSettings{
+		Name:     "my-example",
+		Password: "file:" + filepath.Join(dir, "missing"),
+	})
+	errs := interpolate(tenant, secrets.NewResolver(context.Background()))
+	if len(errs) != 2 {
+		t.Fatalf("Unexpected number of errors. Expected 2, got %d: %v", len(errs), errs)
+	}
+	if !strings.HasPrefix(errs[0].Error(), `output "my-jira": 'url': `) || !strings.HasPrefix(errs[1].Error(), `output "my-jira": 'token': `) {
+		t.Errorf("Errors should point at options: %v", errs)
+	}
+}
+
+func TestInterpolateLocal(t *testing.T) {
+	os.Setenv("POSTEE_TEST_TIMEOUT", "10s")
+	defer os.Unsetenv("POSTEE_TEST_TIMEOUT")
+
+	tenant := &TenantSettings{
+		Outputs: []OutputSettings{{
+			Name:     "my-webhook",
+			Type:     "webhook",
+			Enable:   true,
+			Url:      "${POSTEE_TEST_UNSET}",
+			Timeout:  "${POSTEE_TEST_TIMEOUT}",
+			Password: "vault://secret/data/postee#password",
+		}},
+	}
+	if errs := InterpolateLocal(tenant); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	if tenant.Outputs[0].Timeout != "10s" {
+		t.Errorf("Unexpected timeout: %q", tenant.Outputs[0].Timeout)
+	}
+	if tenant.Outputs[0].Password != "vault://secret/data/postee#password" {
+		t.Errorf("References to secret providers should be kept: %q", tenant.Outputs[0].Password)
+	}
+	errs := Validate(tenant)
+	if len(errs) != 1 || errs[0].Error() != `output "my-webhook": 'url' is required` {
+		t.Errorf("Unset variable should be reported as a missing option: %v", errs)
+	}
+}
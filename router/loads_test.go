@@ -1,15 +1,18 @@
 package router
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/aquasecurity/postee/v2/data"
 	"github.com/aquasecurity/postee/v2/dbservice"
+	"github.com/aquasecurity/postee/v2/delivery"
 	"github.com/aquasecurity/postee/v2/msgservice"
 	"github.com/aquasecurity/postee/v2/outputs"
 	"github.com/aquasecurity/postee/v2/routes"
@@ -67,7 +70,7 @@ outputs:
 type ctxWrapper struct {
 	instance           *Router
 	savedBaseForTicker time.Duration
-	savedGetService    func() service
+	savedGetService    func(*dbservice.Store, *delivery.Dispatcher) service
 	savedDBPath        string
 	cfgPath            string
 	defaultRegoFolder  string
@@ -80,13 +83,14 @@ type invctn struct {
 	routeName   string
 }
 
-func (ctx *ctxWrapper) MsgHandling(input []byte, output outputs.Output, route *routes.InputRoute, inpteval data.Inpteval, aquaServer *string) {
+func (ctx *ctxWrapper) MsgHandling(c context.Context, input []byte, output outputs.Output, route *routes.InputRoute, inpteval data.Inpteval, aquaServer *string) msgservice.Result {
 	i := invctn{
 		fmt.Sprintf("%T", output),
 		fmt.Sprintf("%T", inpteval),
 		route.Name,
 	}
 	ctx.buff <- i
+	return msgservice.Result{Status: msgservice.StatusDelivered}
 }
 
 func (ctxWrapper *ctxWrapper) setup(cfg string) {
@@ -109,7 +113,7 @@ func (ctxWrapper *ctxWrapper) setup(cfg string) {
 		log.Printf("Can't create %s %v", ctxWrapper.defaultRegoFolder, err)
 	}
 
-	getScanService = func() service {
+	getScanService = func(*dbservice.Store, *delivery.Dispatcher) service {
 		return ctxWrapper
 	}
 
@@ -208,8 +212,109 @@ func TestReload(t *testing.T) {
 
 }
 
+func TestReloadKeepsUnchanged(t *testing.T) {
+	wrap := ctxWrapper{}
+	wrap.setup(cfgData)
+
+	defer wrap.teardown()
+
+	demoCtx := wrap.instance
+	if err := demoCtx.Start(wrap.cfgPath); err != nil {
+		t.Fatal(err)
+	}
+	splunk, jira := demoCtx.outputs["splunk"], demoCtx.outputs["jira"]
+	route1, route2 := demoCtx.inputRoutes["route1"], demoCtx.inputRoutes["route2"]
+
+	changed := strings.Replace(cfgData, "password: admin", "password: changed", 1)
+	changed = strings.Replace(changed, `   contains(input.image, "alpine")
+
+  outputs: ["my-slack"]        #  a list of integrations which will receive a scan or an audit event
+  template: raw       #  a template for this route
+  plugins:
+   policy-show-all: true
+`, `   contains(input.image, "ubuntu")
+
+  outputs: ["my-slack"]        #  a list of integrations which will receive a scan or an audit event
+  template: raw       #  a template for this route
+  plugins:
+   policy-show-all: true
+`, 2)
+	if err := ioutil.WriteFile(wrap.cfgPath, []byte(changed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := demoCtx.ReloadConfig(); err != nil {
+		t.Fatalf("Unexpected reload error: %v", err)
+	}
+	if demoCtx.outputs["splunk"] != splunk {
+		t.Error("Unchanged output splunk is rebuilt")
+	}
+	if demoCtx.outputs["jira"] == jira {
+		t.Error("Changed output jira isn't rebuilt")
+	}
+	if demoCtx.inputRoutes["route1"] != route1 {
+		t.Error("Unchanged route route1 is replaced")
+	}
+	if demoCtx.inputRoutes["route2"] == route2 {
+		t.Error("Changed route route2 isn't replaced")
+	}
+
+	tests := []struct {
+		caseDesc string
+		cfg      string
+	}{
+		{"broken yaml", "routes: [\n"},
+		{"duplicated output", changed + `
+- name: splunk
+  type: splunk
+  enable: true
+  url: http://localhost:8089`},
+		{"misconfigured output", changed + `
+- name: jira3
+  type: jira
+  enable: true
+  url: "https://afdesk.atlassian.net/"`},
+	}
+	for _, test := range tests {
+		splunk, jira = demoCtx.outputs["splunk"], demoCtx.outputs["jira"]
+		if err := ioutil.WriteFile(wrap.cfgPath, []byte(test.cfg), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := demoCtx.ReloadConfig(); err == nil {
+			t.Errorf("[%s] Reload should return an error", test.caseDesc)
+		}
+		if len(demoCtx.outputs) != 2 || demoCtx.outputs["splunk"] != splunk || demoCtx.outputs["jira"] != jira {
+			t.Errorf("[%s] Running outputs aren't kept: %v", test.caseDesc, demoCtx.outputs)
+		}
+		if len(demoCtx.inputRoutes) != 2 {
+			t.Errorf("[%s] Running routes aren't kept: %v", test.caseDesc, demoCtx.inputRoutes)
+		}
+	}
+}
+
+func TestConfigDiff(t *testing.T) {
+	tests := []struct {
+		diff     configDiff
+		expected string
+	}{
+		{configDiff{}, "nothing is changed"},
+		{
+			configDiff{
+				routes:    changes{added: []string{"route2", "route1"}},
+				outputs:   changes{changed: []string{"jira"}, removed: []string{"slack"}},
+				templates: changes{changed: []string{"raw"}},
+			},
+			"routes added: route1, route2; outputs changed: jira; outputs removed: slack; templates changed: raw",
+		},
+	}
+	for _, test := range tests {
+		if actual := test.diff.String(); actual != test.expected {
+			t.Errorf("Unexpected diff. Expected %q, got %q", test.expected, actual)
+		}
+	}
+}
+
 func TestServiceGetters(t *testing.T) {
-	scanner := getScanService()
+	scanner := getScanService(nil, nil)
 	if _, ok := scanner.(*msgservice.MsgService); !ok {
 		t.Error("getScanService() doesn't return an instance of scanservice.ScanService")
 	}
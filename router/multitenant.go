@@ -0,0 +1,184 @@
+package router
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aquasecurity/postee/v2/dbservice"
+	"github.com/aquasecurity/postee/v2/delivery"
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/aquasecurity/postee/v2/metrics"
+	"github.com/ghodss/yaml"
+)
+
+var tenantNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+var (
+	mutexTenants sync.RWMutex
+	tenants      = make(map[string]*Router)
+)
+
+// StartTenants starts a router for every configuration in a directory, a file or a subdirectory is a configuration
+// of a tenant. Tenants have their own routes, outputs, templates, database and API key. A tenant is named by
+// `name` option of its configuration, or by the name of its file without extension.
+func StartTenants(dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	started := make([]*Router, 0, len(entries))
+	stop := func() {
+		for _, r := range started {
+			r.Terminate()
+		}
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			//e.g. ..data directory of a Kubernetes ConfigMap
+			continue
+		}
+		if !entry.IsDir() && !configExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			continue
+		}
+		cfgpath := filepath.Join(dir, entry.Name())
+		name, err := tenantName(cfgpath)
+		if err == nil && name == "" {
+			name = strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		}
+		if err == nil {
+			err = checkTenantName(name, started)
+		}
+		var r *Router
+		if err == nil {
+			r, err = startTenant(name, cfgpath)
+		}
+		if err != nil {
+			stop()
+			return fmt.Errorf("tenant %s: %w", cfgpath, err)
+		}
+		started = append(started, r)
+	}
+
+	mutexTenants.Lock()
+	defer mutexTenants.Unlock()
+	for _, r := range started {
+		tenants[r.tenant] = r
+	}
+	logging.Infof("%d tenant(s) are started", len(started))
+	return nil
+}
+
+func checkTenantName(name string, started []*Router) error {
+	if !tenantNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid tenant name %q, it may contain letters, digits, '.', '_' and '-'", name)
+	}
+	for _, r := range started {
+		if r.tenant == name {
+			return fmt.Errorf("tenant %q is already defined by %s", name, r.cfgfile)
+		}
+	}
+	if GetTenant(name) != nil {
+		return fmt.Errorf("tenant %q is already started", name)
+	}
+	return nil
+}
+
+func startTenant(name, cfgpath string) (*Router, error) {
+	store, err := dbservice.NewStore(filepath.Join(filepath.Dir(dbservice.DbPath), "tenants", name+".db"))
+	if err != nil {
+		return nil, err
+	}
+	if err := store.EnsureApiKey(); err != nil {
+		return nil, err
+	}
+	logging.Infof("Starting tenant %s, its database is %s", name, store.Path)
+	r := newRouter(name, store, delivery.New(name, store))
+	if err := r.Start(cfgpath); err != nil {
+		return nil, err
+	}
+	metrics.RegisterStore(name, store)
+	return r, nil
+}
+
+// tenantName reads `name` option of a configuration, which may be set in any of its files
+func tenantName(cfgpath string) (string, error) {
+	name := ""
+	_, err := walkConfig(cfgpath, func(path string, data []byte) ([]string, error) {
+		fragment := struct {
+			Name    string   `json:"name"`
+			Include []string `json:"include"`
+		}{}
+		if err := yaml.Unmarshal(data, &fragment); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if fragment.Name != "" {
+			name = fragment.Name
+		}
+		return fragment.Include, nil
+	})
+	return name, err
+}
+
+// GetTenant returns the router of a tenant, nil if there isn't such tenant
+func GetTenant(name string) *Router {
+	mutexTenants.RLock()
+	defer mutexTenants.RUnlock()
+	return tenants[name]
+}
+
+// Tenants returns the routers of all tenants by their names
+func Tenants() map[string]*Router {
+	mutexTenants.RLock()
+	defer mutexTenants.RUnlock()
+	all := make(map[string]*Router, len(tenants))
+	for name, r := range tenants {
+		all[name] = r
+	}
+	return all
+}
+
+// ReloadTenants reloads configurations of all tenants, a tenant which fails keeps its running configuration
+func ReloadTenants() {
+	for _, name := range tenantNames() {
+		if err := GetTenant(name).ReloadConfig(); err != nil {
+			logging.Errorf("Configuration of tenant %s isn't reloaded: %v", name, err)
+		}
+	}
+}
+
+func TerminateTenants() {
+	mutexTenants.Lock()
+	defer mutexTenants.Unlock()
+	for name, r := range tenants {
+		logging.Infof("Terminating tenant %s....", name)
+		r.Terminate()
+		metrics.UnregisterStore(name)
+		delete(tenants, name)
+	}
+}
+
+func tenantNames() []string {
+	mutexTenants.RLock()
+	defer mutexTenants.RUnlock()
+	names := make([]string, 0, len(tenants))
+	for name := range tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Tenant returns the name of the router's tenant, empty for the default configuration
+func (ctx *Router) Tenant() string {
+	return ctx.tenant
+}
+
+// CfgFile returns the configuration path of the router
+func (ctx *Router) CfgFile() string {
+	return ctx.cfgfile
+}
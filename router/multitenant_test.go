@@ -0,0 +1,96 @@
+package router
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aquasecurity/postee/v2/dbservice"
+)
+
+func TestStartTenants(t *testing.T) {
+	dir, err := ioutil.TempDir("", "postee-tenants")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	savedDbPath := dbservice.DbPath
+	dbservice.DbPath = filepath.Join(dir, "database", "webhooks.db")
+	defer func() {
+		dbservice.DbPath = savedDbPath
+	}()
+
+	tenantsDir := filepath.Join(dir, "tenants")
+	if err := os.Mkdir(tenantsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := `
+%s
+routes:
+- name: %s
+  input: contains(input.image, "alpine")
+  outputs: ["my-stdout"]
+  template: legacy
+templates:
+- name: legacy
+  legacy-scan-renderer: slack
+outputs:
+- name: my-stdout
+  type: stdout
+  enable: true
+`
+	write := func(name, header, route string) {
+		content := strings.Replace(strings.Replace(cfg, "%s", header, 1), "%s", route, 1)
+		if err := ioutil.WriteFile(filepath.Join(tenantsDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("bu-1.yaml", "", "route-1")
+	write("second.yaml", "name: bu-2", "route-2")
+	write("notes.txt", "", "ignored")
+
+	if err := StartTenants(tenantsDir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer TerminateTenants()
+
+	if len(Tenants()) != 2 {
+		t.Fatalf("Unexpected tenants: %v", tenantNames())
+	}
+	bu1, bu2 := GetTenant("bu-1"), GetTenant("bu-2")
+	if bu1 == nil || bu2 == nil {
+		t.Fatalf("Tenants should be named by 'name' option or file name: %v", tenantNames())
+	}
+	if GetTenant("notes") != nil {
+		t.Error("Files which aren't configurations shouldn't be tenants")
+	}
+	if bu1.Store() == nil || bu1.Store().Path == bu2.Store().Path || bu1.Dispatcher() == bu2.Dispatcher() {
+		t.Error("Tenants should have their own databases and dispatchers")
+	}
+	for _, r := range []*Router{bu1, bu2} {
+		if key, err := r.Store().GetApiKey(); err != nil || key == "" {
+			t.Errorf("Tenant %s should have an API key: %v", r.Tenant(), err)
+		}
+	}
+
+	input := []byte(`{"image":"alpine:3.12","registry":"Docker Hub"}`)
+	if _, err := bu2.SendAndWait(context.Background(), "route-1", input); err != ErrUnknownRoute {
+		t.Errorf("Routes of a tenant shouldn't be reachable by other tenants: %v", err)
+	}
+	results, err := bu1.SendAndWait(context.Background(), "", input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Route != "route-1" || !results[0].Matched {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+
+	write("third.yaml", "name: bu-1", "route-3")
+	if err := StartTenants(tenantsDir); err == nil || !strings.Contains(err.Error(), `tenant "bu-1"`) {
+		t.Errorf("Duplicate tenant isn't reported: %v", err)
+	}
+}
@@ -2,9 +2,15 @@ package router
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
-	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 
+	"github.com/aquasecurity/postee/v2/logging"
 	"github.com/ghodss/yaml"
 )
 
@@ -13,39 +19,241 @@ const (
 	v1Warning = `
 
 
-@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@
+@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@
 
   Options supported only in Postee V1 are found in %s. Please make sure app is configured correctly!
   See https://github.com/aquasecurity/postee/blob/main/README.md for the details.
 
-@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@
+@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@
 
 
 `
 )
 
+// StrictConfig makes options which aren't known by Postee an error, otherwise they are only logged.
+// It's off by default, so configs with options of older versions keep working.
+var StrictConfig = false
+
+// configExtensions are extensions of files which are loaded from a configuration directory
+var configExtensions = map[string]bool{".yaml": true, ".yml": true, ".json": true}
+
+// Parsev2cfg loads a configuration from a file, a directory or a glob. Outputs, routes and templates of all files,
+// including ones listed by `include`, are merged. An item defined in more than one file is an error.
 func Parsev2cfg(cfgpath string) (*TenantSettings, error) {
-	data, err := ioutil.ReadFile(cfgpath)
+	tenant := &TenantSettings{}
+	sources := make(map[string]string)
+	files, err := walkConfig(cfgpath, func(path string, data []byte) ([]string, error) {
+		fragment, err := parseFragment(path, data)
+		if err != nil {
+			return nil, err
+		}
+		return fragment.Include, mergeFragment(tenant, sources, fragment, path)
+	})
 	if err != nil {
-		log.Printf("Failed to open file %s, %s", cfgpath, err)
+		logging.Errorf("Failed to load configuration %s, %s", cfgpath, err)
 		return nil, err
 	}
+	if len(files) > 1 {
+		tenant.Sources = sources
+		logging.Infof("Configuration is loaded from %s", strings.Join(files, ", "))
+	}
+	return tenant, nil
+}
 
-	checkV1Cfg(data, cfgpath)
+// ConfigFiles lists the files of a configuration in the order they are loaded
+func ConfigFiles(cfgpath string) ([]string, error) {
+	return walkConfig(cfgpath, func(path string, data []byte) ([]string, error) {
+		fragment := struct {
+			Include []string `json:"include"`
+		}{}
+		if err := yaml.Unmarshal(data, &fragment); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return fragment.Include, nil
+	})
+}
 
-	tenant := &TenantSettings{}
-	err = yaml.Unmarshal(data, tenant)
+// walkConfig reads the files of a configuration and the files they include, every file is read once.
+// visit returns the includes of a file, relative ones are resolved against the directory of the file.
+func walkConfig(cfgpath string, visit func(path string, data []byte) ([]string, error)) ([]string, error) {
+	files := make([]string, 0)
+	loaded := make(map[string]bool)
+
+	var walk func(cfgpath string) error
+	walk = func(cfgpath string) error {
+		paths, err := expandConfigPath(cfgpath)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return err
+			}
+			if loaded[abs] {
+				//files matched by several globs or included in a cycle
+				continue
+			}
+			loaded[abs] = true
+
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			files = append(files, path)
+			includes, err := visit(path, data)
+			if err != nil {
+				return err
+			}
+			for _, include := range includes {
+				if !filepath.IsAbs(include) {
+					include = filepath.Join(filepath.Dir(path), include)
+				}
+				if err := walk(include); err != nil {
+					return fmt.Errorf("include of %s: %w", path, err)
+				}
+			}
+		}
+		return nil
+	}
+	return files, walk(cfgpath)
+}
+
+// expandConfigPath lists configuration files of a path in lexical order, directories are not recursed
+func expandConfigPath(cfgpath string) ([]string, error) {
+	if strings.ContainsAny(cfgpath, "*?[") {
+		matches, err := filepath.Glob(cfgpath)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", cfgpath)
+		}
+		sort.Strings(matches)
+		files := make([]string, 0, len(matches))
+		for _, match := range matches {
+			expanded, err := expandConfigPath(match)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, expanded...)
+		}
+		return files, nil
+	}
 
+	info, err := os.Stat(cfgpath)
 	if err != nil {
-		log.Printf("Failed yaml.Unmarshal, %s", err)
 		return nil, err
 	}
+	if !info.IsDir() {
+		return []string{cfgpath}, nil
+	}
+	entries, err := ioutil.ReadDir(cfgpath)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && configExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			files = append(files, filepath.Join(cfgpath, entry.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no configuration files in directory %s", cfgpath)
+	}
+	return files, nil
+}
 
+func parseFragment(cfgpath string, data []byte) (*TenantSettings, error) {
+	checkV1Cfg(data, cfgpath)
+
+	tenant := &TenantSettings{}
+	if err := yaml.Unmarshal(data, tenant); err != nil {
+		return nil, fmt.Errorf("%s: %w", cfgpath, err)
+	}
+
+	unknown, err := UnknownKeys(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", cfgpath, err)
+	}
+	if len(unknown) > 0 {
+		if StrictConfig {
+			return nil, fmt.Errorf("unknown options in %s: %s", cfgpath, strings.Join(unknown, ", "))
+		}
+		for _, key := range unknown {
+			logging.Warnf("Unknown option %q in %s is ignored", key, cfgpath)
+		}
+	}
 	return tenant, nil
+}
+
+// mergeFragment adds items of a file to a configuration. Sources keep the files of items and top-level options,
+// so items and options set in two files are reported with both files.
+func mergeFragment(tenant *TenantSettings, sources map[string]string, fragment *TenantSettings, cfgpath string) error {
+	claim := func(key string) error {
+		if source, ok := sources[key]; ok && source != cfgpath {
+			//duplicates within a file are reported by validation
+			return fmt.Errorf("%s is defined in both %s and %s", strings.Replace(key, "/", " ", 1), source, cfgpath)
+		}
+		sources[key] = cfgpath
+		return nil
+	}
 
+	//top-level options may be set by any file, but only once
+	dst, src := reflect.ValueOf(tenant).Elem(), reflect.ValueOf(fragment).Elem()
+	for i := 0; i < src.NumField(); i++ {
+		option := strings.Split(src.Type().Field(i).Tag.Get("json"), ",")[0]
+		switch option {
+		case "", "-", "outputs", "routes", "templates", "include", "ingest-auth":
+			continue
+		}
+		if src.Field(i).IsZero() {
+			continue
+		}
+		if err := claim("option/" + option); err != nil {
+			return err
+		}
+		dst.Field(i).Set(src.Field(i))
+	}
+
+	for _, o := range fragment.Outputs {
+		if err := claim("output/" + o.Name); err != nil {
+			return err
+		}
+		tenant.Outputs = append(tenant.Outputs, o)
+	}
+	for _, r := range fragment.InputRoutes {
+		if err := claim("route/" + r.Name); err != nil {
+			return err
+		}
+		tenant.InputRoutes = append(tenant.InputRoutes, r)
+	}
+	for _, t := range fragment.Templates {
+		if err := claim("template/" + t.Name); err != nil {
+			return err
+		}
+		tenant.Templates = append(tenant.Templates, t)
+	}
+	for _, c := range fragment.IngestAuth {
+		if err := claim("credential/" + c.Name); err != nil {
+			return err
+		}
+		tenant.IngestAuth = append(tenant.IngestAuth, c)
+	}
+	tenant.Include = append(tenant.Include, fragment.Include...)
+	return nil
 }
+
+// from tags items of a configuration split across files with their file for errors and logs
+func (tenant *TenantSettings) from(kind, name string) string {
+	if source, ok := tenant.Sources[kind+"/"+name]; ok {
+		return fmt.Sprintf(" (%s)", source)
+	}
+	return ""
+}
+
 func checkV1Cfg(data []byte, cfgpath string) {
 	if bytes.Index(data, []byte(v1Marker)) > -1 {
-		log.Printf(v1Warning, cfgpath)
+		logging.Warnf(v1Warning, cfgpath)
 	}
 }
@@ -3,6 +3,8 @@ package router
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -37,3 +39,105 @@ playing_playlist: {{ action }} playlist {{ playlist_name }}`
 	}
 
 }
+
+func TestParseSplitCfg(t *testing.T) {
+	dir, err := ioutil.TempDir("", "postee-cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("cfg/00-main.yaml", `
+aqua-server: https://aqua.example.com
+include:
+- ../shared/*.yaml
+outputs:
+- name: my-stdout
+  type: stdout
+  enable: true
+`)
+	write("cfg/10-team-a.yml", `
+routes:
+- name: team-a
+  outputs: ["my-stdout"]
+  template: raw
+`)
+	write("cfg/README.md", `not a config`)
+	write("shared/templates.yaml", `
+include:
+- ../cfg/00-main.yaml
+templates:
+- name: raw
+  body: input
+`)
+
+	tests := []struct {
+		name    string
+		cfgpath string
+	}{
+		{"directory", filepath.Join(dir, "cfg")},
+		{"glob", filepath.Join(dir, "cfg", "*.y*ml")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tenant, err := Parsev2cfg(test.cfgpath)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tenant.AquaServer != "https://aqua.example.com" {
+				t.Errorf("Unexpected aqua-server: %q", tenant.AquaServer)
+			}
+			if len(tenant.Outputs) != 1 || len(tenant.InputRoutes) != 1 || len(tenant.Templates) != 1 {
+				t.Fatalf("Items aren't merged: %d outputs, %d routes, %d templates", len(tenant.Outputs), len(tenant.InputRoutes), len(tenant.Templates))
+			}
+			expectedSource := filepath.Join(dir, "cfg", "10-team-a.yml")
+			if got := tenant.from("route", "team-a"); got != " ("+expectedSource+")" {
+				t.Errorf("Unexpected source of route: %q", got)
+			}
+			if got := tenant.from("template", "raw"); !strings.Contains(got, "templates.yaml") {
+				t.Errorf("Unexpected source of template: %q", got)
+			}
+
+			files, err := ConfigFiles(test.cfgpath)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(files) != 3 {
+				t.Errorf("Unexpected files: %v", files)
+			}
+		})
+	}
+
+	write("cfg/20-team-b.yaml", `
+log-level: debug
+routes:
+- name: team-a
+  outputs: ["my-stdout"]
+  template: raw
+`)
+	_, err = Parsev2cfg(filepath.Join(dir, "cfg"))
+	if err == nil || !strings.Contains(err.Error(), `route team-a is defined in both`) {
+		t.Errorf("Duplicate route isn't reported: %v", err)
+	}
+
+	write("cfg/20-team-b.yaml", `
+aqua-server: https://other.example.com
+`)
+	_, err = Parsev2cfg(filepath.Join(dir, "cfg"))
+	if err == nil || !strings.Contains(err.Error(), `option aqua-server is defined in both`) {
+		t.Errorf("Conflicting option isn't reported: %v", err)
+	}
+
+	if _, err = Parsev2cfg(filepath.Join(dir, "none", "*.yaml")); err == nil {
+		t.Errorf("Error is expected for a glob without files")
+	}
+}
@@ -0,0 +1,447 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aquasecurity/postee/v2/data"
+	"github.com/aquasecurity/postee/v2/formatting"
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/aquasecurity/postee/v2/outputs"
+	"github.com/aquasecurity/postee/v2/regoservice"
+	"github.com/aquasecurity/postee/v2/routes"
+	"github.com/aquasecurity/postee/v2/secrets"
+	"github.com/aquasecurity/postee/v2/utils"
+)
+
+// runtimeConfig is a set of outputs, templates and routes built from a configuration.
+// Parts whose settings aren't changed are taken from the running configuration.
+type runtimeConfig struct {
+	aquaServer       string
+	outputs          map[string]outputs.Output
+	outputSettings   map[string]OutputSettings
+	templates        map[string]data.Inpteval
+	templateSettings map[string]Template
+	inputRoutes      map[string]*routes.InputRoute
+	rebuilt          map[string]bool //outputs which are built from scratch
+}
+
+// ReloadConfig applies the configuration file again. Only outputs and routes whose settings are changed
+// are restarted, queued messages and aggregation state of other routes are kept.
+// If the new configuration can't be built, the running one is kept and the error is returned.
+func (ctx *Router) ReloadConfig() error {
+	if atomic.LoadInt32(&ctx.running) == 0 {
+		return ctx.Start(ctx.cfgfile)
+	}
+	ctx.mutexReload.Lock()
+	defer ctx.mutexReload.Unlock()
+
+	logging.Infof("Reloading configuration file %s ....", ctx.cfgfile)
+	tenant, err := Parsev2cfg(ctx.cfgfile)
+	resolver := secrets.NewResolver(context.Background())
+	if err == nil {
+		if errs := interpolate(tenant, resolver); len(errs) > 0 {
+			err = joinErrors(errs)
+		}
+	}
+	if err == nil {
+		err = validate(tenant)
+	}
+	if err != nil {
+		logging.Errorf("Invalid configuration, the running one is kept: %v", err)
+		return err
+	}
+	//secrets are tracked before the build, which logs anonymized settings
+	resolver.Apply(ctx.cfgfile)
+	cfg, errs := ctx.build(tenant)
+	if len(errs) > 0 {
+		cfg.discard()
+		err := joinErrors(errs)
+		logging.Errorf("Unable to build configuration, the running one is kept: %v", err)
+		return err
+	}
+	diff := ctx.diff(cfg)
+	ctx.apply(tenant, cfg)
+	logging.Infof("Configuration is reloaded: %s", diff)
+	return nil
+}
+
+// build prepares outputs, templates and routes of a configuration without touching the running ones.
+// Errors of single outputs and templates are returned along with the parts which could be built.
+func (ctx *Router) build(tenant *TenantSettings) (*runtimeConfig, []error) {
+	ctx.mutexScan.Lock()
+	old := &runtimeConfig{
+		aquaServer:       ctx.aquaServer,
+		outputs:          ctx.outputs,
+		outputSettings:   ctx.outputSettings,
+		templates:        ctx.templates,
+		templateSettings: ctx.templateSettings,
+		inputRoutes:      ctx.inputRoutes,
+	}
+	ctx.mutexScan.Unlock()
+
+	cfg := &runtimeConfig{
+		aquaServer:       buildAquaServerUrl(tenant.AquaServer),
+		outputs:          make(map[string]outputs.Output),
+		outputSettings:   make(map[string]OutputSettings),
+		templates:        make(map[string]data.Inpteval),
+		templateSettings: make(map[string]Template),
+		inputRoutes:      make(map[string]*routes.InputRoute),
+		rebuilt:          make(map[string]bool),
+	}
+	errs := make([]error, 0)
+	rebuiltTemplates := make(map[string]bool)
+
+	for _, t := range tenant.Templates {
+		cfg.templateSettings[t.Name] = t
+		if prev, ok := old.templateSettings[t.Name]; ok && reflect.DeepEqual(prev, t) {
+			if inpteval, ok := old.templates[t.Name]; ok {
+				cfg.templates[t.Name] = inpteval
+			}
+			continue
+		}
+		rebuiltTemplates[t.Name] = true
+		template := t
+		inpteval, err := buildTemplate(&template)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("template %q%s: %w", t.Name, tenant.from("template", t.Name), err))
+			continue
+		}
+		if inpteval != nil {
+			cfg.templates[t.Name] = inpteval
+		}
+	}
+
+	for _, settings := range tenant.Outputs {
+		utils.Debug("%#v\n", anonymizeSettings(&settings))
+		if !settings.Enable {
+			continue
+		}
+		if prev, ok := old.outputSettings[settings.Name]; ok && reflect.DeepEqual(prev, settings) && old.aquaServer == cfg.aquaServer {
+			if plg, ok := old.outputs[settings.Name]; ok {
+				cfg.outputs[settings.Name] = plg
+				cfg.outputSettings[settings.Name] = settings
+				continue
+			}
+		}
+		built := settings //builders may change settings, the original ones are kept for comparison
+		plg, err := buildOutput(&built, cfg.aquaServer)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("output %q%s: %w", settings.Name, tenant.from("output", settings.Name), err))
+			continue
+		}
+		logging.Infof("Output %s%s is configured", settings.Name, tenant.from("output", settings.Name))
+		cfg.outputs[settings.Name] = plg
+		cfg.outputSettings[settings.Name] = settings
+		cfg.rebuilt[settings.Name] = true
+	}
+
+	for i := range tenant.InputRoutes {
+		r := routes.ConfigureTimeouts(&tenant.InputRoutes[i])
+		if prev, ok := old.inputRoutes[r.Name]; ok && !routeChanged(prev, r) &&
+			!rebuiltTemplates[r.Template] && !outputsChanged(r, old, cfg) {
+			cfg.inputRoutes[r.Name] = prev
+			continue
+		}
+		logging.Debugf("Route %s%s is configured", r.Name, tenant.from("route", r.Name))
+		cfg.inputRoutes[r.Name] = r
+	}
+	return cfg, errs
+}
+
+// apply swaps the running configuration with a built one, then stops outputs and route schedulers which were replaced
+func (ctx *Router) apply(tenant *TenantSettings, cfg *runtimeConfig) {
+	if ctx.tenant == "" {
+		//logging is shared by tenants, so it's configured by the default configuration only
+		if err := logging.Configure(tenant.LogFormat, tenant.LogLevel); err != nil {
+			logging.Errorf("Invalid logging settings: %v", err)
+		}
+	}
+	ctx.store.SetSizeLimit(tenant.DBMaxSize)
+
+	for name, plg := range cfg.outputs {
+		if cfg.rebuilt[name] {
+			settings := cfg.outputSettings[name]
+			ctx.dispatcher.Register(plg, buildDeliveryOptions(&settings))
+		}
+	}
+
+	ctx.mutexScan.Lock()
+	oldOutputs, oldRoutes := ctx.outputs, ctx.inputRoutes
+	ctx.aquaServer = cfg.aquaServer
+	ctx.outputs = cfg.outputs
+	ctx.outputSettings = cfg.outputSettings
+	ctx.templates = cfg.templates
+	ctx.templateSettings = cfg.templateSettings
+	ctx.inputRoutes = cfg.inputRoutes
+	ctx.ingestAuth = tenant.IngestAuth
+	ctx.mutexScan.Unlock()
+
+	for name, plg := range oldOutputs {
+		if _, ok := cfg.outputs[name]; ok && !cfg.rebuilt[name] {
+			continue
+		}
+		if _, ok := cfg.outputs[name]; !ok {
+			ctx.dispatcher.Unregister(name)
+			logging.Infof("Output %s is removed", name)
+		}
+		if err := plg.Terminate(); err != nil {
+			logging.Errorf("failed to terminate output: %v", err)
+		}
+	}
+	for name, r := range oldRoutes {
+		if cfg.inputRoutes[name] != r {
+			r.StopScheduler()
+		}
+	}
+
+	if tenant.DBTestInterval == 0 {
+		tenant.DBTestInterval = 1
+	}
+	ctx.startTicker(baseForTicker * time.Duration(tenant.DBTestInterval))
+
+	if tenant.MaxWorkers <= 0 {
+		tenant.MaxWorkers = WorkersDefault
+	}
+	ctx.resizeWorkers(tenant.MaxWorkers)
+}
+
+// configDiff lists names of routes, outputs and templates which are added, changed or removed by a reload
+type configDiff struct {
+	routes, outputs, templates changes
+}
+
+type changes struct {
+	added, changed, removed []string
+}
+
+func (ctx *Router) diff(cfg *runtimeConfig) configDiff {
+	ctx.mutexScan.Lock()
+	defer ctx.mutexScan.Unlock()
+	var d configDiff
+	for name, r := range cfg.inputRoutes {
+		if prev, ok := ctx.inputRoutes[name]; !ok {
+			d.routes.added = append(d.routes.added, name)
+		} else if prev != r {
+			d.routes.changed = append(d.routes.changed, name)
+		}
+	}
+	for name := range ctx.inputRoutes {
+		if _, ok := cfg.inputRoutes[name]; !ok {
+			d.routes.removed = append(d.routes.removed, name)
+		}
+	}
+	for name := range cfg.outputs {
+		if _, ok := ctx.outputs[name]; !ok {
+			d.outputs.added = append(d.outputs.added, name)
+		} else if cfg.rebuilt[name] {
+			d.outputs.changed = append(d.outputs.changed, name)
+		}
+	}
+	for name := range ctx.outputs {
+		if _, ok := cfg.outputs[name]; !ok {
+			d.outputs.removed = append(d.outputs.removed, name)
+		}
+	}
+	for name, t := range cfg.templateSettings {
+		if prev, ok := ctx.templateSettings[name]; !ok {
+			d.templates.added = append(d.templates.added, name)
+		} else if !reflect.DeepEqual(prev, t) {
+			d.templates.changed = append(d.templates.changed, name)
+		}
+	}
+	for name := range ctx.templateSettings {
+		if _, ok := cfg.templateSettings[name]; !ok {
+			d.templates.removed = append(d.templates.removed, name)
+		}
+	}
+	return d
+}
+
+func (d configDiff) String() string {
+	parts := make([]string, 0)
+	for _, c := range []struct {
+		kind string
+		changes
+	}{{"routes", d.routes}, {"outputs", d.outputs}, {"templates", d.templates}} {
+		for _, l := range []struct {
+			action string
+			names  []string
+		}{{"added", c.added}, {"changed", c.changed}, {"removed", c.removed}} {
+			if len(l.names) > 0 {
+				sort.Strings(l.names)
+				parts = append(parts, fmt.Sprintf("%s %s: %s", c.kind, l.action, strings.Join(l.names, ", ")))
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return "nothing is changed"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// discard terminates outputs which were built for a configuration which isn't applied
+func (cfg *runtimeConfig) discard() {
+	for name, plg := range cfg.outputs {
+		if cfg.rebuilt[name] {
+			plg.Terminate()
+		}
+	}
+}
+
+func (ctx *Router) startTicker(interval time.Duration) {
+	if ctx.ticker != nil {
+		ctx.ticker.Reset(interval)
+		return
+	}
+	ctx.ticker = time.NewTicker(interval)
+	go func(ticker *time.Ticker) {
+		for {
+			select {
+			case <-ctx.stopTicker:
+				ticker.Stop()
+				return
+			case <-ticker.C:
+				ctx.store.CheckSizeLimit()
+				ctx.store.CheckExpiredData()
+			}
+		}
+	}(ctx.ticker)
+}
+
+// resizeWorkers starts or stops routing workers. Busy workers stop after their current message.
+func (ctx *Router) resizeWorkers(n int) {
+	ctx.mutexScan.Lock()
+	defer ctx.mutexScan.Unlock()
+	if n == ctx.workerCount {
+		return
+	}
+	for ; ctx.workerCount < n; ctx.workerCount++ {
+		ctx.workers.Add(1)
+		go ctx.listen()
+	}
+	if extra := ctx.workerCount - n; extra > 0 {
+		ctx.workerCount = n
+		go func() {
+			for i := 0; i < extra; i++ {
+				select {
+				case ctx.stopWorker <- struct{}{}:
+				case <-ctx.quit:
+					return
+				}
+			}
+		}()
+	}
+	logging.Infof("%d routing worker(s) are running", n)
+}
+
+// routeChanged compares route settings ignoring the values which are computed at runtime
+func routeChanged(prev, next *routes.InputRoute) bool {
+	a, b := *prev, *next
+	a.Scheduling, b.Scheduling = nil, nil
+	a.Plugins.AggregateTimeoutSeconds, b.Plugins.AggregateTimeoutSeconds = 0, 0
+	a.Plugins.UniqueMessageTimeoutSeconds, b.Plugins.UniqueMessageTimeoutSeconds = 0, 0
+	return !reflect.DeepEqual(a, b)
+}
+
+// outputsChanged reports whether any output of a route is rebuilt, enabled or disabled
+func outputsChanged(r *routes.InputRoute, old, cfg *runtimeConfig) bool {
+	for _, name := range r.Outputs {
+		_, wasRunning := old.outputs[name]
+		_, isRunning := cfg.outputs[name]
+		if wasRunning != isRunning || cfg.rebuilt[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func buildAquaServerUrl(aquaServer string) string {
+	if len(aquaServer) == 0 {
+		return ""
+	}
+	var slash string
+	if !strings.HasSuffix(aquaServer, "/") {
+		slash = "/"
+	}
+	return fmt.Sprintf("%s%s#/images/", aquaServer, slash)
+}
+
+func buildTemplate(template *Template) (data.Inpteval, error) {
+	logging.Infof("Configuring template %s", template.Name)
+	var inpteval data.Inpteval
+
+	if template.LegacyScanRenderer != "" {
+		legacy, err := formatting.BuildLegacyScnEvaluator(template.LegacyScanRenderer)
+		if err != nil {
+			return nil, err
+		}
+		inpteval = legacy
+		logging.Infof("Configured with legacy renderer %s", template.LegacyScanRenderer)
+	}
+
+	if template.RegoPackage != "" {
+		bundled, err := regoservice.BuildBundledRegoEvaluator(template.RegoPackage)
+		if err != nil {
+			return nil, err
+		}
+		inpteval = bundled
+		logging.Infof("Configured with Rego package %s", template.RegoPackage)
+	}
+	if template.Url != "" {
+		logging.Infof("Configured with url: %s", template.Url)
+
+		r, err := http.NewRequest("GET", template.Url, nil)
+		if err != nil {
+			return nil, err
+		}
+		httpClient := getHttpClient()
+		resp, err := httpClient.Do(r)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode > 399 {
+			return nil, errors.New(fmt.Sprintf("can not connect to %s, response status is %d", template.Url, resp.StatusCode))
+		}
+
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		external, err := regoservice.BuildExternalRegoEvaluator(path.Base(r.URL.Path), string(b))
+
+		if err != nil {
+			return nil, err
+		}
+
+		inpteval = external
+	}
+	//body goes last to provide an option to keep body in config but not use it
+	if template.Body != "" {
+		inline, err := regoservice.BuildExternalRegoEvaluator("inline.rego", template.Body)
+		if err != nil {
+			return nil, err
+		}
+		inpteval = inline
+	}
+	return inpteval, nil
+}
+
+func joinErrors(errs []error) error {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
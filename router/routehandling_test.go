@@ -1,8 +1,13 @@
 package router
 
 import (
+	"context"
+	"reflect"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/aquasecurity/postee/v2/msgservice"
 )
 
 var (
@@ -262,7 +267,11 @@ func runTestRouteHandlingCase(t *testing.T, caseDesc string, cfg string, expctdI
 		t.Fatalf("[%s] Unexpected error %v", caseDesc, err)
 	}
 
-	wrap.instance.handle([]byte(payload))
+	go func() {
+		for _, routeName := range wrap.instance.routeNames() {
+			wrap.instance.HandleRouteAndWait(context.Background(), routeName, []byte(payload))
+		}
+	}()
 	timeoutDuration := 3 * time.Second
 	if len(expctdInvctns) == 0 {
 		timeoutDuration = time.Second
@@ -313,7 +322,9 @@ func TestInvalidRouteName(t *testing.T) {
 		t.Fatalf("Unexpected error %v", err)
 	}
 
-	wrap.instance.HandleRoute("not-exist", []byte(payload))
+	if result := wrap.instance.HandleRouteAndWait(context.Background(), "not-exist", []byte(payload)); result.Error != ErrUnknownRoute.Error() {
+		t.Errorf("Unexpected result: %+v", result)
+	}
 	timeout := time.After(1 * time.Second)
 	for {
 		select {
@@ -342,7 +353,7 @@ func TestSend(t *testing.T) {
 		t.Fatalf("Unexpected error %v", err)
 	}
 
-	wrap.instance.Send([]byte(payload))
+	wrap.instance.Send(context.Background(), []byte(payload))
 	timeout := time.After(1 * time.Second)
 	for {
 		select {
@@ -357,3 +368,125 @@ func TestSend(t *testing.T) {
 		}
 	}
 }
+
+func TestSendAndWait(t *testing.T) {
+	tests := []struct {
+		caseDesc        string
+		cfg             string
+		route           string
+		expectedResults []RouteResult
+		expectedError   error
+	}{
+		{
+			"Single route",
+			singleRoute,
+			"",
+			[]RouteResult{
+				{Route: "route1", Matched: true, Outputs: []OutputResult{
+					{Output: "my-slack", Result: msgservice.Result{Status: msgservice.StatusDelivered}},
+				}},
+			},
+			nil,
+		},
+		{
+			"2 Routes",
+			twoRoutes,
+			"route2",
+			[]RouteResult{
+				{Route: "route2", Matched: true, Outputs: []OutputResult{
+					{Output: "my-slack", Result: msgservice.Result{Status: msgservice.StatusDelivered}},
+				}},
+			},
+			nil,
+		},
+		{
+			"Invalid Output reference",
+			invalidOutput,
+			"",
+			[]RouteResult{
+				{Route: "route1", Outputs: []OutputResult{
+					{Output: "x-slack", Result: msgservice.Result{Status: StatusMisconfigured, Error: "output isn't enabled"}},
+				}},
+			},
+			nil,
+		},
+		{
+			"Unknown route",
+			singleRoute,
+			"not-exist",
+			nil,
+			ErrUnknownRoute,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.caseDesc, func(t *testing.T) {
+			wrap := ctxWrapper{}
+			wrap.setup(test.cfg)
+			defer wrap.teardown()
+
+			if err := wrap.instance.Start(wrap.cfgPath); err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			go func() {
+				for range wrap.buff {
+				}
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			results, err := wrap.instance.SendAndWait(ctx, test.route, []byte(payload))
+			if err != test.expectedError {
+				t.Fatalf("Unexpected error, expected %v, got %v", test.expectedError, err)
+			}
+			if !reflect.DeepEqual(results, test.expectedResults) {
+				t.Errorf("Unexpected results\nexpected: %+v\ngot: %+v", test.expectedResults, results)
+			}
+		})
+	}
+}
+
+func TestSendAllowedRoutes(t *testing.T) {
+	wrap := ctxWrapper{}
+	wrap.setup(twoRoutes)
+	defer wrap.teardown()
+
+	if err := wrap.instance.Start(wrap.cfgPath); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	go func() {
+		for range wrap.buff {
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	ctx = WithAllowedRoutes(ctx, []string{"route2"})
+
+	results, err := wrap.instance.SendAndWait(ctx, "", []byte(payload))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(results) != 1 || results[0].Route != "route2" {
+		t.Errorf("Only allowed route should handle the message: %+v", results)
+	}
+	if _, err := wrap.instance.SendAndWait(ctx, "route1", []byte(payload)); err != ErrForbidden {
+		t.Errorf("Unexpected error, expected %v, got %v", ErrForbidden, err)
+	}
+	if err := wrap.instance.SendToRoute(ctx, "route1", []byte(payload)); err != ErrForbidden {
+		t.Errorf("Unexpected error, expected %v, got %v", ErrForbidden, err)
+	}
+}
+
+func TestSendNotRunning(t *testing.T) {
+	r := &Router{queue: make(chan *input, 1)}
+	if err := r.Send(context.Background(), []byte(payload)); err != ErrNotRunning {
+		t.Errorf("Unexpected error, expected %v, got %v", ErrNotRunning, err)
+	}
+	atomic.StoreInt32(&r.running, 1)
+	if err := r.Send(context.Background(), []byte(payload)); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if err := r.Send(context.Background(), []byte(payload)); err != ErrQueueFull {
+		t.Errorf("Unexpected error, expected %v, got %v", ErrQueueFull, err)
+	}
+}
@@ -2,23 +2,25 @@ package router
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"log"
 	"net/http"
-	"path"
-	"strings"
+	"reflect"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aquasecurity/postee/v2/data"
 	"github.com/aquasecurity/postee/v2/dbservice"
-	"github.com/aquasecurity/postee/v2/formatting"
+	"github.com/aquasecurity/postee/v2/delivery"
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/aquasecurity/postee/v2/metrics"
 	"github.com/aquasecurity/postee/v2/msgservice"
 	"github.com/aquasecurity/postee/v2/outputs"
-	"github.com/aquasecurity/postee/v2/regoservice"
 	"github.com/aquasecurity/postee/v2/routes"
+	"github.com/aquasecurity/postee/v2/secrets"
 	"github.com/aquasecurity/postee/v2/utils"
 )
 
@@ -28,19 +30,47 @@ const (
 
 	ServiceNowTableDefault = "incident"
 	AnonymizeReplacement   = "<hidden>"
+
+	WorkersDefault = 10
+)
+
+var (
+	ErrQueueFull    = errors.New("routing queue is full")
+	ErrNotRunning   = errors.New("router isn't running")
+	ErrUnknownRoute = errors.New("route isn't defined")
+	ErrForbidden    = errors.New("route isn't allowed for the sender")
 )
 
+// input is an incoming message waiting in the routing queue
+type input struct {
+	ctx     context.Context
+	data    []byte
+	route   string             //empty to handle the message by all routes
+	results chan []RouteResult //nil if the sender doesn't wait for the results
+}
+
 type Router struct {
-	mutexScan   sync.Mutex
-	quit        chan struct{}
-	queue       chan []byte
-	ticker      *time.Ticker
-	stopTicker  chan struct{}
-	cfgfile     string
-	aquaServer  string
-	outputs     map[string]outputs.Output
-	inputRoutes map[string]*routes.InputRoute
-	templates   map[string]data.Inpteval
+	mutexScan        sync.Mutex
+	mutexReload      sync.Mutex
+	running          int32
+	quit             chan struct{}
+	stopWorker       chan struct{}
+	workers          sync.WaitGroup
+	workerCount      int
+	queue            chan *input
+	ticker           *time.Ticker
+	stopTicker       chan struct{}
+	cfgfile          string
+	aquaServer       string
+	outputs          map[string]outputs.Output
+	outputSettings   map[string]OutputSettings
+	inputRoutes      map[string]*routes.InputRoute
+	templates        map[string]data.Inpteval
+	templateSettings map[string]Template
+	ingestAuth       []IngestCredential
+	tenant           string               //empty for the default configuration
+	store            *dbservice.Store     //nil for the default database
+	dispatcher       *delivery.Dispatcher //dispatcher of outputs of the tenant
 }
 
 var (
@@ -55,257 +85,321 @@ var (
 
 func Instance() *Router {
 	initCtx.Do(func() {
-		routerCtx = &Router{
-			mutexScan:   sync.Mutex{},
-			quit:        make(chan struct{}),
-			queue:       make(chan []byte, 1000),
-			outputs:     make(map[string]outputs.Output),
-			inputRoutes: make(map[string]*routes.InputRoute),
-			templates:   make(map[string]data.Inpteval),
-			stopTicker:  make(chan struct{}),
-		}
+		routerCtx = newRouter("", nil, delivery.Instance())
 	})
 	return routerCtx
 }
-func (ctx *Router) ReloadConfig() {
-	ctx.Terminate()
-	err := ctx.Start(ctx.cfgfile)
 
-	if err != nil {
-		log.Printf("Unable to start router: %s", err)
+func newRouter(tenant string, store *dbservice.Store, dispatcher *delivery.Dispatcher) *Router {
+	return &Router{
+		mutexScan:        sync.Mutex{},
+		quit:             make(chan struct{}),
+		stopWorker:       make(chan struct{}),
+		queue:            make(chan *input, 1000),
+		outputs:          make(map[string]outputs.Output),
+		outputSettings:   make(map[string]OutputSettings),
+		inputRoutes:      make(map[string]*routes.InputRoute),
+		templates:        make(map[string]data.Inpteval),
+		templateSettings: make(map[string]Template),
+		stopTicker:       make(chan struct{}),
+		tenant:           tenant,
+		store:            store,
+		dispatcher:       dispatcher,
 	}
 }
 
 func (ctx *Router) Start(cfgfile string) error {
-	log.Printf("Starting Router....")
+	ctx.mutexReload.Lock()
+	defer ctx.mutexReload.Unlock()
+	logging.Infof("Starting Router....")
 
 	ctx.cfgfile = cfgfile
+	ctx.mutexScan.Lock()
 	ctx.outputs = map[string]outputs.Output{}
+	ctx.outputSettings = map[string]OutputSettings{}
 	ctx.inputRoutes = map[string]*routes.InputRoute{}
 	ctx.templates = map[string]data.Inpteval{}
+	ctx.templateSettings = map[string]Template{}
+	ctx.mutexScan.Unlock()
 	ctx.ticker = nil
 
-	err := ctx.load()
+	logging.Infof("Loading alerts configuration file %s ....", ctx.cfgfile)
+	tenant, err := Parsev2cfg(ctx.cfgfile)
 	if err != nil {
 		return err
 	}
-	go ctx.listen()
+	resolver := secrets.NewResolver(context.Background())
+	if errs := interpolate(tenant, resolver); len(errs) > 0 {
+		return joinErrors(errs)
+	}
+	if err := validate(tenant); err != nil {
+		return err
+	}
+	//secrets are tracked before the build, which logs anonymized settings
+	resolver.Apply(ctx.cfgfile)
+	cfg, errs := ctx.build(tenant)
+	for _, err := range errs {
+		logging.Errorf("Can not initialize %v", err)
+	}
+	ctx.quit = make(chan struct{})
+	ctx.apply(tenant, cfg)
+	ctx.dispatcher.Start()
+	atomic.StoreInt32(&ctx.running, 1)
 	return nil
 }
 
 func (ctx *Router) Terminate() {
-	log.Printf("Terminating Router....")
+	logging.Infof("Terminating Router....")
+	if atomic.SwapInt32(&ctx.running, 0) == 1 {
+		close(ctx.quit)
+	}
+	ctx.workerCount = 0
+
+	ctx.dispatcher.Terminate()
+	logging.Infof("Delivery retries and in-flight sends stopped")
+
+	ctx.workers.Wait()
+	logging.Infof("Routing workers stopped")
 
 	for _, pl := range ctx.outputs {
 		err := pl.Terminate()
 		if err != nil {
-			log.Printf("failed to terminate output: %v", err)
+			logging.Errorf("failed to terminate output: %v", err)
 		}
 	}
-	log.Printf("Outputs terminated")
+	logging.Infof("Outputs terminated")
 
 	for _, route := range ctx.inputRoutes {
 		route.StopScheduler()
 	}
-	log.Printf("Route schedulers stopped")
+	logging.Infof("Route schedulers stopped")
 
-	ctx.quit <- struct{}{}
-	log.Printf("quit notified")
 	if ctx.ticker != nil {
 		ctx.stopTicker <- struct{}{}
-		log.Printf("stopTicker notified")
+		ctx.ticker = nil
+		logging.Infof("stopTicker notified")
 	}
 
 }
 
-func (ctx *Router) Send(data []byte) {
-	ctx.queue <- data
+// Send puts a message to the routing queue to be handled by all routes
+func (ctx *Router) Send(c context.Context, data []byte) error {
+	return ctx.enqueue(&input{ctx: c, data: data})
 }
 
-func (ctx *Router) initTemplate(template *Template) error {
-	log.Printf("Configuring template %s \n", template.Name)
-
-	if template.LegacyScanRenderer != "" {
-		inpteval, err := formatting.BuildLegacyScnEvaluator(template.LegacyScanRenderer)
-		if err != nil {
-			return err
-		}
-		ctx.templates[template.Name] = inpteval
-		log.Printf("Configured with legacy renderer %s \n", template.LegacyScanRenderer)
+// SendToRoute puts a message to the routing queue to be handled by a single route
+func (ctx *Router) SendToRoute(c context.Context, route string, data []byte) error {
+	if !ctx.hasRoute(route) {
+		return ErrUnknownRoute
 	}
-
-	if template.RegoPackage != "" {
-		inpteval, err := regoservice.BuildBundledRegoEvaluator(template.RegoPackage)
-		if err != nil {
-			return err
-		}
-		ctx.templates[template.Name] = inpteval
-		log.Printf("Configured with Rego package %s\n", template.RegoPackage)
+	if !isAllowedRoute(c, route) {
+		return ErrForbidden
 	}
-	if template.Url != "" {
-		log.Printf("Configured with url: %s\n", template.Url)
-
-		r, err := http.NewRequest("GET", template.Url, nil)
-		if err != nil {
-			return err
-		}
-		httpClient := getHttpClient()
-		resp, err := httpClient.Do(r)
-		if err != nil {
-			return err
-		}
-
-		if resp.StatusCode > 399 {
-			return errors.New(fmt.Sprintf("can not connect to %s, response status is %d", template.Url, resp.StatusCode))
-		}
+	return ctx.enqueue(&input{ctx: c, data: data, route: route})
+}
 
-		b, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return err
+// SendAndWait puts a message to the routing queue and waits until it's handled by all routes,
+// or by a single route if its name isn't empty, and the first delivery attempts to their outputs are made.
+func (ctx *Router) SendAndWait(c context.Context, route string, data []byte) ([]RouteResult, error) {
+	if route != "" && !ctx.hasRoute(route) {
+		return nil, ErrUnknownRoute
+	}
+	if route != "" && !isAllowedRoute(c, route) {
+		return nil, ErrForbidden
+	}
+	in := &input{ctx: c, data: data, route: route, results: make(chan []RouteResult, 1)}
+	if err := ctx.enqueue(in); err != nil {
+		return nil, err
+	}
+	select {
+	case results := <-in.results:
+		for i := range results {
+			results[i] = results[i].wait(c)
 		}
-		defer resp.Body.Close()
-		inpteval, err := regoservice.BuildExternalRegoEvaluator(path.Base(r.URL.Path), string(b))
-
-		if err != nil {
-			return err
+		if c.Err() != nil {
+			return nil, c.Err()
 		}
+		return results, nil
+	case <-c.Done():
+		return nil, c.Err()
+	}
+}
 
-		ctx.templates[template.Name] = inpteval
+func (ctx *Router) enqueue(in *input) error {
+	if atomic.LoadInt32(&ctx.running) == 0 {
+		return ErrNotRunning
 	}
-	//body goes last to provide an option to keep body in config but not use it
-	if template.Body != "" {
-		inpteval, err := regoservice.BuildExternalRegoEvaluator("inline.rego", template.Body)
-		if err != nil {
-			return err
-		}
-		ctx.templates[template.Name] = inpteval
+	select {
+	case ctx.queue <- in:
+		metrics.RoutingQueueDepth(ctx.tenant, len(ctx.queue))
+		return nil
+	default:
+		return ErrQueueFull
 	}
-	return nil
 }
 
-func (ctx *Router) load() error {
-	ctx.mutexScan.Lock()
-	defer ctx.mutexScan.Unlock()
-	log.Printf("Loading alerts configuration file %s ....\n", ctx.cfgfile)
-	tenant, err := Parsev2cfg(ctx.cfgfile)
+// Dispatcher returns the dispatcher of outputs of the router
+func (ctx *Router) Dispatcher() *delivery.Dispatcher {
+	return ctx.dispatcher
+}
 
-	if err != nil {
-		return err
-	}
+// Store returns the database of the router, nil for the default database
+func (ctx *Router) Store() *dbservice.Store {
+	return ctx.store
+}
 
-	if len(tenant.AquaServer) > 0 {
-		var slash string
-		if !strings.HasSuffix(tenant.AquaServer, "/") {
-			slash = "/"
-		}
-		ctx.aquaServer = fmt.Sprintf("%s%s#/images/", tenant.AquaServer, slash)
-	}
-
-	dbservice.DbSizeLimit = tenant.DBMaxSize
-	if tenant.DBTestInterval == 0 {
-		tenant.DBTestInterval = 1
-	}
-	ctx.ticker = time.NewTicker(baseForTicker * time.Duration(tenant.DBTestInterval))
-	go func() {
-		for {
-			select {
-			case <-ctx.stopTicker:
-				return
-			case <-ctx.ticker.C:
-				dbservice.CheckSizeLimit()
-				dbservice.CheckExpiredData()
-			}
-		}
-	}()
+func (ctx *Router) hasRoute(name string) bool {
+	ctx.mutexScan.Lock()
+	defer ctx.mutexScan.Unlock()
+	_, ok := ctx.inputRoutes[name]
+	return ok
+}
 
-	for i, r := range tenant.InputRoutes {
-		ctx.inputRoutes[r.Name] = routes.ConfigureTimeouts(&tenant.InputRoutes[i])
-	}
-	for _, t := range tenant.Templates {
-		err := ctx.initTemplate(&t)
-		if err != nil {
-			log.Printf("Can not initialize template %s: %v \n", t.Name, err)
-		}
+func (ctx *Router) routeNames() []string {
+	ctx.mutexScan.Lock()
+	defer ctx.mutexScan.Unlock()
+	names := make([]string, 0, len(ctx.inputRoutes))
+	for routeName := range ctx.inputRoutes {
+		names = append(names, routeName)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	for _, settings := range tenant.Outputs {
-		utils.Debug("%#v\n", anonymizeSettings(&settings))
-
-		if settings.Enable {
-			plg := BuildAndInitOtpt(&settings, ctx.aquaServer)
-			if plg != nil {
-				log.Printf("Output %s is configured", settings.Name)
-				ctx.outputs[settings.Name] = plg
-			}
+// allowedRouteNames returns the routes which may handle messages sent with a context, see WithAllowedRoutes
+func (ctx *Router) allowedRouteNames(c context.Context) []string {
+	names := make([]string, 0)
+	for _, name := range ctx.routeNames() {
+		if isAllowedRoute(c, name) {
+			names = append(names, name)
 		}
 	}
-	return nil
+	return names
 }
 
 type service interface {
-	MsgHandling(input []byte, output outputs.Output, route *routes.InputRoute, inpteval data.Inpteval, aquaServer *string)
+	MsgHandling(ctx context.Context, input []byte, output outputs.Output, route *routes.InputRoute, inpteval data.Inpteval, aquaServer *string) msgservice.Result
 }
 
-var getScanService = func() service {
-	serv := &msgservice.MsgService{}
+var getScanService = func(store *dbservice.Store, dispatcher *delivery.Dispatcher) service {
+	serv := &msgservice.MsgService{Store: store, Dispatcher: dispatcher}
 	return serv
 }
 var getHttpClient = func() *http.Client {
 	return http.DefaultClient
 }
 
-func (ctx *Router) HandleRoute(routeName string, in []byte) {
+// HandleRouteAndWait handles a message by a route and returns the results of all its outputs
+// once the first delivery attempts are made
+func (ctx *Router) HandleRouteAndWait(c context.Context, routeName string, in []byte) RouteResult {
+	return ctx.routeMessage(c, routeName, in).wait(c)
+}
+
+// routeMessage handles a message by a route. Messages for outputs are put to their delivery queues,
+// results of the outputs are queued until the first delivery attempts, see RouteResult.wait.
+func (ctx *Router) routeMessage(c context.Context, routeName string, in []byte) RouteResult {
+	logger := logging.FromContext(c).With("route", routeName)
+	result := RouteResult{Route: routeName}
+	ctx.mutexScan.Lock()
 	r, ok := ctx.inputRoutes[routeName]
+	running, templates, aquaServer := ctx.outputs, ctx.templates, ctx.aquaServer
+	ctx.mutexScan.Unlock()
 	if !ok || r == nil {
-		log.Printf("There isn't route %q", routeName)
-		return
+		logger.Warnf("There isn't route %q", routeName)
+		result.Error = ErrUnknownRoute.Error()
+		return result
 	}
 	if len(r.Outputs) == 0 {
-		log.Printf("route %q has no outputs", routeName)
-		return
+		logger.Warnf("route %q has no outputs", routeName)
+		result.Error = "route has no outputs"
+		return result
 	}
-	for _, outputName := range r.Outputs {
-		pl, ok := ctx.outputs[outputName]
+	result.Outputs = make([]OutputResult, len(r.Outputs))
+	wg := sync.WaitGroup{}
+	for i, outputName := range r.Outputs {
+		result.Outputs[i].Output = outputName
+		pl, ok := running[outputName]
 		if !ok {
-			log.Printf("route %q contains an output %q, which doesn't enable now.", routeName, outputName)
+			logger.Warnf("route %q contains an output %q, which doesn't enable now.", routeName, outputName)
+			result.Outputs[i].Status = StatusMisconfigured
+			result.Outputs[i].Error = "output isn't enabled"
 			continue
 		}
-		tmpl, ok := ctx.templates[r.Template]
+		tmpl, ok := templates[r.Template]
 		if !ok {
-			log.Printf("route %q contains reference to undefined or misconfigured template %q.",
+			logger.Warnf("route %q contains reference to undefined or misconfigured template %q.",
 				routeName, r.Template)
+			result.Outputs[i].Status = StatusMisconfigured
+			result.Outputs[i].Error = fmt.Sprintf("undefined or misconfigured template %q", r.Template)
+			continue
+		}
+		logger.Debugf("route %q is associated with template %q", routeName, r.Template)
+		wg.Add(1)
+		go func(i int, pl outputs.Output, tmpl data.Inpteval) {
+			defer wg.Done()
+			result.Outputs[i].Result = getScanService(ctx.store, ctx.dispatcher).MsgHandling(c, in, pl, r, tmpl, &aquaServer)
+		}(i, pl, tmpl)
+	}
+	wg.Wait()
+	evaluated := false
+	for _, o := range result.Outputs {
+		if o.Status == StatusMisconfigured || o.Status == "" {
 			continue
 		}
-		log.Printf("route %q is associated with template %q", routeName, r.Template)
-		go getScanService().MsgHandling(in, pl, r, tmpl, &ctx.aquaServer)
+		evaluated = true
+		if o.IsMatched() {
+			result.Matched = true
+		}
+	}
+	if evaluated {
+		metrics.RouteHandled(ctx.tenant, routeName, result.Matched)
 	}
+	return result
 }
 
-func (ctx *Router) handle(in []byte) {
-	for routeName := range ctx.inputRoutes {
-		ctx.HandleRoute(routeName, in)
+// routeAll handles a message by all routes allowed for the sender, see routeMessage
+func (ctx *Router) routeAll(c context.Context, in []byte) []RouteResult {
+	names := ctx.allowedRouteNames(c)
+
+	results := make([]RouteResult, len(names))
+	wg := sync.WaitGroup{}
+	for i, routeName := range names {
+		wg.Add(1)
+		go func(i int, routeName string) {
+			defer wg.Done()
+			results[i] = ctx.routeMessage(c, routeName, in)
+		}(i, routeName)
 	}
+	wg.Wait()
+	return results
 }
 func BuildAndInitOtpt(settings *OutputSettings, aquaServerUrl string) outputs.Output {
-	settings.User = utils.GetEnvironmentVarOrPlain(settings.User)
-	if len(settings.User) == 0 && requireAuthorization[settings.Type] {
-		log.Printf("User for %q is empty", settings.Name)
+	if errs := interpolateFields(reflect.ValueOf(settings).Elem(), secrets.NewResolver(context.Background())); len(errs) > 0 {
+		logging.Errorf("Can not resolve settings of %q: %v", settings.Name, joinErrors(errs))
 		return nil
 	}
-	settings.Password = utils.GetEnvironmentVarOrPlain(settings.Password)
-	if len(settings.Password) == 0 && requireAuthorization[settings.Type] {
-		log.Printf("Password for %q is empty", settings.Name)
+	plg, err := buildOutput(settings, aquaServerUrl)
+	if err != nil {
+		logging.Errorf("%v", err)
 		return nil
 	}
-	settings.Token = utils.GetEnvironmentVarOrPlain(settings.Token)
+	return plg
+}
+
+// buildOutput builds an output from interpolated settings
+func buildOutput(settings *OutputSettings, aquaServerUrl string) (outputs.Output, error) {
+	if len(settings.User) == 0 && requireAuthorization[settings.Type] {
+		return nil, fmt.Errorf("User for %q is empty", settings.Name)
+	}
+	if len(settings.Password) == 0 && requireAuthorization[settings.Type] {
+		return nil, fmt.Errorf("Password for %q is empty", settings.Name)
+	}
 	if settings.Type == "jira" {
 		if len(settings.User) == 0 {
-			log.Printf("User for %q is empty", settings.Name)
-			return nil
+			return nil, fmt.Errorf("User for %q is empty", settings.Name)
 		}
 		if len(settings.Token) == 0 && len(settings.Password) == 0 {
-			log.Printf("Password and Token for %q are empty", settings.Name)
-			return nil
+			return nil, fmt.Errorf("Password and Token for %q are empty", settings.Name)
 		}
 	}
 
@@ -330,26 +424,76 @@ func BuildAndInitOtpt(settings *OutputSettings, aquaServerUrl string) outputs.Ou
 		plg = buildSplunkOutput(settings)
 	case "stdout":
 		plg = buildStdoutOutput(settings)
+	case "pagerduty":
+		plg = buildPagerDutyOutput(settings)
+	case "opsgenie":
+		plg = buildOpsgenieOutput(settings)
+	case "http":
+		plg = buildHttpOutput(settings)
+	case "kafka":
+		plg = buildKafkaOutput(settings)
+	case "syslog":
+		plg = buildSyslogOutput(settings)
 	default:
-		log.Printf("Output type %q is undefined or empty. Output name is %q.",
+		return nil, fmt.Errorf("Output type %q is undefined or empty. Output name is %q.",
 			settings.Type, settings.Name)
-		return nil
 	}
-	err := plg.Init()
-	if err != nil {
-		log.Printf("failed to Init : %v", err)
+	if err := plg.Init(); err != nil {
+		return nil, fmt.Errorf("failed to Init : %w", err)
 	}
 
-	return plg
+	return plg, nil
+}
+
+func buildDeliveryOptions(settings *OutputSettings) delivery.Options {
+	return delivery.Options{
+		Type:             settings.Type,
+		Retry:            buildRetryPolicy(settings),
+		Timeout:          buildTimeout(settings),
+		MaxConcurrency:   settings.MaxConcurrency,
+		OrderingKey:      buildOrderingKey(settings),
+		RateLimiter:      buildRateLimiter(settings),
+		BreakerThreshold: buildBreakerThreshold(settings),
+		BreakerCooldown:  parseDuration(settings.Name, "circuit-breaker-cooldown", settings.BreakerCooldown),
+	}
 }
 
+// listen is a routing worker, it handles messages from the routing queue one by one
 func (ctx *Router) listen() {
+	defer ctx.workers.Done()
 	for {
 		select {
 		case <-ctx.quit:
 			return
-		case data := <-ctx.queue:
-			go ctx.handle(bytes.ReplaceAll(data, []byte{'`'}, []byte{'\''}))
+		case <-ctx.stopWorker:
+			return
+		case in := <-ctx.queue:
+			metrics.RoutingQueueDepth(ctx.tenant, len(ctx.queue))
+			metrics.WorkerBusy(ctx.tenant)
+			ctx.process(in)
+			metrics.WorkerIdle(ctx.tenant)
 		}
 	}
 }
+
+func (ctx *Router) process(in *input) {
+	metrics.MessageReceived(ctx.tenant)
+	c := in.ctx
+	if c == nil {
+		c = context.Background()
+	}
+	if ctx.tenant != "" {
+		c = logging.WithTenant(c, ctx.tenant)
+	}
+	logging.FromContext(c).Debugf("Message is taken from the routing queue")
+	data := bytes.ReplaceAll(in.data, []byte{'`'}, []byte{'\''})
+	var results []RouteResult
+	if in.route == "" {
+		results = ctx.routeAll(c, data)
+	} else {
+		results = []RouteResult{ctx.routeMessage(c, in.route, data)}
+	}
+	if in.results != nil {
+		in.results <- results
+	}
+}
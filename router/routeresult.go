@@ -0,0 +1,31 @@
+package router
+
+import (
+	"context"
+
+	"github.com/aquasecurity/postee/v2/msgservice"
+)
+
+// StatusMisconfigured is reported for outputs which can't be used because of the configuration
+const StatusMisconfigured = "misconfigured"
+
+type OutputResult struct {
+	Output string `json:"output"`
+	msgservice.Result
+}
+
+// RouteResult is a summary of handling a message by a single route
+type RouteResult struct {
+	Route   string         `json:"route"`
+	Matched bool           `json:"matched"`
+	Error   string         `json:"error,omitempty"`
+	Outputs []OutputResult `json:"outputs,omitempty"`
+}
+
+// wait waits for the first delivery attempts of messages which are queued for outputs of the route
+func (r RouteResult) wait(c context.Context) RouteResult {
+	for i := range r.Outputs {
+		r.Outputs[i].Result = r.Outputs[i].Wait(c)
+	}
+	return r
+}
@@ -0,0 +1,224 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+const schemaId = "https://github.com/aquasecurity/postee/blob/main/cfg.schema.json"
+
+// emailServerOptions are required for email outputs which don't use MX servers of recipients
+var emailServerOptions = []string{"host", "port"}
+
+// Schema returns JSON Schema of the configuration file. It's generated from TenantSettings,
+// so the schema and the options which are read by Postee can't diverge.
+func Schema() map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(TenantSettings{}))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["$id"] = schemaId
+	schema["title"] = "Postee configuration"
+
+	props := schema["properties"].(map[string]interface{})
+	props["log-level"].(map[string]interface{})["enum"] = []interface{}{"debug", "info", "warn", "error", nil}
+	props["log-format"].(map[string]interface{})["enum"] = []interface{}{"text", "json", nil}
+
+	output := props["outputs"].(map[string]interface{})["items"].(map[string]interface{})
+	output["required"] = []string{"name", "type"}
+	output["properties"].(map[string]interface{})["type"].(map[string]interface{})["enum"] = outputTypes()
+	output["allOf"] = outputRules()
+
+	route := props["routes"].(map[string]interface{})["items"].(map[string]interface{})
+	route["required"] = []string{"name"}
+	template := props["templates"].(map[string]interface{})["items"].(map[string]interface{})
+	template["required"] = []string{"name"}
+	credential := props["ingest-auth"].(map[string]interface{})["items"].(map[string]interface{})
+	credential["required"] = []string{"name", "type"}
+	credential["properties"].(map[string]interface{})["type"].(map[string]interface{})["enum"] = []string{CredentialBearer, CredentialClientCert, CredentialHmac}
+	credential["properties"].(map[string]interface{})["algorithm"].(map[string]interface{})["enum"] = []interface{}{"sha1", "sha256", "sha512", nil}
+	return schema
+}
+
+// SchemaJson returns indented JSON Schema of the configuration file
+func SchemaJson() ([]byte, error) {
+	return json.MarshalIndent(Schema(), "", "  ")
+}
+
+// typeSchema returns schema of a Go type. Empty options are null in yaml, e.g. "url:", so null is allowed everywhere.
+func typeSchema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.Struct:
+		props := make(map[string]interface{})
+		for _, f := range optionFields(t) {
+			props[f.key] = typeSchema(f.typ)
+		}
+		return map[string]interface{}{"type": []string{"object", "null"}, "properties": props, "additionalProperties": false}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": []string{"array", "null"}, "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": []string{"object", "null"}, "additionalProperties": typeSchema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": []string{"string", "null"}}
+	case reflect.Bool:
+		return map[string]interface{}{"type": []string{"boolean", "null"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": []string{"integer", "null"}}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": []string{"number", "null"}}
+	}
+	return map[string]interface{}{}
+}
+
+func outputTypes() []string {
+	types := make([]string, 0, len(requiredOptions))
+	for outputType := range requiredOptions {
+		types = append(types, outputType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// outputRules makes options from requiredOptions required for enabled outputs of every type
+func outputRules() []interface{} {
+	enabled := func(outputType string) map[string]interface{} {
+		return map[string]interface{}{
+			"properties": map[string]interface{}{
+				"type":   map[string]interface{}{"const": outputType},
+				"enable": map[string]interface{}{"const": true},
+			},
+			"required": []string{"type", "enable"},
+		}
+	}
+	rules := make([]interface{}, 0)
+	for _, outputType := range outputTypes() {
+		required := make([]string, 0)
+		alternatives := make([]interface{}, 0)
+		for _, options := range requiredOptions[outputType] {
+			names := strings.Split(options, "|")
+			if len(names) == 1 {
+				required = append(required, options)
+				continue
+			}
+			anyOf := make([]interface{}, 0, len(names))
+			for _, name := range names {
+				anyOf = append(anyOf, map[string]interface{}{"required": []string{name}})
+			}
+			alternatives = append(alternatives, map[string]interface{}{"anyOf": anyOf})
+		}
+		if len(required) == 0 && len(alternatives) == 0 {
+			continue
+		}
+		then := make(map[string]interface{})
+		if len(required) > 0 {
+			then["required"] = required
+		}
+		if len(alternatives) > 0 {
+			then["allOf"] = alternatives
+		}
+		rules = append(rules, map[string]interface{}{"if": enabled(outputType), "then": then})
+	}
+
+	withoutMX := enabled("email")
+	withoutMX["not"] = map[string]interface{}{
+		"properties": map[string]interface{}{"use-mx": map[string]interface{}{"const": true}},
+		"required":   []string{"use-mx"},
+	}
+	rules = append(rules, map[string]interface{}{"if": withoutMX, "then": map[string]interface{}{"required": emailServerOptions}})
+	return rules
+}
+
+type optionField struct {
+	name string
+	key  string
+	typ  reflect.Type
+}
+
+// optionFields returns fields of a settings struct which can be set in the configuration file.
+// Fields without json tag are computed at runtime.
+func optionFields(t reflect.Type) []optionField {
+	fields := make([]optionField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := strings.Split(f.Tag.Get("json"), ",")[0]
+		if f.PkgPath != "" || key == "" || key == "-" {
+			continue
+		}
+		fields = append(fields, optionField{f.Name, key, f.Type})
+	}
+	return fields
+}
+
+// UnknownKeys returns paths of options in a configuration file which aren't known by Postee, e.g. `outputs[my-jira].enabled`.
+// Items of lists are named by their names if they have them.
+func UnknownKeys(data []byte) ([]string, error) {
+	j, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(j, &v); err != nil {
+		return nil, err
+	}
+	keys := unknownKeys(v, reflect.TypeOf(TenantSettings{}), "")
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func unknownKeys(v interface{}, t reflect.Type, path string) []string {
+	keys := make([]string, 0)
+	switch t.Kind() {
+	case reflect.Ptr:
+		return unknownKeys(v, t.Elem(), path)
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return keys
+		}
+		fields := make(map[string]reflect.Type)
+		for _, f := range optionFields(t) {
+			fields[f.key] = f.typ
+		}
+		for key, value := range m {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			ft, ok := fields[key]
+			if !ok {
+				keys = append(keys, keyPath)
+				continue
+			}
+			keys = append(keys, unknownKeys(value, ft, keyPath)...)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := v.([]interface{})
+		if !ok {
+			return keys
+		}
+		for i, item := range items {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if m, ok := item.(map[string]interface{}); ok {
+				if name, ok := m["name"].(string); ok && name != "" {
+					itemPath = fmt.Sprintf("%s[%s]", path, name)
+				}
+			}
+			keys = append(keys, unknownKeys(item, t.Elem(), itemPath)...)
+		}
+	case reflect.Map:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return keys
+		}
+		for key, value := range m {
+			keys = append(keys, unknownKeys(value, t.Elem(), path+"."+key)...)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,77 @@
+package router
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSchemaIsPublished(t *testing.T) {
+	published, err := ioutil.ReadFile("../cfg.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := SchemaJson()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(published)) != string(generated) {
+		t.Error("cfg.schema.json is outdated, run `postee schema > cfg.schema.json`")
+	}
+}
+
+func TestUnknownKeys(t *testing.T) {
+	cfg := `
+name: tenant
+aqua-srever: https://demolab.aquasec.com
+routes:
+- name: route1
+  outputs: ["my-jira"]
+  plugins:
+    aggregate-message-timout: 1h
+    unique-message-props: ["digest"]
+- input: contains(input.image, "alpine")
+  plugins:
+    AggregateTimeoutSeconds: 5
+outputs:
+- name: my-jira
+  type: jira
+  enabled: true
+  unknowns:
+    custom-field: value
+templates:
+- name: raw
+  body: input
+`
+	expected := []string{
+		"aqua-srever",
+		"outputs[my-jira].enabled",
+		"routes[1].plugins.AggregateTimeoutSeconds",
+		"routes[route1].plugins.aggregate-message-timout",
+	}
+	unknown, err := UnknownKeys([]byte(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(unknown, expected) {
+		t.Errorf("Unexpected unknown keys. Expected %v, got %v", expected, unknown)
+	}
+
+	cfgPath := "cfg_unknown_keys.yaml"
+	if err := ioutil.WriteFile(cfgPath, []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(cfgPath)
+	if _, err := Parsev2cfg(cfgPath); err != nil {
+		t.Errorf("Unknown keys should be ignored by default: %v", err)
+	}
+	StrictConfig = true
+	defer func() {
+		StrictConfig = false
+	}()
+	if _, err := Parsev2cfg(cfgPath); err == nil {
+		t.Error("Unknown keys should be an error for strict config")
+	}
+}
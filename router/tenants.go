@@ -5,11 +5,20 @@ import (
 )
 
 type TenantSettings struct {
+	Name            string              `json:"name,omitempty"`
 	AquaServer      string              `json:"aqua-server,omitempty"`
 	DBMaxSize       int                 `json:"max-db-size,omitempty"`
 	DBRemoveOldData int                 `json:"delete-old-data,omitempty"`
 	DBTestInterval  int                 `json:"db-verify-interval,omitempty"`
+	LogLevel        string              `json:"log-level,omitempty"`
+	LogFormat       string              `json:"log-format,omitempty"`
+	MaxWorkers      int                 `json:"max-workers,omitempty"`
 	Outputs         []OutputSettings    `json:"outputs"`
 	InputRoutes     []routes.InputRoute `json:"routes"`
 	Templates       []Template          `json:"templates"`
+	Include         []string            `json:"include,omitempty"`
+	IngestAuth      []IngestCredential  `json:"ingest-auth,omitempty"`
+
+	// Sources maps items of a configuration split across files to their files, e.g. "route/route1" to "teams/a.yaml"
+	Sources map[string]string `json:"-"`
 }
@@ -0,0 +1,258 @@
+package router
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/aquasecurity/postee/v2/outputs"
+	"github.com/aquasecurity/postee/v2/regoservice"
+	"github.com/aquasecurity/postee/v2/routes"
+)
+
+// requiredOptions lists options which an output of a type can't work without, alternatives are separated by "|"
+var requiredOptions = map[string][]string{
+	"jira":       {"url", "project-key", "user", "password|token"},
+	"email":      {"sender", "recipients"},
+	"slack":      {"url"},
+	"teams":      {"url"},
+	"serviceNow": {"user", "password", "instance"},
+	"webhook":    {"url"},
+	"splunk":     {"url", "token"},
+	"stdout":     {},
+	"pagerduty":  {"routing-key"},
+	"opsgenie":   {"token"},
+	"http":       {"url"},
+	"kafka":      {"brokers", "topic"},
+	"syslog":     {"host"},
+}
+
+// validate checks errors which make a configuration unusable
+func validate(tenant *TenantSettings) error {
+	if errs := checkNames(tenant); len(errs) > 0 {
+		return joinErrors(errs)
+	}
+	return nil
+}
+
+// Validate checks a configuration without starting it: names, references between routes, outputs and templates,
+// Rego criteria of routes, templates, timeouts, required options of outputs and ingest credentials.
+// Errors point at the config items, e.g. `route "route1": output "my-slack" isn't defined`.
+func Validate(tenant *TenantSettings) []error {
+	errs := checkNames(tenant)
+
+	if tenant.LogLevel != "" {
+		if _, err := logging.ParseLevel(tenant.LogLevel); err != nil {
+			errs = append(errs, fmt.Errorf("log-level: %w", err))
+		}
+	}
+	if f := strings.ToLower(tenant.LogFormat); f != "" && f != logging.FormatText && f != logging.FormatJson {
+		errs = append(errs, fmt.Errorf("log-format: unknown log format %q", tenant.LogFormat))
+	}
+
+	definedOutputs := make(map[string]bool)
+	for i := range tenant.Outputs {
+		definedOutputs[tenant.Outputs[i].Name] = true
+		for _, err := range checkOutput(&tenant.Outputs[i]) {
+			errs = append(errs, fmt.Errorf("output %q%s: %w", tenant.Outputs[i].Name, tenant.from("output", tenant.Outputs[i].Name), err))
+		}
+	}
+
+	definedTemplates := make(map[string]bool)
+	for i := range tenant.Templates {
+		t := &tenant.Templates[i]
+		//a template without a source can be kept in a config as a placeholder, routes can't use it
+		definedTemplates[t.Name] = t.Body != "" || t.RegoPackage != "" || t.LegacyScanRenderer != "" || t.Url != ""
+		if err := checkTemplate(&tenant.Templates[i]); err != nil {
+			errs = append(errs, fmt.Errorf("template %q%s: %w", t.Name, tenant.from("template", t.Name), err))
+		}
+	}
+
+	for i := range tenant.InputRoutes {
+		r := &tenant.InputRoutes[i]
+		routeErrs := routes.CheckTimeouts(r)
+		if len(r.Outputs) == 0 {
+			routeErrs = append(routeErrs, fmt.Errorf("no outputs"))
+		}
+		for _, name := range r.Outputs {
+			if !definedOutputs[name] {
+				routeErrs = append(routeErrs, fmt.Errorf("output %q isn't defined", name))
+			}
+		}
+		if r.Template == "" {
+			routeErrs = append(routeErrs, fmt.Errorf("no template"))
+		} else if defined, ok := definedTemplates[r.Template]; !ok {
+			routeErrs = append(routeErrs, fmt.Errorf("template %q isn't defined", r.Template))
+		} else if !defined {
+			routeErrs = append(routeErrs, fmt.Errorf("template %q has no 'body', 'rego-package', 'legacy-scan-renderer' or 'url'", r.Template))
+		}
+		if r.Plugins.AggregateMessageNumber < 0 {
+			routeErrs = append(routeErrs, fmt.Errorf("'aggregate-message-number' can't be negative"))
+		}
+		if err := regoservice.CompileRegoCriteria(r.InputFiles, r.Input); err != nil {
+			routeErrs = append(routeErrs, fmt.Errorf("invalid input: %w", err))
+		}
+		for _, err := range routeErrs {
+			errs = append(errs, fmt.Errorf("route %q%s: %w", r.Name, tenant.from("route", r.Name), err))
+		}
+	}
+
+	definedRoutes := make(map[string]bool)
+	for _, r := range tenant.InputRoutes {
+		definedRoutes[r.Name] = true
+	}
+	for i := range tenant.IngestAuth {
+		c := &tenant.IngestAuth[i]
+		for _, err := range checkCredential(c, definedRoutes) {
+			errs = append(errs, fmt.Errorf("credential %q%s: %w", c.Name, tenant.from("credential", c.Name), err))
+		}
+	}
+	return errs
+}
+
+func checkNames(tenant *TenantSettings) []error {
+	errs := make([]error, 0)
+	check := func(kind string, names []string) {
+		seen := make(map[string]bool)
+		for i, name := range names {
+			if name == "" {
+				errs = append(errs, fmt.Errorf("%s #%d without name", kind, i+1))
+				continue
+			}
+			if seen[name] {
+				errs = append(errs, fmt.Errorf("%s %q is defined more than once", kind, name))
+			}
+			seen[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(tenant.Outputs))
+	for _, o := range tenant.Outputs {
+		names = append(names, o.Name)
+	}
+	check("output", names)
+
+	names = make([]string, 0, len(tenant.InputRoutes))
+	for _, r := range tenant.InputRoutes {
+		names = append(names, r.Name)
+	}
+	check("route", names)
+
+	names = make([]string, 0, len(tenant.Templates))
+	for _, t := range tenant.Templates {
+		names = append(names, t.Name)
+	}
+	check("template", names)
+
+	names = make([]string, 0, len(tenant.IngestAuth))
+	for _, c := range tenant.IngestAuth {
+		names = append(names, c.Name)
+	}
+	check("credential", names)
+	return errs
+}
+
+func checkOutput(settings *OutputSettings) []error {
+	errs := make([]error, 0)
+	required, ok := requiredOptions[settings.Type]
+	if !ok {
+		return append(errs, fmt.Errorf("type %q is undefined or empty", settings.Type))
+	}
+	if !settings.Enable {
+		//disabled outputs aren't started, they are often kept as examples
+		return errs
+	}
+	if settings.Type == "email" && !settings.UseMX {
+		required = append(required, emailServerOptions...)
+	}
+	for _, options := range required {
+		if !hasAnyOption(settings, strings.Split(options, "|")) {
+			errs = append(errs, fmt.Errorf("'%s' is required", strings.ReplaceAll(options, "|", "' or '")))
+		}
+	}
+
+	for option, value := range map[string]string{
+		"timeout":                  settings.Timeout,
+		"retry-backoff":            settings.RetryBackoff,
+		"retry-max-backoff":        settings.RetryMaxBackoff,
+		"circuit-breaker-cooldown": settings.BreakerCooldown,
+		"batch-timeout":            settings.BatchTimeout,
+	} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(value); err != nil {
+			errs = append(errs, fmt.Errorf("can't convert '%s'(%q) to duration", option, value))
+		}
+	}
+	if settings.RateLimit != "" {
+		if _, _, err := parseRate(settings.RateLimit); err != nil {
+			errs = append(errs, fmt.Errorf("'rate-limit'(%q): %w", settings.RateLimit, err))
+		}
+	}
+	switch settings.Type {
+	case "pagerduty":
+		for severity, mapped := range settings.SeverityMap {
+			if !outputs.IsPagerDutySeverity(mapped) {
+				errs = append(errs, fmt.Errorf("'severity-map': %q is mapped to %q, which isn't one of critical, error, warning or info", severity, mapped))
+			}
+		}
+	case "http":
+		if err := buildHttpOutput(settings).Check(); err != nil {
+			errs = append(errs, err)
+		}
+	case "kafka":
+		if err := buildKafkaOutput(settings).Check(); err != nil {
+			errs = append(errs, err)
+		}
+	case "syslog":
+		if err := buildSyslogOutput(settings).Check(); err != nil {
+			errs = append(errs, err)
+		}
+	case "opsgenie":
+		if settings.Priority != "" && !outputs.IsOpsgeniePriority(settings.Priority) {
+			errs = append(errs, fmt.Errorf("'priority'(%q) isn't one of P1, P2, P3, P4 or P5", settings.Priority))
+		}
+		for severity, mapped := range settings.SeverityMap {
+			if !outputs.IsOpsgeniePriority(mapped) {
+				errs = append(errs, fmt.Errorf("'severity-map': %q is mapped to %q, which isn't one of P1, P2, P3, P4 or P5", severity, mapped))
+			}
+		}
+	}
+	return errs
+}
+
+func checkTemplate(template *Template) error {
+	if template.Url != "" && template.Body == "" {
+		//external templates aren't downloaded, so validation works offline
+		u, err := url.Parse(template.Url)
+		if err != nil {
+			return err
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("'url'(%q) should be an http or https URL", template.Url)
+		}
+		offline := *template
+		offline.Url = ""
+		template = &offline
+	}
+	_, err := buildTemplate(template)
+	return err
+}
+
+// hasAnyOption reports whether any of options, given by their yaml names, is set
+func hasAnyOption(settings *OutputSettings, options []string) bool {
+	v := reflect.ValueOf(settings).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		key := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		for _, option := range options {
+			if key == option && !v.Field(i).IsZero() {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package router
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aquasecurity/postee/v2/routes"
+)
+
+func TestValidate(t *testing.T) {
+	validTenant := func() *TenantSettings {
+		return &TenantSettings{
+			InputRoutes: []routes.InputRoute{{
+				Name:     "route1",
+				Input:    `contains(input.image, "alpine")`,
+				Outputs:  []string{"my-slack"},
+				Template: "raw",
+			}},
+			Templates: []Template{
+				{Name: "raw", LegacyScanRenderer: "slack"},
+				{Name: "placeholder"},
+			},
+			Outputs: []OutputSettings{
+				{Name: "my-slack", Type: "slack", Enable: true, Url: "https://hooks.slack.com/services/x"},
+				{Name: "my-jira", Type: "jira"},
+			},
+		}
+	}
+
+	tests := []struct {
+		caseDesc string
+		modify   func(*TenantSettings)
+		expected []string
+	}{
+		{"valid config", func(*TenantSettings) {}, nil},
+		{
+			"undefined references",
+			func(tenant *TenantSettings) {
+				tenant.InputRoutes[0].Outputs = []string{"my-slack", "my-teams"}
+				tenant.InputRoutes[0].Template = "row"
+			},
+			[]string{`route "route1": output "my-teams" isn't defined`, `route "route1": template "row" isn't defined`},
+		},
+		{
+			"template without source",
+			func(tenant *TenantSettings) { tenant.InputRoutes[0].Template = "placeholder" },
+			[]string{`route "route1": template "placeholder" has no 'body', 'rego-package', 'legacy-scan-renderer' or 'url'`},
+		},
+		{
+			"invalid route settings",
+			func(tenant *TenantSettings) {
+				tenant.InputRoutes[0].Input = `contains(input.image, "alpine"`
+				tenant.InputRoutes[0].Plugins.AggregateMessageTimeout = "10x"
+			},
+			[]string{
+				`route "route1": can't convert 'aggregate-message-timeout'("10x") to seconds`,
+				`route "route1": invalid input: `,
+			},
+		},
+		{
+			"invalid template",
+			func(tenant *TenantSettings) { tenant.Templates[0].LegacyScanRenderer = "slak" },
+			[]string{`template "raw": unknown layout type`},
+		},
+		{
+			"missing output options",
+			func(tenant *TenantSettings) {
+				tenant.Outputs[0].Url = ""
+				tenant.Outputs[0].Timeout = "10"
+				tenant.Outputs[1].Enable = true
+				tenant.Outputs[1].Url = "https://jira.example.com"
+				tenant.Outputs[1].User = "admin"
+			},
+			[]string{
+				`output "my-slack": 'url' is required`,
+				`output "my-slack": can't convert 'timeout'("10") to duration`,
+				`output "my-jira": 'project-key' is required`,
+				`output "my-jira": 'password' or 'token' is required`,
+			},
+		},
+		{
+			"invalid pagerduty severity",
+			func(tenant *TenantSettings) {
+				tenant.Outputs = append(tenant.Outputs, OutputSettings{
+					Name: "my-pagerduty", Type: "pagerduty", Enable: true,
+					SeverityMap: map[string]string{"high": "urgent"},
+				})
+			},
+			[]string{
+				`output "my-pagerduty": 'routing-key' is required`,
+				`output "my-pagerduty": 'severity-map': "high" is mapped to "urgent", which isn't one of critical, error, warning or info`,
+			},
+		},
+		{
+			"invalid opsgenie priority",
+			func(tenant *TenantSettings) {
+				tenant.Outputs = append(tenant.Outputs, OutputSettings{
+					Name: "my-opsgenie", Type: "opsgenie", Enable: true, Token: "key", Priority: "high",
+					SeverityMap: map[string]string{"high": "P2"},
+				})
+			},
+			[]string{`output "my-opsgenie": 'priority'("high") isn't one of P1, P2, P3, P4 or P5`},
+		},
+		{
+			"invalid kafka options",
+			func(tenant *TenantSettings) {
+				tenant.Outputs = append(tenant.Outputs, OutputSettings{
+					Name: "my-kafka", Type: "kafka", Enable: true, Brokers: []string{"localhost:9092"},
+					Acks: "some", BatchTimeout: "10",
+				})
+			},
+			[]string{
+				`output "my-kafka": 'topic' is required`,
+				`output "my-kafka": can't convert 'batch-timeout'("10") to duration`,
+				`output "my-kafka": 'acks'("some") isn't one of all, leader or none`,
+			},
+		},
+		{
+			"invalid syslog format",
+			func(tenant *TenantSettings) {
+				tenant.Outputs = append(tenant.Outputs, OutputSettings{
+					Name: "my-syslog", Type: "syslog", Enable: true, Host: "siem.example.com", Format: "gelf",
+				})
+			},
+			[]string{`output "my-syslog": 'format'("gelf") isn't one of rfc5424, cef or leef`},
+		},
+		{
+			"duplicated names and unknown type",
+			func(tenant *TenantSettings) {
+				tenant.Outputs[1] = OutputSettings{Name: "my-slack", Type: "slak"}
+				tenant.LogLevel = "verbose"
+			},
+			[]string{
+				`output "my-slack" is defined more than once`,
+				`output "my-slack": type "slak" is undefined or empty`,
+				`log-level: unknown log level "verbose"`,
+			},
+		},
+		{
+			"invalid ingest credentials",
+			func(tenant *TenantSettings) {
+				tenant.IngestAuth = []IngestCredential{
+					{Name: "aqua", Type: "hmac", Algorithm: "md5", Tolerance: "5", Routes: []string{"route1", "route2"}},
+					{Name: "ci", Type: "bearer"},
+					{Name: "scanner", Type: "client-cert", Subject: "scanner.example.com"},
+					{Name: "legacy", Type: "basic"},
+				}
+			},
+			[]string{
+				`credential "aqua": 'secret' is required`,
+				`credential "aqua": unknown algorithm "md5"`,
+				`credential "aqua": 'tolerance'("5") should be a positive duration, e.g. 5m`,
+				`credential "aqua": route "route2" isn't defined`,
+				`credential "ci": 'token' is required`,
+				`credential "legacy": type "basic" is undefined or empty`,
+			},
+		},
+	}
+	for _, test := range tests {
+		tenant := validTenant()
+		test.modify(tenant)
+		errs := Validate(tenant)
+		if len(errs) != len(test.expected) {
+			t.Errorf("[%s] Unexpected number of errors. Expected %d, got %d: %v", test.caseDesc, len(test.expected), len(errs), errs)
+			continue
+		}
+		for _, expected := range test.expected {
+			found := false
+			for _, err := range errs {
+				if strings.HasPrefix(err.Error(), expected) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("[%s] Error %q isn't found in %v", test.caseDesc, expected, errs)
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package router
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/aquasecurity/postee/v2/logging"
+)
+
+const (
+	WatchIntervalDefault = 5 * time.Second
+	WatchDebounceDefault = 2 * time.Second
+)
+
+// ConfigWatcher polls the configuration files and calls reload once their content is changed and stays the same
+// for the debounce period. Polling also follows symlinks, which are swapped when a Kubernetes ConfigMap is updated.
+// Files which are added to a configuration directory or match its glob are picked up too.
+type ConfigWatcher struct {
+	path     string
+	interval time.Duration
+	debounce time.Duration
+	reload   func() error
+	quit     chan struct{}
+	done     chan struct{}
+}
+
+func NewConfigWatcher(path string, interval, debounce time.Duration, reload func() error) *ConfigWatcher {
+	if interval <= 0 {
+		interval = WatchIntervalDefault
+	}
+	if debounce < 0 {
+		debounce = 0
+	}
+	return &ConfigWatcher{
+		path:     path,
+		interval: interval,
+		debounce: debounce,
+		reload:   reload,
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (w *ConfigWatcher) Start() {
+	applied, err := w.checksum()
+	if err != nil {
+		logging.Warnf("Unable to read configuration file %s: %v", w.path, err)
+	}
+	logging.Infof("Watching configuration file %s for changes every %s", w.path, w.interval)
+	go w.watch(applied)
+}
+
+// Stop stops polling and waits for a reload which is in progress
+func (w *ConfigWatcher) Stop() {
+	close(w.quit)
+	<-w.done
+}
+
+func (w *ConfigWatcher) watch(applied []byte) {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	var pending []byte
+	var changedAt time.Time
+	for {
+		select {
+		case <-w.quit:
+			return
+		case <-ticker.C:
+		}
+		sum, err := w.checksum()
+		if err != nil {
+			//the file may be missing for a moment while it's replaced
+			logging.Debugf("Unable to read configuration file %s: %v", w.path, err)
+			continue
+		}
+		if bytes.Equal(sum, applied) {
+			pending = nil
+			continue
+		}
+		if !bytes.Equal(sum, pending) {
+			pending, changedAt = sum, time.Now()
+		}
+		if time.Since(changedAt) < w.debounce {
+			continue
+		}
+		applied, pending = sum, nil
+		logging.Infof("Configuration file %s is changed", w.path)
+		w.reload() //a failed reload keeps the running configuration and is logged by the router
+	}
+}
+
+func (w *ConfigWatcher) checksum() ([]byte, error) {
+	files, err := ConfigFiles(w.path)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", file, len(b))
+		h.Write(b)
+	}
+	return h.Sum(nil), nil
+}
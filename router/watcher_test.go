@@ -0,0 +1,61 @@
+package router
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConfigWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "postee-watcher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfgPath := filepath.Join(dir, "cfg.yaml")
+	if err := ioutil.WriteFile(cfgPath, []byte("name: tenant"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reloads := make(chan struct{}, 10)
+	watcher := NewConfigWatcher(cfgPath, 5*time.Millisecond, 50*time.Millisecond, func() error {
+		reloads <- struct{}{}
+		return nil
+	})
+	watcher.Start()
+	defer watcher.Stop()
+
+	expectReloads := func(caseDesc string, expected int) {
+		time.Sleep(200 * time.Millisecond)
+		if len(reloads) != expected {
+			t.Errorf("[%s] Unexpected number of reloads. Expected %d, got %d", caseDesc, expected, len(reloads))
+		}
+		for len(reloads) > 0 {
+			<-reloads
+		}
+	}
+
+	if err := ioutil.WriteFile(cfgPath, []byte("name: tenant"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectReloads("same content", 0)
+
+	for _, content := range []string{"name: tenant1", "name: tenant2", "name: tenant3"} {
+		if err := ioutil.WriteFile(cfgPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	expectReloads("several quick changes", 1)
+
+	if err := os.Remove(cfgPath); err != nil {
+		t.Fatal(err)
+	}
+	expectReloads("removed file", 0)
+	if err := ioutil.WriteFile(cfgPath, []byte("name: tenant3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectReloads("file is restored", 0)
+}
@@ -1,9 +1,11 @@
 package routes
 
 import (
-	"log"
+	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/aquasecurity/postee/v2/logging"
 )
 
 func parseTimeouts(v string) (int, error) {
@@ -38,10 +40,22 @@ func parseTimeouts(v string) (int, error) {
 	return timeout, err
 }
 
+// CheckTimeouts returns errors of route timeouts which can't be converted to seconds
+func CheckTimeouts(route *InputRoute) []error {
+	errs := make([]error, 0)
+	if _, err := parseTimeouts(route.Plugins.AggregateMessageTimeout); err != nil {
+		errs = append(errs, fmt.Errorf("can't convert 'aggregate-message-timeout'(%q) to seconds", route.Plugins.AggregateMessageTimeout))
+	}
+	if _, err := parseTimeouts(route.Plugins.UniqueMessageTimeout); err != nil {
+		errs = append(errs, fmt.Errorf("can't convert 'unique-message-timeout'(%q) to seconds", route.Plugins.UniqueMessageTimeout))
+	}
+	return errs
+}
+
 func ConfigureTimeouts(route *InputRoute) *InputRoute {
 	aggregateTimeoutSeconds, err := parseTimeouts(route.Plugins.AggregateMessageTimeout)
 	if err != nil {
-		log.Printf("%q settings: Can't convert 'aggregate-message-timeout'(%q) to seconds.",
+		logging.Warnf("%q settings: Can't convert 'aggregate-message-timeout'(%q) to seconds.",
 			route.Name, route.Plugins.AggregateMessageTimeout)
 	}
 
@@ -49,7 +63,7 @@ func ConfigureTimeouts(route *InputRoute) *InputRoute {
 
 	uniqueMessageTimeoutSeconds, err := parseTimeouts(route.Plugins.UniqueMessageTimeout)
 	if err != nil {
-		log.Printf("%q settings: Can't convert 'unique-message-timeout'(%q) to seconds.",
+		logging.Warnf("%q settings: Can't convert 'unique-message-timeout'(%q) to seconds.",
 			route.Name, route.Plugins.UniqueMessageTimeout)
 	}
 
@@ -0,0 +1,33 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+)
+
+// FileProvider reads secrets from local YAML or JSON files with a map of keys, e.g. "local://jira.yaml#token".
+// Relative paths are resolved against Root, or POSTEE_SECRETS_DIR environment variable if Root is empty.
+// It's meant for development and tests, when Vault isn't available.
+type FileProvider struct {
+	Root string
+}
+
+func (p *FileProvider) Read(ctx context.Context, path string) (map[string]string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(firstNonEmpty(p.Root, os.Getenv("POSTEE_SECRETS_DIR")), path)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string]interface{})
+	if err := yaml.Unmarshal(b, &data); err != nil {
+		return nil, fmt.Errorf("can't parse %s: %w", path, err)
+	}
+	return stringValues(data), nil
+}
@@ -0,0 +1,49 @@
+package secrets
+
+import (
+	"strings"
+	"sync"
+)
+
+// minTrackedLength is the length of a secret from which it's looked for inside of other values.
+// Shorter secrets are only matched as whole values, so a one-letter value doesn't hide everything.
+const minTrackedLength = 4
+
+var (
+	trackedMu sync.RWMutex
+	tracked   = make(map[string]map[string]bool) //resolved secrets by configuration file
+)
+
+// Track sets the resolved secrets of a configuration, so they can be hidden from logs. Secrets of its previous
+// load are forgotten, values which aren't secrets anymore aren't hidden.
+func Track(config string, secrets []string) {
+	set := make(map[string]bool, len(secrets))
+	for _, secret := range secrets {
+		if secret != "" {
+			set[secret] = true
+		}
+	}
+	trackedMu.Lock()
+	defer trackedMu.Unlock()
+	tracked[config] = set
+}
+
+// Contains reports whether a value contains a resolved secret
+func Contains(value string) bool {
+	if value == "" {
+		return false
+	}
+	trackedMu.RLock()
+	defer trackedMu.RUnlock()
+	for _, set := range tracked {
+		if set[value] {
+			return true
+		}
+		for secret := range set {
+			if len(secret) >= minTrackedLength && strings.Contains(value, secret) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Provider reads secrets which are referenced in the configuration as "<scheme>://<path>#<key>"
+type Provider interface {
+	// Read returns all keys stored at a path
+	Read(ctx context.Context, path string) (map[string]string, error)
+}
+
+var (
+	mu        sync.RWMutex
+	providers = map[string]Provider{
+		"vault": &VaultProvider{},
+		"local": &FileProvider{},
+	}
+)
+
+// Register adds a provider of a reference scheme or replaces the existing one
+func Register(scheme string, provider Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[scheme] = provider
+}
+
+func providerOf(scheme string) (Provider, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := providers[scheme]
+	return p, ok
+}
+
+// Resolver resolves secret references of a single configuration load.
+// Every path is read once, so a secret with several keys costs a single request.
+type Resolver struct {
+	ctx      context.Context
+	cache    map[string]map[string]string
+	resolved []string //secrets to track once the configuration is loaded, see Apply
+}
+
+func NewResolver(ctx context.Context) *Resolver {
+	return &Resolver{ctx: ctx, cache: make(map[string]map[string]string)}
+}
+
+// Track remembers a secret which isn't read from a provider, e.g. content of a file
+func (r *Resolver) Track(secret string) {
+	r.resolved = append(r.resolved, secret)
+}
+
+// Apply makes the secrets resolved for a configuration file hidden from logs instead of its previous ones
+func (r *Resolver) Apply(config string) {
+	Track(config, r.resolved)
+}
+
+// Resolve returns the secret referenced by a value. Values which aren't references of a registered scheme
+// are returned as is, with false.
+func (r *Resolver) Resolve(value string) (string, bool, error) {
+	sep := strings.Index(value, "://")
+	if sep <= 0 {
+		return value, false, nil
+	}
+	scheme := value[:sep]
+	provider, ok := providerOf(scheme)
+	if !ok {
+		return value, false, nil
+	}
+
+	ref := value[sep+3:]
+	hash := strings.LastIndex(ref, "#")
+	if hash < 0 || hash == len(ref)-1 {
+		return "", true, fmt.Errorf("%s reference %q has no key, '%s://<path>#<key>' is expected", scheme, ref, scheme)
+	}
+	path, key := ref[:hash], ref[hash+1:]
+
+	cacheKey := scheme + "://" + path
+	data, ok := r.cache[cacheKey]
+	if !ok {
+		var err error
+		data, err = provider.Read(r.ctx, path)
+		if err != nil {
+			return "", true, fmt.Errorf("can't read %s secret %q: %w", scheme, path, err)
+		}
+		r.cache[cacheKey] = data
+	}
+	secret, ok := data[key]
+	if !ok {
+		return "", true, fmt.Errorf("%s secret %q has no key %q", scheme, path, key)
+	}
+	r.Track(secret)
+	return secret, true, nil
+}
@@ -0,0 +1,129 @@
+package secrets
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVaultProvider(t *testing.T) {
+	requests := 0
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("X-Vault-Token") != "root-token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		if r.Header.Get("X-Vault-Namespace") != "team" {
+			t.Errorf("Unexpected namespace %q", r.Header.Get("X-Vault-Namespace"))
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/postee":
+			w.Write([]byte(`{"data":{"data":{"jira-token":"s3cr3t-token","port":587},"metadata":{"version":3}}}`))
+		case "/v1/kv/postee":
+			w.Write([]byte(`{"data":{"password":"kv1-password"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer vault.Close()
+	Register("vault", &VaultProvider{Addr: vault.URL, Token: "root-token", Namespace: "team"})
+	defer Register("vault", &VaultProvider{})
+
+	tests := []struct {
+		caseDesc      string
+		value         string
+		expected      string
+		isReference   bool
+		expectedError string
+	}{
+		{"KV v2", "vault://secret/data/postee#jira-token", "s3cr3t-token", true, ""},
+		{"KV v2 non-string value", "vault://secret/data/postee#port", "587", true, ""},
+		{"KV v1", "vault://kv/postee#password", "kv1-password", true, ""},
+		{"missing key", "vault://secret/data/postee#user", "", true, `vault secret "secret/data/postee" has no key "user"`},
+		{"missing secret", "vault://secret/data/other#user", "", true, `"404 Not Found"`},
+		{"reference without key", "vault://secret/data/postee", "", true, "has no key"},
+		{"plain value", "https://hooks.slack.com/services/xxx", "https://hooks.slack.com/services/xxx", false, ""},
+		{"unknown scheme", "aws://postee#token", "aws://postee#token", false, ""},
+	}
+	resolver := NewResolver(context.Background())
+	for _, test := range tests {
+		value, isReference, err := resolver.Resolve(test.value)
+		if test.expectedError != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+				t.Errorf("[%s] Expected error containing %q, got %v", test.caseDesc, test.expectedError, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%s] Unexpected error: %v", test.caseDesc, err)
+			continue
+		}
+		if value != test.expected || isReference != test.isReference {
+			t.Errorf("[%s] Expected %q (reference: %t), got %q (reference: %t)", test.caseDesc, test.expected, test.isReference, value, isReference)
+		}
+	}
+	if requests != 3 {
+		t.Errorf("Every secret should be read once by a resolver, got %d requests", requests)
+	}
+
+	Register("vault", &VaultProvider{Addr: vault.URL, Token: "wrong-token"})
+	if _, _, err := NewResolver(context.Background()).Resolve("vault://secret/data/postee#jira-token"); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Expected permission error, got %v", err)
+	}
+}
+
+func TestFileProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "postee-secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "jira.yaml"), []byte("user: admin\ntoken: local-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	Register("local", &FileProvider{Root: dir})
+	defer Register("local", &FileProvider{})
+
+	resolver := NewResolver(context.Background())
+	for value, expected := range map[string]string{
+		"local://jira.yaml#token":                              "local-token",
+		"local://" + filepath.Join(dir, "jira.yaml") + "#user": "admin",
+	} {
+		resolved, _, err := resolver.Resolve(value)
+		if err != nil || resolved != expected {
+			t.Errorf("Expected %q for %s, got %q, %v", expected, value, resolved, err)
+		}
+	}
+	if _, _, err := resolver.Resolve("local://missing.yaml#token"); err == nil {
+		t.Error("Error is expected for a missing file")
+	}
+}
+
+func TestContains(t *testing.T) {
+	Track("test.yaml", []string{"tracked-secret", "abc"})
+	defer Track("test.yaml", nil)
+	tests := []struct {
+		value    string
+		expected bool
+	}{
+		{"tracked-secret", true},
+		{"https://example.com/?token=tracked-secret", true},
+		{"abc", true},
+		{"abcdef", false},
+		{"other", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if actual := Contains(test.value); actual != test.expected {
+			t.Errorf("Contains(%q): expected %t, got %t", test.value, test.expected, actual)
+		}
+	}
+}
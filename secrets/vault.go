@@ -0,0 +1,117 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const vaultTimeout = 10 * time.Second
+
+// VaultProvider reads secrets from HashiCorp Vault KV engines of both versions,
+// e.g. "vault://secret/data/postee#jira-token" for KV v2 mounted at "secret".
+// Empty fields are taken from VAULT_ADDR, VAULT_TOKEN (or a file in VAULT_TOKEN_FILE) and VAULT_NAMESPACE
+// environment variables when a secret is read.
+type VaultProvider struct {
+	Addr      string
+	Token     string
+	Namespace string
+	Client    *http.Client
+}
+
+func (p *VaultProvider) Read(ctx context.Context, path string) (map[string]string, error) {
+	addr := firstNonEmpty(p.Addr, os.Getenv("VAULT_ADDR"))
+	if addr == "" {
+		return nil, fmt.Errorf("vault address isn't set, use VAULT_ADDR environment variable")
+	}
+	token, err := p.token()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := firstNonEmpty(p.Namespace, os.Getenv("VAULT_NAMESPACE")); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: vaultTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		json.Unmarshal(body, &vaultErr)
+		return nil, fmt.Errorf("vault responded with %q %s", resp.Status, strings.Join(vaultErr.Errors, "; "))
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return nil, fmt.Errorf("can't parse vault response: %w", err)
+	}
+	data := secret.Data
+	//KV v2 keeps the secret under data.data along with data.metadata
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = inner
+		}
+	}
+	return stringValues(data), nil
+}
+
+func (p *VaultProvider) token() (string, error) {
+	if token := firstNonEmpty(p.Token, os.Getenv("VAULT_TOKEN")); token != "" {
+		return token, nil
+	}
+	if file := os.Getenv("VAULT_TOKEN_FILE"); file != "" {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+	return "", fmt.Errorf("vault token isn't set, use VAULT_TOKEN or VAULT_TOKEN_FILE environment variable")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// stringValues converts values of a secret to strings, values which aren't strings are kept as JSON
+func stringValues(data map[string]interface{}) map[string]string {
+	values := make(map[string]string, len(data))
+	for k, v := range data {
+		if s, ok := v.(string); ok {
+			values[k] = s
+			continue
+		}
+		b, _ := json.Marshal(v)
+		values[k] = string(b)
+	}
+	return values
+}
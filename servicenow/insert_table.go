@@ -2,6 +2,7 @@ package servicenow_api
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"fmt"
 	"net/http"
@@ -9,12 +10,12 @@ import (
 	"github.com/aquasecurity/postee/v2/utils"
 )
 
-func InsertRecordToTable(user, password, instance, table string, content []byte) error {
+func InsertRecordToTable(ctx context.Context, user, password, instance, table string, content []byte) error {
 	url := fmt.Sprintf("https://%s.%s%s%s%s",
 		instance, BaseServer, baseApiUrl, tableApi, table)
 	r := bytes.NewReader(content)
 	client := http.DefaultClient
-	reg, err := http.NewRequest("POST", url, r)
+	reg, err := http.NewRequestWithContext(ctx, "POST", url, r)
 	if err != nil {
 		return err
 	}
@@ -24,9 +25,10 @@ func InsertRecordToTable(user, password, instance, table string, content []byte)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("InsertRecordToTable Error: %v\nHeader: %v",
-			resp.Status, utils.PrnLogResponse(resp.Body))
+		return utils.NewHttpError(resp.StatusCode, "InsertRecordToTable Error: %v\nHeader: %v",
+			resp.Status, utils.PrnLogResponse(resp.Body)).WithRetryAfter(resp.Header)
 	}
 	return nil
 }
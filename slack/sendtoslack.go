@@ -2,27 +2,33 @@ package slack_api
 
 import (
 	"bytes"
-	"fmt"
+	"context"
 	"io/ioutil"
-	"log"
 	"net/http"
+
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/aquasecurity/postee/v2/utils"
 )
 
-func SendToUrl(url string, data []byte) error {
-	r := bytes.NewReader(data)
-	resp, err := http.Post(url, "application/json", r)
+func SendToUrl(ctx context.Context, url string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		log.Printf("Slack API error: %v", err)
+		logging.FromContext(ctx).Errorf("Slack API error: %v", err)
 		return err
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
 		msg, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
 			return err
 		}
-		return fmt.Errorf("Slack API error: Status: %q. Message: %q",
-			resp.Status, msg)
+		return utils.NewHttpError(resp.StatusCode, "Slack API error: Status: %q. Message: %q",
+			resp.Status, msg).WithRetryAfter(resp.Header)
 	}
 	return nil
 }
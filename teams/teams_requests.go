@@ -2,6 +2,7 @@ package teams_api
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -9,14 +10,14 @@ import (
 	"github.com/aquasecurity/postee/v2/utils"
 )
 
-func CreateMessageByWebhook(webhook, content string) error {
+func CreateMessageByWebhook(ctx context.Context, webhook, content string) error {
 	var message bytes.Buffer
 	fmt.Fprintf(&message, "{\"text\":\"%s\"}", content)
 
 	utils.Debug("Data for sending to %q: %q\n", webhook, message.String())
 	r := bytes.NewReader(message.Bytes())
 	client := http.DefaultClient
-	reg, err := http.NewRequest("POST", webhook, r)
+	reg, err := http.NewRequestWithContext(ctx, "POST", webhook, r)
 	if err != nil {
 		return err
 	}
@@ -28,7 +29,7 @@ func CreateMessageByWebhook(webhook, content string) error {
 
 	defer resp.Body.Close()
 	if message, _ := ioutil.ReadAll(resp.Body); resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("InsertRecordToTable Error: %q. %s", resp.Status, message)
+		return utils.NewHttpError(resp.StatusCode, "InsertRecordToTable Error: %q. %s", resp.Status, message).WithRetryAfter(resp.Header)
 	} else {
 		if message[0] != '1' {
 			return fmt.Errorf("Teams Body Error: %q", string(message))
@@ -35,6 +35,24 @@ func (srv *uiServer) getConfig(w http.ResponseWriter, r *http.Request) {
 	w.Write(d)
 }
 
+// getConfigSchema passes JSON Schema of the config file from the webhook server, so it matches the running version
+func (srv *uiServer) getConfigSchema(w http.ResponseWriter, r *http.Request) {
+	resp, err := http.Get(fmt.Sprintf("%s/config/schema", srv.webhookUrl))
+	if err != nil {
+		log.Printf("Can not get config schema %v", err)
+		http.Error(w, "Can not get config schema from webhook server", http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		http.Error(w, fmt.Sprintf("Webhook server responded with %q", resp.Status), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, resp.Body)
+}
+
 func (srv *uiServer) updateConfig(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 	inputYaml, err := io.ReadAll(r.Body)
@@ -80,8 +98,12 @@ func (srv *uiServer) updateConfig(w http.ResponseWriter, r *http.Request) {
 }
 
 func reloadWebhookCfg(url string, key string) error {
-	u := fmt.Sprintf("%s/reload?key=%s", url, key)
-	resp, err := http.Get(u)
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/reload", url), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+key)
+	resp, err := http.DefaultClient.Do(req)
 
 	if err != nil {
 		return err
@@ -0,0 +1,72 @@
+package uiserver
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+
+	hookDbService "github.com/aquasecurity/postee/dbservice"
+	"github.com/gorilla/mux"
+)
+
+func (srv *uiServer) listDeadLetters(w http.ResponseWriter, r *http.Request) {
+	srv.forwardToWebhook(w, "GET", "/deadletters", nil)
+}
+
+func (srv *uiServer) getDeadLetter(w http.ResponseWriter, r *http.Request) {
+	srv.forwardToWebhook(w, "GET", "/deadletters/"+url.PathEscape(mux.Vars(r)["id"]), nil)
+}
+
+func (srv *uiServer) replayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	srv.forwardToWebhook(w, "POST", "/deadletters/"+url.PathEscape(mux.Vars(r)["id"])+"/replay", nil)
+}
+
+func (srv *uiServer) replayDeadLetters(w http.ResponseWriter, r *http.Request) {
+	params := url.Values{}
+	if output := r.URL.Query().Get("output"); output != "" {
+		params.Set("output", output)
+	}
+	srv.forwardToWebhook(w, "POST", "/deadletters/replay", params)
+}
+
+func (srv *uiServer) removeDeadLetter(w http.ResponseWriter, r *http.Request) {
+	srv.forwardToWebhook(w, "DELETE", "/deadletters/"+url.PathEscape(mux.Vars(r)["id"]), nil)
+}
+
+func (srv *uiServer) purgeDeadLetters(w http.ResponseWriter, r *http.Request) {
+	srv.forwardToWebhook(w, "DELETE", "/deadletters", nil)
+}
+
+// forwardToWebhook calls an API of the webhook server and copies its response
+func (srv *uiServer) forwardToWebhook(w http.ResponseWriter, method, path string, params url.Values) {
+	apikey, err := hookDbService.GetApiKey()
+	if err != nil {
+		log.Printf("Can not load api key from bolt %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	u := fmt.Sprintf("%s%s", srv.webhookUrl, path)
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		handleErr(w, err)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+apikey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("Can not call webhook server %v", err)
+		http.Error(w, "Can not call webhook server", http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
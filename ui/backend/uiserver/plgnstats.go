@@ -2,17 +2,53 @@ package uiserver
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
+	"time"
 
+	hookDbService "github.com/aquasecurity/postee/dbservice"
 	"github.com/aquasecurity/postee/ui/backend/dbservice"
 )
 
+// outputHealth is a state of an output reported by the webhook server
+type outputHealth struct {
+	Output              string     `json:"output"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive-failures"`
+	LastError           string     `json:"last-error,omitempty"`
+	OpenedAt            *time.Time `json:"opened-at,omitempty"`
+	Pending             int        `json:"pending"`
+}
+
+type outputStats struct {
+	Received int           `json:"received"`
+	Health   *outputHealth `json:"health,omitempty"`
+}
+
 func (srv *uiServer) plgnStats(w http.ResponseWriter, r *http.Request) {
-	stats, err := dbservice.GetPlgnStats()
+	counts, err := dbservice.GetPlgnStats()
 	if err != nil {
 		handleErr(w, err)
 		return
 	}
+	stats := make(map[string]*outputStats)
+	for name, cnt := range counts {
+		stats[name] = &outputStats{Received: cnt}
+	}
+
+	health, err := srv.getOutputsHealth()
+	if err != nil {
+		//counts are still useful when the webhook server isn't available
+		log.Printf("Can not get health of outputs: %v", err)
+	}
+	for i, h := range health {
+		if _, ok := stats[h.Output]; !ok {
+			stats[h.Output] = &outputStats{}
+		}
+		stats[h.Output].Health = &health[i]
+	}
+
 	data, err := json.Marshal(stats)
 	if err != nil {
 		handleErr(w, err)
@@ -22,6 +58,32 @@ func (srv *uiServer) plgnStats(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(data))
 }
+
+func (srv *uiServer) getOutputsHealth() ([]outputHealth, error) {
+	apikey, err := hookDbService.GetApiKey()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/outputs/health", srv.webhookUrl), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+apikey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("webhook server responded with %q", resp.Status)
+	}
+	health := make([]outputHealth, 0)
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return nil, err
+	}
+	return health, nil
+}
+
 func handleErr(w http.ResponseWriter, err error) {
 	w.WriteHeader(http.StatusInternalServerError)
 	w.Write([]byte(err.Error()))
@@ -49,8 +49,15 @@ func Instance(webLocalPath, port, cfg, webhookUrl, admusr string, admpwd string)
 	server.router.HandleFunc("/api/logout", server.logout).Methods("GET")
 	server.router.HandleFunc("/api/config", server.updateConfig).Methods("POST")
 	server.router.HandleFunc("/api/config", server.getConfig).Methods("GET")
+	server.router.HandleFunc("/api/config/schema", server.getConfigSchema).Methods("GET")
 	server.router.HandleFunc("/api/test", server.testSettings).Methods("POST")
 	server.router.HandleFunc("/api/outputs/stats", server.plgnStats).Methods("GET")
+	server.router.HandleFunc("/api/deadletters", server.listDeadLetters).Methods("GET")
+	server.router.HandleFunc("/api/deadletters", server.purgeDeadLetters).Methods("DELETE")
+	server.router.HandleFunc("/api/deadletters/replay", server.replayDeadLetters).Methods("POST")
+	server.router.HandleFunc("/api/deadletters/{id}", server.getDeadLetter).Methods("GET")
+	server.router.HandleFunc("/api/deadletters/{id}", server.removeDeadLetter).Methods("DELETE")
+	server.router.HandleFunc("/api/deadletters/{id}/replay", server.replayDeadLetter).Methods("POST")
 
 	server.router.HandleFunc("/ping", server.pingHandler).Methods("GET")
 
@@ -14,9 +14,27 @@ export default {
     getConfig: function () {
         return axios.get("/api/config", { transformResponse: transformYaml })
     },
+    getConfigSchema: function () {
+        return axios.get("/api/config/schema")
+    },
     getStats: function () {
         return axios.get("/api/outputs/stats")
     },
+    getDeadLetters: function () {
+        return axios.get("/api/deadletters")
+    },
+    replayDeadLetter: function (id) {
+        return axios.post(`/api/deadletters/${encodeURIComponent(id)}/replay`)
+    },
+    replayDeadLetters: function () {
+        return axios.post("/api/deadletters/replay")
+    },
+    removeDeadLetter: function (id) {
+        return axios.delete(`/api/deadletters/${encodeURIComponent(id)}`)
+    },
+    purgeDeadLetters: function () {
+        return axios.delete("/api/deadletters")
+    },
     saveConfig: function (settings) {
         const yamlObj = yaml.dump(settings)
         return axios.post("/api/config", yamlObj)
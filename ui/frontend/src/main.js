@@ -9,6 +9,7 @@ import Routes from './components/Routes.vue'
 import TemplateDetails from './components/TemplateDetails.vue'
 import Templates from './components/Templates.vue'
 import Settings from './components/Settings.vue'
+import DeadLetters from './components/DeadLetters.vue'
 import { BootstrapVue, BootstrapVueIcons } from 'bootstrap-vue'
 import store from './store/store'
 import 'bootstrap/dist/css/bootstrap.css'
@@ -25,6 +26,7 @@ const routes = [
   { name: 'add-route', path: '/route', component: RouteDetails },
   { name: 'route', path: '/route/:name', component: RouteDetails },
   { name: 'settings', path: '/settings', component: Settings },
+  { name: 'deadletters', path: '/deadletters', component: DeadLetters },
   { name: 'login', path: '/login', component: LoginForm },
   { name: 'add-output', path: '/output', component: OutputDetails },
   { name: 'output', path: '/output/:name', component: OutputDetails },
@@ -0,0 +1,52 @@
+import api from "../../api"
+export default {
+    namespaced: true,
+    state: {
+        all: []
+    },
+    actions: {
+        load(context) {
+            api.getDeadLetters().then((response) => {
+                context.commit("set", response.data)
+            }).catch((error) => {
+                context.commit("error/set", error.response.data, {root: true})
+            })
+        },
+        replay(context, id) {
+            api.replayDeadLetter(id).then(() => {
+                context.commit("remove", id)
+            }).catch((error) => {
+                context.commit("error/set", error.response.data, {root: true})
+            })
+        },
+        replayAll(context) {
+            api.replayDeadLetters().then(() => {
+                context.dispatch("load")
+            }).catch((error) => {
+                context.commit("error/set", error.response.data, {root: true})
+            })
+        },
+        remove(context, id) {
+            api.removeDeadLetter(id).then(() => {
+                context.commit("remove", id)
+            }).catch((error) => {
+                context.commit("error/set", error.response.data, {root: true})
+            })
+        },
+        purge(context) {
+            api.purgeDeadLetters().then(() => {
+                context.commit("set", [])
+            }).catch((error) => {
+                context.commit("error/set", error.response.data, {root: true})
+            })
+        }
+    },
+    mutations: {
+        set(state, payload) {
+            state.all = [...(payload || [])]
+        },
+        remove(state, id) {
+            state.all = state.all.filter(item => item.id !== id)
+        }
+    }
+}
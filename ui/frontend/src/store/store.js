@@ -6,6 +6,7 @@ import error from './modules/error.js'
 import account from './modules/account.js'
 import outputs from './modules/outputs.js'
 import stats from './modules/stats.js'
+import deadletters from './modules/deadletters.js'
 import routes from './modules/routes.js'
 import settings from './modules/settings.js'
 import flags from './modules/flags.js'
@@ -19,6 +20,7 @@ export default new Vuex.Store({
         outputs,
         account,
         stats,
+        deadletters,
         routes,
         settings,
         flags,
@@ -35,10 +37,10 @@ export default new Vuex.Store({
                 const data = response.data
                 const settings = {
                     name: data.name,
-                    AquaServer: data.AquaServer,
-                    Delete_Old_Data: data.Delete_Old_Data,
-                    DbVerifyInterval: data.DbVerifyInterval,
-                    Max_DB_Size: data.Max_DB_Size
+                    "aqua-server": data["aqua-server"],
+                    "delete-old-data": data["delete-old-data"],
+                    "db-verify-interval": data["db-verify-interval"],
+                    "max-db-size": data["max-db-size"]
                 }
                 data.outputs && context.commit("outputs/set", data.outputs)
                 data.routes && context.commit("routes/set", data.routes)
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HttpError is returned when a remote endpoint responds with an unexpected status
+type HttpError struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration //delay requested by the endpoint, zero if it isn't given
+}
+
+func NewHttpError(statusCode int, format string, v ...interface{}) *HttpError {
+	return &HttpError{
+		StatusCode: statusCode,
+		Message:    fmt.Sprintf(format, v...),
+	}
+}
+
+func (e *HttpError) Error() string {
+	return e.Message
+}
+
+// IsTemporary reports whether the same request may succeed later
+func (e *HttpError) IsTemporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// WithRetryAfter takes the delay from Retry-After header of a response
+func (e *HttpError) WithRetryAfter(header http.Header) *HttpError {
+	e.RetryAfter = ParseRetryAfter(header.Get("Retry-After"))
+	return e
+}
+
+// ParseRetryAfter converts a value of Retry-After header, which is either seconds or an HTTP date, to a delay
+func ParseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
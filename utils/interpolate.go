@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const FilePrefix = "file:"
+
+var envVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Interpolate resolves references to environment variables and files in a setting:
+//   - "${VAR}" anywhere in a value is replaced with the variable, "${VAR:-default}" uses the default if it's empty or unset
+//   - "$${" is kept as "${"
+//   - "$VAR" as the whole value is the variable, as GetEnvironmentVarOrPlain does
+//   - "file:/path" as the whole value is the content of the file without trailing newlines, the path can refer to variables
+func Interpolate(value string) (string, error) {
+	if strings.HasPrefix(value, FilePrefix) {
+		path, err := interpolateVars(strings.TrimPrefix(value, FilePrefix))
+		if err != nil {
+			return "", err
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+	if strings.HasPrefix(value, "$") && envVarName.MatchString(value[1:]) {
+		return os.Getenv(value[1:]), nil
+	}
+	return interpolateVars(value)
+}
+
+func interpolateVars(value string) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); {
+		switch {
+		case strings.HasPrefix(value[i:], "$${"):
+			b.WriteString("${")
+			i += 3
+		case strings.HasPrefix(value[i:], "${"):
+			end := strings.IndexByte(value[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("'${' at %d isn't closed", i)
+			}
+			name, defaultValue := value[i+2:i+end], ""
+			if sep := strings.Index(name, ":-"); sep >= 0 {
+				name, defaultValue = name[:sep], name[sep+2:]
+			}
+			if !envVarName.MatchString(name) {
+				return "", fmt.Errorf("invalid environment variable name %q", name)
+			}
+			v := os.Getenv(name)
+			if v == "" {
+				v = defaultValue
+			}
+			b.WriteString(v)
+			i += end + 1
+		default:
+			b.WriteByte(value[i])
+			i++
+		}
+	}
+	return b.String(), nil
+}
@@ -2,14 +2,11 @@ package utils
 
 import (
 	"errors"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
-)
 
-var (
-	dbg = false
+	"github.com/aquasecurity/postee/v2/logging"
 )
 
 func GetEnvironmentVarOrPlain(value string) string {
@@ -21,18 +18,13 @@ func GetEnvironmentVarOrPlain(value string) string {
 }
 
 func InitDebug() {
-	if os.Getenv("AQUAALERT_DEBUG") != "" {
-		dbg = true
-	}
-	if os.Getenv("POSTEE_DEBUG") != "" {
-		dbg = true
+	if os.Getenv("AQUAALERT_DEBUG") != "" || os.Getenv("POSTEE_DEBUG") != "" {
+		logging.SetLevel(logging.DebugLevel)
 	}
 }
 
 func Debug(format string, v ...interface{}) {
-	if dbg != false {
-		log.Printf(format, v...)
-	}
+	logging.Debugf(format, v...)
 }
 
 func GetEnv(name string) (string, error) {
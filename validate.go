@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/aquasecurity/postee/v2/router"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the alert configuration file",
+	Long: "Validate the alert configuration file: references between routes, outputs and templates, Rego criteria of routes,\n" +
+		"templates, timeouts and required options of outputs. Exits with a non-zero code if there are errors.",
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		allowUnknown, _ := cmd.Flags().GetBool("allow-unknown-keys")
+		os.Exit(validate(cmd.Flag("cfgfile").Value.String(), allowUnknown))
+	},
+}
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print JSON Schema of the alert configuration file",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		schema, err := router.SchemaJson()
+		if err != nil {
+			logging.Errorf("Can't build the schema: %v", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(schema))
+	},
+}
+
+func init() {
+	validateCmd.Flags().String("cfgfile", CFG_FILE, CFG_USAGE)
+	validateCmd.Flags().Bool("allow-unknown-keys", false, "Don't report unknown options, as Postee ignores them unless it's started with --strict-config.")
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(schemaCmd)
+}
+
+func validate(cfgfile string, allowUnknown bool) int {
+	//only problems with the configuration are printed
+	if err := logging.Configure("", "error"); err != nil {
+		logging.Errorf("Invalid logging settings: %v", err)
+	}
+	tenant, err := router.Parsev2cfg(cfgfile)
+	if err != nil {
+		return 1
+	}
+	//values are checked as Postee sees them, secret providers aren't called
+	errs := router.InterpolateLocal(tenant)
+	errs = append(errs, router.Validate(tenant)...)
+	if !allowUnknown {
+		//Parsev2cfg has already read the files, so they are readable
+		files, _ := router.ConfigFiles(cfgfile)
+		for _, file := range files {
+			data, _ := ioutil.ReadFile(file)
+			unknown, _ := router.UnknownKeys(data)
+			for _, key := range unknown {
+				if len(files) > 1 {
+					errs = append(errs, fmt.Errorf("unknown option %q in %s", key, file))
+				} else {
+					errs = append(errs, fmt.Errorf("unknown option %q", key))
+				}
+			}
+		}
+	}
+	if len(errs) == 0 {
+		fmt.Printf("%s is valid\n", cfgfile)
+		return 0
+	}
+	fmt.Printf("%s has %d error(s):\n", cfgfile, len(errs))
+	for _, err := range errs {
+		fmt.Printf("  %v\n", err)
+	}
+	return 1
+}
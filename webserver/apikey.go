@@ -0,0 +1,108 @@
+package webserver
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/aquasecurity/postee/v2/dbservice"
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/gorilla/mux"
+)
+
+const (
+	principalAdmin  = "admin"
+	principalTenant = "tenant:"
+)
+
+// statusRecorder keeps the status of a response for audit logs
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// withApiKey authenticates admin requests by the API key in "Authorization: Bearer <key>" header.
+// The key of the default configuration is a cross-tenant admin key, it's accepted by all endpoints including
+// the ones of every tenant. A key of a tenant is accepted only by endpoints of the tenant.
+// Every request is logged for audit with the owner of the key, the endpoint and the response status.
+func (ctx *WebServer) withApiKey(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		audit := logging.With("audit", true).With("remote", r.RemoteAddr)
+
+		key, fromQuery := requestApiKey(r)
+		if fromQuery {
+			audit.Warnf("API key is passed in the query of %s, it's deprecated as query strings are logged. Use 'Authorization: Bearer <key>' header", r.URL.Path)
+		}
+		principal := ctx.principal(r, key)
+		if principal == "" {
+			audit.Warnf("%s %s is rejected: invalid API key", r.Method, r.URL.Path)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r)
+		audit.With("principal", principal).Infof("%s %s is handled with status %d", r.Method, r.URL.Path, recorder.status)
+	}
+}
+
+// requestApiKey returns the key from Authorization header, or from ?key= parameter of older clients
+func requestApiKey(r *http.Request) (key string, fromQuery bool) {
+	const prefix = "bearer "
+	if auth := r.Header.Get("Authorization"); len(auth) > len(prefix) && strings.EqualFold(auth[:len(prefix)], prefix) {
+		return auth[len(prefix):], false
+	}
+	if key := r.URL.Query().Get("key"); key != "" {
+		return key, true
+	}
+	return "", false
+}
+
+// principal returns the owner of a key: the admin, a tenant or nobody if the key is invalid.
+// The admin key is checked first, so requests to endpoints of a tenant made with it are audited as the admin.
+func (ctx *WebServer) principal(r *http.Request, key string) string {
+	if key == "" {
+		return ""
+	}
+	adminKey, err := dbservice.GetApiKey()
+	if err != nil || adminKey == "" {
+		logging.Errorf("API key is either empty or there is an error: %v", err)
+	} else if equalKeys(key, adminKey) {
+		return principalAdmin
+	}
+
+	tenant, ok := mux.Vars(r)["tenant"]
+	if !ok {
+		return ""
+	}
+	tenantKey, err := ctx.target(r).Store().GetApiKey()
+	if err != nil || tenantKey == "" {
+		logging.Errorf("API key of tenant %q is either empty or there is an error: %v", tenant, err)
+		return ""
+	}
+	if equalKeys(key, tenantKey) {
+		return principalTenant + tenant
+	}
+	return ""
+}
+
+func equalKeys(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// rotateApiKey replaces the API key of the default configuration or of the tenant in the path.
+// The new key is returned once, the previous one stops working immediately.
+func (ctx *WebServer) rotateApiKey(w http.ResponseWriter, r *http.Request) {
+	key, err := ctx.target(r).Store().RotateApiKey()
+	if err != nil {
+		logging.Errorf("Unable to rotate API key: %v", err)
+		ctx.writeResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	ctx.writeResponse(w, http.StatusOK, map[string]string{"key": key})
+}
@@ -0,0 +1,171 @@
+package webserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aquasecurity/postee/v2/router"
+)
+
+const clientCAEnv = "POSTEE_TLS_CLIENT_CA"
+
+var errUnauthenticated = errors.New("message isn't authenticated")
+
+var signatureHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// authenticate returns the credential which a message is sent with. Nil credential without an error is returned
+// if the configuration has no credentials, so everyone can send messages. The signature of a signed message is
+// reserved, it has to be released if the message isn't accepted, so the sender may retry it as is.
+func (ctx *WebServer) authenticate(credentials []router.IngestCredential, r *http.Request, body []byte) (*router.IngestCredential, *signature, error) {
+	if len(credentials) == 0 {
+		return nil, nil, nil
+	}
+	for i := range credentials {
+		c := &credentials[i]
+		var ok bool
+		var sig *signature
+		switch c.Type {
+		case router.CredentialBearer:
+			ok = verifyBearer(c, r)
+		case router.CredentialHmac:
+			sig = verifySignature(c, r, body)
+			ok = sig != nil && ctx.signatures.reserve(sig)
+		case router.CredentialClientCert:
+			ok = verifyClientCert(c, r)
+		}
+		if ok {
+			return c, sig, nil
+		}
+	}
+	return nil, nil, errUnauthenticated
+}
+
+func verifyBearer(c *router.IngestCredential, r *http.Request) bool {
+	const prefix = "bearer "
+	auth := r.Header.Get("Authorization")
+	if c.Token == "" || len(auth) <= len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(auth[len(prefix):]), []byte(c.Token)) == 1
+}
+
+// verifySignature checks HMAC of the timestamp header, a dot and the body, the signature is hex encoded and may be
+// prefixed by the algorithm, e.g. "sha256=5d61...". Messages signed outside of the tolerance of the credential
+// are rejected. It returns the verified signature, nil if the message isn't signed with the credential.
+func verifySignature(c *router.IngestCredential, r *http.Request, body []byte) *signature {
+	newHash, ok := signatureHashes[c.SignatureAlgorithm()]
+	header := r.Header.Get(c.SignatureHeader())
+	timestamp := r.Header.Get(router.TimestampHeader)
+	if !ok || c.Secret == "" || header == "" || timestamp == "" {
+		return nil
+	}
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil
+	}
+	tolerance := c.SignatureTolerance()
+	if age := time.Since(time.Unix(signedAt, 0)); age > tolerance || age < -tolerance {
+		return nil
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(header, c.SignatureAlgorithm()+"="))
+	if err != nil {
+		return nil
+	}
+	mac := hmac.New(newHash, []byte(c.Secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return nil
+	}
+	return &signature{key: c.Name + ":" + hex.EncodeToString(got), expires: time.Unix(signedAt, 0).Add(tolerance)}
+}
+
+// signature is a verified signature of a message, it's kept until the message is too old to be accepted
+type signature struct {
+	key     string
+	expires time.Time
+}
+
+// signatureCache keeps signatures of accepted messages, so a captured request can't be replayed
+type signatureCache struct {
+	mutex   sync.Mutex
+	expires map[string]time.Time
+}
+
+func newSignatureCache() *signatureCache {
+	return &signatureCache{expires: map[string]time.Time{}}
+}
+
+// reserve reports whether the signature isn't used yet and keeps it until it expires or is released
+func (sc *signatureCache) reserve(s *signature) bool {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	now := time.Now()
+	for key, e := range sc.expires {
+		if now.After(e) {
+			delete(sc.expires, key)
+		}
+	}
+	if _, ok := sc.expires[s.key]; ok {
+		return false
+	}
+	sc.expires[s.key] = s.expires
+	return true
+}
+
+// release forgets the signature of a message which isn't accepted, nil signature is ignored
+func (sc *signatureCache) release(s *signature) {
+	if s == nil {
+		return
+	}
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	delete(sc.expires, s.key)
+}
+
+// verifyClientCert checks that the request is sent with a certificate which is verified by the TLS listener.
+// The common name of the certificate should match the subject of the credential, if any.
+func verifyClientCert(c *router.IngestCredential, r *http.Request) bool {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return false
+	}
+	return c.Subject == "" || r.TLS.VerifiedChains[0][0].Subject.CommonName == c.Subject
+}
+
+// tlsConfig makes the TLS listener verify client certificates signed by CAs from POSTEE_TLS_CLIENT_CA file.
+// Certificates are optional for the listener, credentials decide which messages require them.
+func tlsConfig() (*tls.Config, error) {
+	caFile := os.Getenv(clientCAEnv)
+	if caFile == "" {
+		return nil, nil
+	}
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates are found in %s", caFile)
+	}
+	return &tls.Config{ClientCAs: pool, ClientAuth: tls.VerifyClientCertIfGiven}, nil
+}
@@ -0,0 +1,80 @@
+package webserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aquasecurity/postee/v2/router"
+)
+
+func TestVerifySignature(t *testing.T) {
+	body := `{"image":"alpine:3.8"}`
+	sign := func(secret string, signedAt time.Time) (string, string) {
+		timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + "." + body))
+		return timestamp, "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		signedAt time.Time
+		omit     string
+		expected bool
+	}{
+		{"Valid signature", "secret", time.Now(), "", true},
+		{"Wrong secret", "another-secret", time.Now(), "", false},
+		{"Stale timestamp", "secret", time.Now().Add(-10 * time.Minute), "", false},
+		{"Future timestamp", "secret", time.Now().Add(10 * time.Minute), "", false},
+		{"Missing timestamp", "secret", time.Now(), router.TimestampHeader, false},
+		{"Missing signature", "secret", time.Now(), router.SignatureHeaderDefault, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &router.IngestCredential{Name: "aqua", Type: router.CredentialHmac, Secret: "secret"}
+			r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+			timestamp, signature := sign(test.secret, test.signedAt)
+			r.Header.Set(router.TimestampHeader, timestamp)
+			r.Header.Set(router.SignatureHeaderDefault, signature)
+			r.Header.Del(test.omit)
+
+			if got := verifySignature(c, r, []byte(body)) != nil; got != test.expected {
+				t.Errorf("Unexpected result, expected: %t, got: %t", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestVerifySignatureReplay(t *testing.T) {
+	ctx := &WebServer{signatures: newSignatureCache()}
+	body := []byte(`{"image":"alpine:3.8"}`)
+	credentials := []router.IngestCredential{{Name: "replayed", Type: router.CredentialHmac, Secret: "secret", Tolerance: "1m"}}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(credentials[0].Secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set(router.TimestampHeader, timestamp)
+	r.Header.Set(router.SignatureHeaderDefault, hex.EncodeToString(mac.Sum(nil)))
+	_, sig, err := ctx.authenticate(credentials, r, body)
+	if err != nil {
+		t.Fatalf("The signature isn't verified: %v", err)
+	}
+	if _, _, err := ctx.authenticate(credentials, r, body); err != errUnauthenticated {
+		t.Error("The replayed request is accepted")
+	}
+
+	//the message is rejected by the router, so it may be retried as is
+	ctx.signatures.release(sig)
+	if _, _, err := ctx.authenticate(credentials, r, body); err != nil {
+		t.Errorf("The retried request is rejected: %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package webserver
+
+import (
+	"net/http"
+
+	"github.com/aquasecurity/postee/v2/delivery"
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/gorilla/mux"
+)
+
+func (ctx *WebServer) listDeadLetters(w http.ResponseWriter, r *http.Request) {
+	messages, err := ctx.target(r).Store().GetDeadLetters()
+	if err != nil {
+		logging.Errorf("Unable to load dead letters: %v", err)
+		ctx.writeResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	ctx.writeResponse(w, http.StatusOK, messages)
+}
+
+func (ctx *WebServer) getDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	msg, err := ctx.target(r).Store().GetDeadLetter(id)
+	if err != nil {
+		logging.Errorf("Unable to load dead letter %s: %v", id, err)
+		ctx.writeResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if msg == nil {
+		ctx.writeResponse(w, http.StatusNotFound, delivery.ErrNotFound.Error())
+		return
+	}
+	ctx.writeResponse(w, http.StatusOK, msg)
+}
+
+func (ctx *WebServer) replayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	err := ctx.target(r).Dispatcher().Replay(id)
+	if err == delivery.ErrNotFound {
+		ctx.writeResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		logging.Errorf("Unable to replay dead letter %s: %v", id, err)
+		ctx.writeResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	ctx.writeResponse(w, http.StatusAccepted, map[string]int{"replayed": 1})
+}
+
+func (ctx *WebServer) replayDeadLetters(w http.ResponseWriter, r *http.Request) {
+	replayed, err := ctx.target(r).Dispatcher().ReplayAll(r.URL.Query().Get("output"))
+	if err != nil {
+		logging.Errorf("Unable to replay dead letters: %v", err)
+		ctx.writeResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	ctx.writeResponse(w, http.StatusAccepted, map[string]int{"replayed": replayed})
+}
+
+func (ctx *WebServer) removeDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	store := ctx.target(r).Store()
+	msg, err := store.GetDeadLetter(id)
+	if err != nil {
+		logging.Errorf("Unable to load dead letter %s: %v", id, err)
+		ctx.writeResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if msg == nil {
+		ctx.writeResponse(w, http.StatusNotFound, delivery.ErrNotFound.Error())
+		return
+	}
+	if err := store.RemoveDeadLetter(id); err != nil {
+		logging.Errorf("Unable to remove dead letter %s: %v", id, err)
+		ctx.writeResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	ctx.writeResponse(w, http.StatusOK, map[string]int{"purged": 1})
+}
+
+func (ctx *WebServer) purgeDeadLetters(w http.ResponseWriter, r *http.Request) {
+	purged, err := ctx.target(r).Store().PurgeDeadLetters()
+	if err != nil {
+		logging.Errorf("Unable to purge dead letters: %v", err)
+		ctx.writeResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	logging.Infof("%d dead letter(s) purged", purged)
+	ctx.writeResponse(w, http.StatusOK, map[string]int{"purged": purged})
+}
@@ -0,0 +1,17 @@
+package webserver
+
+import (
+	"net/http"
+
+	"github.com/aquasecurity/postee/v2/logging"
+)
+
+func (ctx *WebServer) outputsHealth(w http.ResponseWriter, r *http.Request) {
+	health, err := ctx.target(r).Dispatcher().Health()
+	if err != nil {
+		logging.Errorf("Unable to get health of outputs: %v", err)
+		ctx.writeResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	ctx.writeResponse(w, http.StatusOK, health)
+}
@@ -0,0 +1,155 @@
+package webserver
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/aquasecurity/postee/v2/router"
+	"github.com/gorilla/mux"
+)
+
+const (
+	retryAfterSeconds   = "1"
+	correlationIdHeader = "X-Correlation-ID"
+	maxCorrelationIdLen = 64
+)
+
+type ingestResponse struct {
+	CorrelationId string               `json:"correlation-id"`
+	Routes        []router.RouteResult `json:"routes"`
+}
+
+// ingest passes a message to the router. The route is empty to handle the message by all routes,
+// or by the routes which the credential of the message may trigger.
+// With ?wait=true the response is sent after all routes handled the message and contains their results.
+func (ctx *WebServer) ingest(w http.ResponseWriter, r *http.Request, route string) {
+	correlationId := getCorrelationId(r)
+	w.Header().Set(correlationIdHeader, correlationId)
+	logger := logging.With(logging.CorrelationIdKey, correlationId)
+	if tenant := mux.Vars(r)["tenant"]; tenant != "" {
+		logger = logger.With(logging.TenantKey, tenant)
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logger.Errorf("Failed ioutil.ReadAll: %s", err)
+		ctx.writeResponseError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer r.Body.Close()
+
+	target := ctx.target(r)
+	credential, sig, err := ctx.authenticate(target.IngestCredentials(), r, body)
+	if err != nil {
+		logger.Warnf("Message from %s is rejected: %v", r.RemoteAddr, err)
+		ctx.writeResponse(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	accepted := false
+	defer func() {
+		//a rejected message may be sent again with the same signature, e.g. after Retry-After
+		if !accepted {
+			ctx.signatures.release(sig)
+		}
+	}()
+	scope := func(c context.Context) context.Context {
+		return logging.WithCorrelationId(c, correlationId)
+	}
+	if credential != nil {
+		logger = logger.With("credential", credential.Name)
+		if len(credential.Routes) > 0 {
+			scope = func(c context.Context) context.Context {
+				return router.WithAllowedRoutes(logging.WithCorrelationId(c, correlationId), credential.Routes)
+			}
+		}
+	}
+	logger.Debugf("Message is received from %s: %s", r.RemoteAddr, body)
+
+	if !json.Valid(body) {
+		logger.Warnf("Received message isn't a valid json")
+		ctx.writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	wait := false
+	if v := r.URL.Query().Get("wait"); v != "" {
+		wait, err = strconv.ParseBool(v)
+		if err != nil {
+			ctx.writeResponse(w, http.StatusBadRequest, "invalid value of wait parameter")
+			return
+		}
+	}
+
+	if !wait {
+		c := scope(context.Background())
+		if route == "" {
+			err = target.Send(c, body)
+		} else {
+			err = target.SendToRoute(c, route, body)
+		}
+		if err != nil {
+			ctx.writeRoutingError(w, logger, err)
+			return
+		}
+		accepted = true
+		ctx.writeResponse(w, http.StatusOK, "")
+		return
+	}
+
+	results, err := target.SendAndWait(scope(r.Context()), route, body)
+	accepted = !isRejected(err)
+	if err != nil {
+		ctx.writeRoutingError(w, logger, err)
+		return
+	}
+	ctx.writeResponse(w, http.StatusOK, ingestResponse{CorrelationId: correlationId, Routes: results})
+}
+
+func (ctx *WebServer) writeRoutingError(w http.ResponseWriter, logger *logging.Logger, err error) {
+	switch err {
+	case router.ErrUnknownRoute:
+		logger.Warnf("Message is rejected: %v", err)
+		ctx.writeResponse(w, http.StatusNotFound, err.Error())
+	case router.ErrForbidden:
+		logger.Warnf("Message is rejected: %v", err)
+		ctx.writeResponse(w, http.StatusForbidden, err.Error())
+	case router.ErrQueueFull:
+		logger.Warnf("Message is rejected: %v", err)
+		w.Header().Set("Retry-After", retryAfterSeconds)
+		ctx.writeResponse(w, http.StatusTooManyRequests, err.Error())
+	case router.ErrNotRunning:
+		logger.Warnf("Message is rejected: %v", err)
+		w.Header().Set("Retry-After", retryAfterSeconds)
+		ctx.writeResponse(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		logger.Errorf("Message handling failed: %v", err)
+		ctx.writeResponse(w, http.StatusServiceUnavailable, err.Error())
+	}
+}
+
+// isRejected reports whether the router rejected a message without handling it
+func isRejected(err error) bool {
+	switch err {
+	case router.ErrUnknownRoute, router.ErrForbidden, router.ErrQueueFull, router.ErrNotRunning:
+		return true
+	}
+	return false
+}
+
+// getCorrelationId returns the id passed by the sender in X-Correlation-ID header, or a new one
+func getCorrelationId(r *http.Request) string {
+	id := r.Header.Get(correlationIdHeader)
+	if id == "" || len(id) > maxCorrelationIdLen {
+		return logging.NewCorrelationId()
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return logging.NewCorrelationId()
+		}
+	}
+	return id
+}
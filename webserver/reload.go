@@ -1,11 +1,9 @@
 package webserver
 
-import (
-	"net/http"
-
-	"github.com/aquasecurity/postee/v2/router"
-)
+import "net/http"
 
 func (web *WebServer) reload(w http.ResponseWriter, r *http.Request) {
-	router.Instance().ReloadConfig()
+	if err := web.target(r).ReloadConfig(); err != nil {
+		web.writeResponse(w, http.StatusInternalServerError, err.Error())
+	}
 }
@@ -0,0 +1,11 @@
+package webserver
+
+import (
+	"net/http"
+
+	"github.com/aquasecurity/postee/v2/router"
+)
+
+func (ctx *WebServer) configSchema(w http.ResponseWriter, r *http.Request) {
+	ctx.writeResponse(w, http.StatusOK, router.Schema())
+}
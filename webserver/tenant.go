@@ -1,32 +1,45 @@
 package webserver
 
 import (
-	"io/ioutil"
-	"log"
 	"net/http"
 
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/aquasecurity/postee/v2/metrics"
 	"github.com/aquasecurity/postee/v2/router"
-	"github.com/aquasecurity/postee/v2/utils"
 	"github.com/gorilla/mux"
 )
 
 func (ctx *WebServer) tenantHandler(w http.ResponseWriter, r *http.Request) {
 	route, ok := mux.Vars(r)["route"]
 	if !ok || len(route) == 0 {
-		log.Printf("Failed route: %q", route)
+		logging.Warnf("Failed route: %q", route)
 		ctx.writeResponse(w, http.StatusBadRequest, "failed route")
 		return
 	}
+	ctx.ingest(w, r, route)
+}
 
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		log.Printf("Failed ioutil.ReadAll: %s", err)
-		ctx.writeResponseError(w, http.StatusInternalServerError, err)
-		return
+// withTenant rejects requests to tenants which aren't started
+func (ctx *WebServer) withTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if router.GetTenant(mux.Vars(r)["tenant"]) == nil {
+			logging.Warnf("Request to unknown tenant %q", mux.Vars(r)["tenant"])
+			ctx.writeResponse(w, http.StatusNotFound, "tenant isn't defined")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// target returns the router of the tenant in the request path, or the default router
+func (ctx *WebServer) target(r *http.Request) *router.Router {
+	if tenant, ok := mux.Vars(r)["tenant"]; ok {
+		return router.GetTenant(tenant)
 	}
+	return router.Instance()
+}
 
-	defer r.Body.Close()
-	utils.Debug("%s\n\n", string(body))
-	router.Instance().HandleRoute(route, body)
-	ctx.writeResponse(w, http.StatusOK, "")
+// tenantMetrics serves metrics of the tenant in the request path only
+func (ctx *WebServer) tenantMetrics(w http.ResponseWriter, r *http.Request) {
+	metrics.TenantHandler(mux.Vars(r)["tenant"]).ServeHTTP(w, r)
 }
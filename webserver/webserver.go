@@ -2,7 +2,6 @@ package webserver
 
 import (
 	"encoding/json"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
@@ -10,14 +9,16 @@ import (
 	"sync"
 
 	"github.com/aquasecurity/postee/v2/dbservice"
-	"github.com/aquasecurity/postee/v2/router"
+	"github.com/aquasecurity/postee/v2/logging"
+	"github.com/aquasecurity/postee/v2/metrics"
 	"github.com/aquasecurity/postee/v2/utils"
 	"github.com/gorilla/mux"
 )
 
 type WebServer struct {
-	quit   chan struct{}
-	router *mux.Router
+	quit       chan struct{}
+	router     *mux.Router
+	signatures *signatureCache //signatures of accepted messages
 }
 
 var initCtx sync.Once
@@ -26,33 +27,15 @@ var wsCtx *WebServer
 func Instance() *WebServer {
 	initCtx.Do(func() {
 		wsCtx = &WebServer{
-			quit:   make(chan struct{}),
-			router: mux.NewRouter().StrictSlash(true),
+			quit:       make(chan struct{}),
+			router:     mux.NewRouter().StrictSlash(true),
+			signatures: newSignatureCache(),
 		}
 	})
 	return wsCtx
 }
-func (ctx *WebServer) withApiKey(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		correctKey, err := dbservice.GetApiKey()
-
-		if err != nil || correctKey == "" {
-			log.Printf("reload API key is either empty or there is an error: %s \n", err)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		}
-
-		if key := r.URL.Query().Get("key"); key != correctKey {
-			log.Printf("reload API received an incorrect key %q", key)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
-
-		next(w, r)
-	}
-}
-
 func (ctx *WebServer) Start(host, tlshost string) {
-	log.Printf("Starting WebServer....")
+	logging.Infof("Starting WebServer....")
 
 	rootDir, _ := utils.GetRootDir()
 	certPem := filepath.Join(rootDir, "cert.pem")
@@ -61,7 +44,7 @@ func (ctx *WebServer) Start(host, tlshost string) {
 	if ok := utils.PathExists(keyPem); ok != true {
 		err := utils.GenerateCertificate(keyPem, certPem)
 		if err != nil {
-			log.Printf("GenerateCertificate error: %v \n", err)
+			logging.Errorf("GenerateCertificate error: %v", err)
 		}
 	}
 
@@ -72,30 +55,64 @@ func (ctx *WebServer) Start(host, tlshost string) {
 	if os.Getenv("AQUAALERT_KEY_PEM") != "" {
 		keyPem = os.Getenv("AQUAALERT_KEY_PEM")
 	}
-	err := dbservice.EnsureApiKey()
+	clientTls, err := tlsConfig()
+	if err != nil {
+		logging.Errorf("Client certificates can't be verified, %s is ignored: %v", clientCAEnv, err)
+	}
+	err = dbservice.EnsureApiKey()
 	if err != nil {
-		log.Printf("EnsureApiKey error: %v \n", err)
+		logging.Errorf("EnsureApiKey error: %v", err)
 	}
 
 	ctx.router.HandleFunc("/", ctx.sessionHandler(ctx.scanHandler)).Methods("POST")
 	ctx.router.HandleFunc("/tenant/{route}", ctx.sessionHandler(ctx.tenantHandler)).Methods("POST")
 	ctx.router.HandleFunc("/scan", ctx.sessionHandler(ctx.scanHandler)).Methods("POST")
 	ctx.router.HandleFunc("/ping", ctx.sessionHandler(ctx.pingHandler)).Methods("GET")
+	ctx.router.Handle("/metrics", metrics.Handler()).Methods("GET")
+	ctx.router.HandleFunc("/config/schema", ctx.configSchema).Methods("GET")
 
 	ctx.router.HandleFunc("/reload", ctx.withApiKey(ctx.reload)).Methods("GET")
 
+	ctx.router.HandleFunc("/deadletters", ctx.withApiKey(ctx.listDeadLetters)).Methods("GET")
+	ctx.router.HandleFunc("/deadletters", ctx.withApiKey(ctx.purgeDeadLetters)).Methods("DELETE")
+	ctx.router.HandleFunc("/deadletters/replay", ctx.withApiKey(ctx.replayDeadLetters)).Methods("POST")
+	ctx.router.HandleFunc("/deadletters/{id}", ctx.withApiKey(ctx.getDeadLetter)).Methods("GET")
+	ctx.router.HandleFunc("/deadletters/{id}", ctx.withApiKey(ctx.removeDeadLetter)).Methods("DELETE")
+	ctx.router.HandleFunc("/deadletters/{id}/replay", ctx.withApiKey(ctx.replayDeadLetter)).Methods("POST")
+
+	ctx.router.HandleFunc("/outputs/health", ctx.withApiKey(ctx.outputsHealth)).Methods("GET")
+
+	ctx.router.HandleFunc("/apikey/rotate", ctx.withApiKey(ctx.rotateApiKey)).Methods("POST")
+
+	tenants := ctx.router.PathPrefix("/tenants/{tenant}").Subrouter()
+	tenants.Use(ctx.withTenant)
+	tenants.HandleFunc("", ctx.sessionHandler(ctx.scanHandler)).Methods("POST")
+	tenants.HandleFunc("/scan", ctx.sessionHandler(ctx.scanHandler)).Methods("POST")
+	tenants.HandleFunc("/routes/{route}", ctx.sessionHandler(ctx.tenantHandler)).Methods("POST")
+	tenants.HandleFunc("/reload", ctx.withApiKey(ctx.reload)).Methods("GET")
+	tenants.HandleFunc("/deadletters", ctx.withApiKey(ctx.listDeadLetters)).Methods("GET")
+	tenants.HandleFunc("/deadletters", ctx.withApiKey(ctx.purgeDeadLetters)).Methods("DELETE")
+	tenants.HandleFunc("/deadletters/replay", ctx.withApiKey(ctx.replayDeadLetters)).Methods("POST")
+	tenants.HandleFunc("/deadletters/{id}", ctx.withApiKey(ctx.getDeadLetter)).Methods("GET")
+	tenants.HandleFunc("/deadletters/{id}", ctx.withApiKey(ctx.removeDeadLetter)).Methods("DELETE")
+	tenants.HandleFunc("/deadletters/{id}/replay", ctx.withApiKey(ctx.replayDeadLetter)).Methods("POST")
+	tenants.HandleFunc("/outputs/health", ctx.withApiKey(ctx.outputsHealth)).Methods("GET")
+	tenants.HandleFunc("/metrics", ctx.withApiKey(ctx.tenantMetrics)).Methods("GET")
+	tenants.HandleFunc("/apikey/rotate", ctx.withApiKey(ctx.rotateApiKey)).Methods("POST")
+
 	go func() {
-		log.Printf("Listening for HTTP on %s ", host)
+		logging.Infof("Listening for HTTP on %s", host)
 		log.Fatal(http.ListenAndServe(host, ctx.router))
 	}()
 	go func() {
-		log.Printf("Listening for HTTPS on %s", tlshost)
-		log.Fatal(http.ListenAndServeTLS(tlshost, certPem, keyPem, ctx.router))
+		logging.Infof("Listening for HTTPS on %s", tlshost)
+		server := &http.Server{Addr: tlshost, Handler: ctx.router, TLSConfig: clientTls}
+		log.Fatal(server.ListenAndServeTLS(certPem, keyPem))
 	}()
 }
 
 func (ctx *WebServer) Terminate() {
-	log.Printf("Terminating WebServer....")
+	logging.Infof("Terminating WebServer....")
 	close(ctx.quit)
 }
 
@@ -106,17 +123,7 @@ func (ctx *WebServer) sessionHandler(f func(http.ResponseWriter, *http.Request))
 }
 
 func (ctx *WebServer) scanHandler(w http.ResponseWriter, r *http.Request) {
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		log.Printf("Failed ioutil.ReadAll: %s\n", err)
-		ctx.writeResponseError(w, http.StatusInternalServerError, err)
-		return
-	}
-
-	defer r.Body.Close()
-	utils.Debug("%s\n\n", string(body))
-	router.Instance().Send(body)
-	ctx.writeResponse(w, http.StatusOK, "")
+	ctx.ingest(w, r, "")
 }
 
 func (ctx *WebServer) pingHandler(w http.ResponseWriter, r *http.Request) {
@@ -130,7 +137,7 @@ func (ctx *WebServer) writeResponse(w http.ResponseWriter, httpStatus int, v int
 		result, _ := json.Marshal(v)
 		_, err := w.Write(result)
 		if err != nil {
-			log.Printf("Write error: %s \n", err)
+			logging.Errorf("Write error: %s", err)
 		}
 	}
 }
@@ -140,6 +147,6 @@ func (ctx *WebServer) writeResponseError(w http.ResponseWriter, httpError int, e
 	w.WriteHeader(httpError)
 	errEncode := json.NewEncoder(w).Encode(err)
 	if errEncode != nil {
-		log.Printf("Encode error: %s \n", errEncode)
+		logging.Errorf("Encode error: %s", errEncode)
 	}
 }